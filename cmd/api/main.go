@@ -2,49 +2,112 @@ package main
 
 import (
 	"context"
-	"fmt"
+	"flag"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
 	"os/signal"
 	"syscall"
 	"time"
 
+	"AuthServer/internal/app"
+	"AuthServer/internal/config"
+	"AuthServer/internal/logging"
+	"AuthServer/internal/scheduler"
 	"AuthServer/internal/server"
+	"AuthServer/internal/tracing"
+
+	"github.com/gin-gonic/gin"
 )
 
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
+func gracefulShutdown(apiServer *http.Server, jobs *scheduler.Scheduler, done chan bool) {
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
 
 	<-ctx.Done()
 
-	log.Println("shutting down gracefully, press Ctrl+C again to force")
+	slog.Info("shutting down gracefully, press Ctrl+C again to force")
 	stop()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 	if err := apiServer.Shutdown(ctx); err != nil {
-		log.Printf("Server forced to shutdown with error: %v", err)
+		slog.Error("server forced to shut down", "error", err)
+	}
+
+	// Stop background jobs after in-flight requests are done
+	if err := jobs.Stop(ctx); err != nil {
+		slog.Error("scheduler forced to stop", "error", err)
 	}
 
-	log.Println("Server exiting")
+	slog.Info("server exiting")
 
 	done <- true
 }
 
 func main() {
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	printConfig := fs.Bool("print-config", false, "print the effective configuration, with secrets redacted, and exit")
+
+	cfg, err := config.Load(fs, os.Args[1:])
+	if err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("failed to print configuration: %v", err)
+		}
+		return
+	}
+	if err := logging.Setup(cfg.Log); err != nil {
+		log.Fatalf("invalid configuration: %v", err)
+	}
+	// Gin's own route and request logging would bypass the structured logger
+	if level, _ := logging.ParseLevel(cfg.Log.Level); level > slog.LevelDebug {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	tp, shutdownTracing, err := tracing.NewProvider(context.Background(), cfg.Tracing)
+	if err != nil {
+		fatal("failed to set up tracing", err)
+	}
 
-	server := server.NewServer()
+	a, err := app.New(cfg, tp)
+	if err != nil {
+		fatal("failed to open database", err)
+	}
+	defer a.Close()
+
+	server, jobs, err := server.NewServer(a)
+	if err != nil {
+		fatal("failed to start server", err)
+	}
 
 	done := make(chan bool, 1)
 
-	go gracefulShutdown(server, done)
+	go gracefulShutdown(server, jobs, done)
 
-	err := server.ListenAndServe()
+	err = server.ListenAndServe()
 	if err != nil && err != http.ErrServerClosed {
-		panic(fmt.Sprintf("http server error: %s", err))
+		fatal("http server error", err)
 	}
 
 	<-done
-	log.Println("Graceful shutdown complete.")
+
+	// Export the spans still buffered
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := shutdownTracing(ctx); err != nil {
+		slog.Error("failed to flush traces", "error", err)
+	}
+	slog.Info("graceful shutdown complete")
+}
+
+func fatal(msg string, err error) {
+	slog.Error(msg, "error", err)
+	os.Exit(1)
 }
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"AuthServer/internal/app"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/service"
+)
+
+// audit checks the integrity of the audit log. verify returns status 1 when the chain is
+// broken. Passing checkpoints exported earlier verifies the log against copies kept outside
+// the database, rather than the stored ones.
+func audit(ctx context.Context, a *app.App, args []string) int {
+	cmd, args := subcommand(args, "audit verify [-checkpoints file] | checkpoints [-o file] | checkpoint")
+	audit := a.Services.Audit
+
+	switch cmd {
+	case "verify":
+		fs := flag.NewFlagSet("audit verify", flag.ExitOnError)
+		from := fs.String("checkpoints", "", "verify against checkpoints exported to this file")
+		fs.Parse(args)
+		return verify(ctx, audit, *from)
+	case "checkpoints":
+		fs := flag.NewFlagSet("audit checkpoints", flag.ExitOnError)
+		out := fs.String("o", "", "write to this file instead of stdout")
+		fs.Parse(args)
+		exportCheckpoints(ctx, audit, *out)
+	case "checkpoint":
+		cp, err := audit.Checkpoint(ctx)
+		if err != nil {
+			log.Fatalf("failed to create checkpoint: %v", err)
+		}
+		if cp == nil {
+			fmt.Println("no audit events since the last checkpoint")
+			return 0
+		}
+		fmt.Printf("checkpoint %d signed event %d (%s)\n", cp.ID, cp.EventID, cp.EventHash)
+	default:
+		usage()
+	}
+	return 0
+}
+
+func verify(ctx context.Context, audit *service.AuditService, from string) int {
+	var (
+		result *models.AuditVerification
+		err    error
+	)
+	if from == "" {
+		result, err = audit.Verify(ctx)
+	} else {
+		var checkpoints []models.AuditCheckpoint
+		checkpoints, err = readCheckpoints(from)
+		if err != nil {
+			log.Fatalf("failed to read checkpoints: %v", err)
+		}
+		result, err = audit.VerifyAgainst(ctx, checkpoints)
+	}
+	if err != nil {
+		log.Fatalf("failed to verify audit chain: %v", err)
+	}
+
+	fmt.Printf("events checked: %d (%d written before chaining)\n", result.EventsChecked, result.UnchainedEvents)
+	fmt.Printf("checkpoints checked: %d\n", result.CheckpointsChecked)
+	if result.OK {
+		fmt.Println("audit chain OK")
+		return 0
+	}
+
+	if result.CheckpointID != 0 {
+		fmt.Printf("BROKEN at event %d (checkpoint %d): %s\n", result.BrokenAt, result.CheckpointID, result.Problem)
+	} else {
+		fmt.Printf("BROKEN at event %d: %s\n", result.BrokenAt, result.Problem)
+	}
+	return 1
+}
+
+// readCheckpoints accepts either a bare JSON array or the {"data": [...]} body returned by
+// GET /api/audit/checkpoints.
+func readCheckpoints(path string) ([]models.AuditCheckpoint, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var checkpoints []models.AuditCheckpoint
+	if err := json.Unmarshal(b, &checkpoints); err == nil {
+		return checkpoints, nil
+	}
+	var body struct {
+		Data []models.AuditCheckpoint `json:"data"`
+	}
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil, err
+	}
+	return body.Data, nil
+}
+
+func exportCheckpoints(ctx context.Context, audit *service.AuditService, path string) {
+	checkpoints, err := audit.Checkpoints(ctx)
+	if err != nil {
+		log.Fatalf("failed to list checkpoints: %v", err)
+	}
+	if checkpoints == nil {
+		checkpoints = []models.AuditCheckpoint{}
+	}
+
+	if err := writeJSON(path, checkpoints, 0o644); err != nil {
+		log.Fatalf("failed to write checkpoints: %v", err)
+	}
+}
+
+// writeJSON writes v as indented JSON to the file at path, created with perm, or to stdout
+// when path is empty.
+func writeJSON(path string, v any, perm os.FileMode) error {
+	var w io.Writer = os.Stdout
+	if path != "" {
+		f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"AuthServer/internal/app"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/service"
+)
+
+func keys(ctx context.Context, a *app.App, args []string) {
+	cmd, _ := subcommand(args, "keys rotate | list")
+	switch cmd {
+	case "rotate":
+		key, err := a.Services.SigningKeys.Rotate(ctx)
+		if err != nil {
+			log.Fatalf("failed to rotate signing key: %v", err)
+		}
+		record(ctx, a, models.AuditEvent{
+			Action:     models.AuditSigningKeyRotate,
+			TargetType: models.AuditTargetSigningKey,
+			TargetID:   key.ID,
+		})
+		fmt.Printf("new signing key %s; servers switch to it within %s\n", key.ID, service.SigningKeyRefreshInterval)
+	case "list":
+		list, err := a.Services.SigningKeys.List(ctx)
+		if err != nil {
+			log.Fatalf("failed to list signing keys: %v", err)
+		}
+		if len(list) == 0 {
+			fmt.Println("no stored keys; tokens are signed with the configured secret")
+			return
+		}
+
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ID\tCREATED\tRETIRED")
+		for _, k := range list {
+			retired := "-"
+			if k.RetiredAt != nil {
+				retired = k.RetiredAt.Format(time.RFC3339)
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", k.ID, k.CreatedAt.Format(time.RFC3339), retired)
+		}
+		w.Flush()
+	default:
+		usage()
+	}
+}
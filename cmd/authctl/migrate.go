@@ -0,0 +1,87 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+
+	"AuthServer/internal/app"
+	"AuthServer/internal/database"
+	"AuthServer/internal/repository"
+
+	"github.com/golang-migrate/migrate/v4"
+)
+
+// migrateCmd manages the embedded migrations. force only records a version, for recovering
+// from a migration that failed part way; fix the schema by hand first.
+func migrateCmd(ctx context.Context, a *app.App, args []string) {
+	cmd, args := subcommand(args, "migrate up | down [n] | goto <version> | version | force <version> | check")
+
+	if cmd == "check" {
+		if err := database.CheckSchema(ctx, a.DB.DB(), repository.Schema); err != nil {
+			log.Fatal(err)
+		}
+		fmt.Println("schema matches the repositories")
+		return
+	}
+
+	m, err := database.NewMigrator(a.DB.DB())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	switch cmd {
+	case "up":
+		err = m.Up()
+	case "down":
+		// One step by default; rolling back everything has to be asked for explicitly
+		steps := 1
+		if len(args) > 0 {
+			steps = number(args[0])
+		}
+		if steps < 1 {
+			log.Fatalf("n must be at least 1")
+		}
+		err = m.Steps(-steps)
+	case "goto":
+		if len(args) != 1 {
+			log.Fatalf("usage: authctl migrate goto <version>")
+		}
+		err = m.Migrate(uint(number(args[0])))
+	case "force":
+		if len(args) != 1 {
+			log.Fatalf("usage: authctl migrate force <version>")
+		}
+		err = m.Force(number(args[0]))
+	case "version":
+	default:
+		usage()
+	}
+	if errors.Is(err, migrate.ErrNoChange) {
+		fmt.Println("no change")
+	} else if err != nil {
+		log.Fatalf("migration failed: %v", err)
+	}
+
+	version, dirty, err := m.Version()
+	switch {
+	case errors.Is(err, migrate.ErrNilVersion):
+		fmt.Println("no migrations applied")
+	case err != nil:
+		log.Fatalf("failed to read migration version: %v", err)
+	case dirty:
+		fmt.Printf("version %d (dirty: fix the schema, then run authctl migrate force %d)\n", version, version)
+	default:
+		fmt.Printf("version %d\n", version)
+	}
+}
+
+func number(arg string) int {
+	n, err := strconv.Atoi(arg)
+	if err != nil || n < 0 {
+		log.Fatalf("%q is not a non-negative number", arg)
+	}
+	return n
+}
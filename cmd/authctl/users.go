@@ -0,0 +1,172 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"AuthServer/internal/app"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/service"
+)
+
+// bootstrapActor is recorded as the assigner of the first admin role.
+const bootstrapActor = "system:bootstrap"
+
+// userFlags are the flags shared by bootstrap-admin and users create.
+type userFlags struct {
+	fs            *flag.FlagSet
+	fullName      *string
+	username      *string
+	email         *string
+	passwordStdin *bool
+}
+
+func newUserFlags(name string) userFlags {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	return userFlags{
+		fs:            fs,
+		fullName:      fs.String("full-name", "", "full name"),
+		username:      fs.String("username", "", "username (required)"),
+		email:         fs.String("email", "", "email address (required)"),
+		passwordStdin: fs.Bool("password-stdin", false, "read the password from the first line of stdin instead of generating one"),
+	}
+}
+
+// createUser parses the flags and creates the user, printing a generated password.
+func (f userFlags) createUser(ctx context.Context, a *app.App, args []string) *models.User {
+	f.fs.Parse(args)
+	if *f.username == "" || *f.email == "" {
+		f.fs.Usage()
+		os.Exit(2)
+	}
+
+	password, generated := readPassword(*f.passwordStdin)
+	user, err := a.Services.User.Create(ctx, *f.fullName, *f.username, *f.email, password)
+	if err != nil {
+		log.Fatalf("failed to create user: %v", err)
+	}
+	record(ctx, a, models.AuditEvent{
+		Action:     models.AuditUserCreate,
+		TargetType: models.AuditTargetUser,
+		TargetID:   user.ID,
+		After:      service.AuditState(user),
+	})
+
+	fmt.Printf("created user %s (%s)\n", user.ID, user.Username)
+	if generated {
+		fmt.Printf("password: %s\n", password)
+	}
+	return user
+}
+
+// readPassword reads the password from stdin, or generates one when fromStdin is false. It
+// reports whether the password was generated.
+func readPassword(fromStdin bool) (string, bool) {
+	if !fromStdin {
+		b := make([]byte, 18)
+		if _, err := rand.Read(b); err != nil {
+			log.Fatalf("failed to generate password: %v", err)
+		}
+		return base64.RawURLEncoding.EncodeToString(b), true
+	}
+
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		log.Fatalf("failed to read password from stdin: %v", err)
+	}
+	return line, false
+}
+
+// bootstrapAdmin creates the first admin. It refuses once anyone holds the admin role, so it
+// cannot be used to bypass the normal assignment checks later.
+func bootstrapAdmin(ctx context.Context, a *app.App, args []string) {
+	admins, err := a.Repositories.UserRoles.CountGlobalHolders(ctx, roles.RoleAdmin)
+	if err != nil {
+		log.Fatalf("failed to check for existing admins: %v", err)
+	}
+	if admins > 0 {
+		log.Fatalf("an admin already exists; assign further admins with authctl roles assign")
+	}
+
+	user := newUserFlags("bootstrap-admin").createUser(ctx, a, args)
+	assignRole(ctx, a, roles.UserRole{UserID: user.ID, Role: roles.RoleAdmin}, bootstrapActor)
+}
+
+func users(ctx context.Context, a *app.App, args []string) {
+	cmd, args := subcommand(args, "users create | list | disable <user-id> | enable <user-id>")
+	switch cmd {
+	case "create":
+		newUserFlags("users create").createUser(ctx, a, args)
+	case "list":
+		listUsers(ctx, a)
+	case "disable":
+		setDisabled(ctx, a, args, true)
+	case "enable":
+		setDisabled(ctx, a, args, false)
+	default:
+		usage()
+	}
+}
+
+func listUsers(ctx context.Context, a *app.App) {
+	list, err := a.Services.User.List(ctx)
+	if err != nil {
+		log.Fatalf("failed to list users: %v", err)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tUSERNAME\tEMAIL\tCREATED\tDISABLED")
+	for _, u := range list {
+		disabled := "-"
+		if u.DisabledAt != nil {
+			disabled = u.DisabledAt.Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", u.ID, u.Username, u.Email, u.CreatedAt.Format(time.RFC3339), disabled)
+	}
+	w.Flush()
+}
+
+func setDisabled(ctx context.Context, a *app.App, args []string, disable bool) {
+	if len(args) != 1 {
+		log.Fatalf("usage: authctl users disable|enable <user-id>")
+	}
+	id := args[0]
+
+	before, err := a.Services.User.FindById(ctx, id)
+	if err != nil {
+		log.Fatalf("failed to find user: %v", err)
+	}
+
+	action, change := models.AuditUserEnable, a.Services.User.Enable
+	if disable {
+		action, change = models.AuditUserDisable, a.Services.User.Disable
+	}
+	after, err := change(ctx, id)
+	if err != nil {
+		log.Fatalf("failed to update user: %v", err)
+	}
+	record(ctx, a, models.AuditEvent{
+		Action:     action,
+		TargetType: models.AuditTargetUser,
+		TargetID:   id,
+		Before:     service.AuditState(before),
+		After:      service.AuditState(after),
+	})
+
+	if disable {
+		fmt.Printf("disabled user %s\n", id)
+	} else {
+		fmt.Printf("enabled user %s\n", id)
+	}
+}
@@ -0,0 +1,135 @@
+// Package app builds the server's object graph in order: configuration, database, metrics,
+// repositories, services. Everything hangs off an App rather than package variables, so
+// importing a package never opens a connection and several apps can run in one process. The
+// tracer provider is passed in, so tests can record spans.
+package app
+
+import (
+	"AuthServer/internal/config"
+	"AuthServer/internal/database"
+	"AuthServer/internal/events"
+	"AuthServer/internal/metrics"
+	"AuthServer/internal/repository"
+	domain "AuthServer/internal/service"
+	"AuthServer/internal/tracing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+type Repositories struct {
+	Users         repository.IUserRepository
+	UserRoles     *repository.UserRoleRepository
+	Projects      repository.IProjectRepository
+	JITRequests   *repository.JITRequestRepository
+	JITApprovals  *repository.JITApprovalRepository
+	Tuples        *repository.RelationTupleRepository
+	Groups        *repository.GroupRepository
+	SoDViolations *repository.SoDViolationRepository
+	BreakGlass    *repository.BreakGlassRepository
+	AccessReviews *repository.AccessReviewRepository
+	JobRuns       *repository.JobRunRepository
+	Audit         *repository.AuditRepository
+	Webhooks      *repository.WebhookRepository
+	SigningKeys   *repository.SigningKeyRepository
+}
+
+type Services struct {
+	Token         domain.ITokenService
+	Hash          domain.IHashService
+	User          domain.IUserService
+	Project       domain.IProjectService
+	SoD           *domain.SoDService
+	RBAC          *domain.RBACService
+	JIT           *domain.JITService
+	ReBAC         *domain.ReBACService
+	Group         *domain.GroupService
+	Mail          domain.IMailService
+	Audit         *domain.AuditService
+	Webhook       *domain.WebhookService
+	AccessReview  *domain.AccessReviewService
+	BreakGlass    *domain.BreakGlassService
+	ProjectMember *domain.ProjectMemberService
+	SigningKeys   *domain.SigningKeyService
+	Export        *domain.ExportService
+}
+
+type App struct {
+	Config       *config.Config
+	DB           database.Service
+	Events       *events.Bus
+	Metrics      *metrics.Metrics
+	Tracing      trace.TracerProvider
+	Repositories Repositories
+	Services     Services
+}
+
+// New opens the database described by cfg and builds the app on it, tracing with tp. cfg
+// must have been validated. The caller closes the app when done.
+func New(cfg *config.Config, tp trace.TracerProvider) (*App, error) {
+	db, err := database.Open(cfg.Database, tp)
+	if err != nil {
+		return nil, err
+	}
+	return NewWithDB(cfg, db, tp), nil
+}
+
+// NewWithDB builds the app on an already open database.
+func NewWithDB(cfg *config.Config, db database.Service, tp trace.TracerProvider) *App {
+	a := &App{
+		Config:  cfg,
+		DB:      db,
+		Events:  events.NewBus(events.DefaultHistorySize),
+		Metrics: metrics.New(),
+		Tracing: tp,
+	}
+	a.Metrics.RegisterDB(db.DB(), cfg.Database.Database)
+
+	a.Repositories = Repositories{
+		Users:         repository.NewUserRepository(db),
+		UserRoles:     repository.NewUserRoleRepository(db),
+		Projects:      repository.NewProjectRepository(db),
+		JITRequests:   repository.NewJITRequestRepository(db),
+		JITApprovals:  repository.NewJITApprovalRepository(db),
+		Tuples:        repository.NewRelationTupleRepository(db),
+		Groups:        repository.NewGroupRepository(db),
+		SoDViolations: repository.NewSoDViolationRepository(db),
+		BreakGlass:    repository.NewBreakGlassRepository(db),
+		AccessReviews: repository.NewAccessReviewRepository(db),
+		JobRuns:       repository.NewJobRunRepository(db),
+		Audit:         repository.NewAuditRepository(db),
+		Webhooks:      repository.NewWebhookRepository(db),
+		SigningKeys:   repository.NewSigningKeyRepository(db),
+	}
+
+	a.Metrics.RegisterJITStates(a.Repositories.JITRequests.CountByStatus)
+
+	r := &a.Repositories
+	s := &a.Services
+	tracer := tp.Tracer(tracing.Scope)
+	// Replaced keys verify for the token lifetime plus the time other servers take to
+	// notice a rotation.
+	s.SigningKeys = domain.NewSigningKeyService(db, r.SigningKeys, []byte(cfg.Token.Secret), cfg.Token.SealingKey(), cfg.Token.AccessTTL+domain.SigningKeyRefreshInterval)
+	s.Token = domain.NewTokenService(s.SigningKeys, cfg.Token.Issuer, cfg.Token.AccessTTL)
+	s.Hash = domain.NewHashService(tracer)
+	s.User = domain.NewUserService(r.Users, s.Hash, tracer)
+	s.Project = domain.NewProjectService(r.Projects, a.Events, tracer)
+	s.SoD = domain.NewSoDService(cfg.SoDPolicy, r.SoDViolations)
+	s.RBAC = domain.NewRBACService(db, r.UserRoles, r.Groups, r.Users, r.Projects, s.SoD, a.Events, a.Metrics, tracer)
+	s.JIT = domain.NewJITService(db, r.JITRequests, r.JITApprovals, r.UserRoles, r.Groups, s.RBAC, s.SoD, cfg.JIT.PendingTTL, a.Events, tracer)
+	s.ReBAC = domain.NewReBACService(cfg.Namespaces, r.Tuples, r.UserRoles)
+	s.Group = domain.NewGroupService(db, r.Groups, r.Users, s.RBAC, s.SoD, tracer)
+	s.Mail = domain.NewMailService(cfg.Mail.Sender, a.Metrics, tracer)
+	s.Audit = domain.NewAuditService(db, r.Audit, cfg.CheckpointKey(), cfg.PreviousCheckpointKeys(), tracer)
+	s.Webhook = domain.NewWebhookService(r.Webhooks, a.Events, tracer)
+	s.AccessReview = domain.NewAccessReviewService(db, r.AccessReviews, r.UserRoles, r.Users, r.Projects, s.RBAC, s.Audit, tracer)
+	s.BreakGlass = domain.NewBreakGlassService(db, cfg.BreakGlass.Policy(), r.BreakGlass, r.UserRoles, r.Groups, r.Users, s.RBAC, s.SoD, s.Mail, a.Events, tracer)
+	s.ProjectMember = domain.NewProjectMemberService(db, r.UserRoles, r.Users, r.Projects, s.RBAC)
+	s.Export = domain.NewExportService(db, r.Users, r.Projects, r.UserRoles)
+
+	return a
+}
+
+// Close closes the database connection.
+func (a *App) Close() error {
+	return a.DB.Close()
+}
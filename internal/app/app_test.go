@@ -0,0 +1,45 @@
+package app
+
+import (
+	"AuthServer/internal/config"
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/rebac"
+	"AuthServer/internal/domain/roles"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestAppsAreIndependent(t *testing.T) {
+	namespaces, err := rebac.ParseConfig(rebac.DefaultConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	newApp := func(name string) *App {
+		cfg := config.Default()
+		cfg.Database = database.Config{Host: "127.0.0.1", Port: 1, Database: name}
+		cfg.Namespaces = namespaces
+		cfg.SoDPolicy = roles.DefaultSoDPolicy()
+		// Opening the pool does not connect, so no database is needed
+		a, err := New(cfg, noop.NewTracerProvider())
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { a.Close() })
+		return a
+	}
+
+	a, b := newApp("a"), newApp("b")
+	if a.DB == b.DB || a.DB.DB() == b.DB.DB() {
+		t.Fatal("apps share a database connection")
+	}
+	if a.Events == b.Events {
+		t.Fatal("apps share an event bus")
+	}
+	if a.Metrics == b.Metrics {
+		t.Fatal("apps share metrics")
+	}
+	if a.Services.RBAC == b.Services.RBAC || a.Services.JIT == b.Services.JIT {
+		t.Fatal("apps share services")
+	}
+}
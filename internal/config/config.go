@@ -0,0 +1,317 @@
+// Package config holds the settings the server is built from. Nothing in it touches the
+// database or the network, so it can be loaded and inspected before anything starts.
+package config
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/rebac"
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/events"
+	"AuthServer/internal/logging"
+	"AuthServer/internal/tracing"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"time"
+)
+
+// minSecretLength is the shortest accepted signing secret; HS256 keys shorter than the
+// hash output weaken the signature.
+const minSecretLength = 32
+
+// Config is the full server configuration. Each setting can come from the environment
+// variable in its env tag, the .env file, the YAML file under its yaml path, or the flag
+// named after that path, such as --database.host.
+type Config struct {
+	Server       ServerConfig       `yaml:"server"`
+	Log          logging.Config     `yaml:"log"`
+	Tracing      tracing.Config     `yaml:"tracing"`
+	Database     database.Config    `yaml:"database"`
+	Token        TokenConfig        `yaml:"token"`
+	Verification VerificationConfig `yaml:"verification"`
+	Mail         MailConfig         `yaml:"mail"`
+	JIT          JITConfig          `yaml:"jit"`
+	Audit        AuditConfig        `yaml:"audit"`
+	Scheduler    SchedulerConfig    `yaml:"scheduler"`
+	ReBAC        ReBACConfig        `yaml:"rebac"`
+	SoD          SoDConfig          `yaml:"sod"`
+	BreakGlass   BreakGlassConfig   `yaml:"break_glass"`
+
+	// Read from the files named above by Validate.
+	Namespaces *rebac.Config   `yaml:"-"`
+	SoDPolicy  roles.SoDPolicy `yaml:"-"`
+}
+
+type ServerConfig struct {
+	Port        int      `yaml:"port" env:"PORT"`
+	CORSOrigins []string `yaml:"cors_origins" env:"CORS_ALLOWED_ORIGINS"`
+	// TrustedProxies lists the IPs and CIDRs of reverse proxies whose X-Forwarded-For header
+	// is believed. With none, the client IP used in conditions and the audit log is the peer
+	// address.
+	TrustedProxies []string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES"`
+	// MetricsToken, when set, must be sent as a bearer token to read /metrics.
+	MetricsToken string `yaml:"metrics_token" env:"METRICS_TOKEN" secret:"true"`
+}
+
+type TokenConfig struct {
+	// Secret signs access tokens until the first key rotation, and audit checkpoints unless
+	// audit.checkpoint_key is set.
+	Secret string `yaml:"secret" env:"ACCESS_TOKEN_SECRET_KEY" secret:"true"`
+	// KeyEncryptionKey seals the rotated signing keys stored in the database. When empty the
+	// secret is used. Stored keys only open with the key that sealed them.
+	KeyEncryptionKey string        `yaml:"key_encryption_key" env:"TOKEN_KEY_ENCRYPTION_KEY" secret:"true"`
+	Issuer           string        `yaml:"issuer" env:"TOKEN_ISSUER"`
+	AccessTTL        time.Duration `yaml:"access_ttl" env:"ACCESS_TOKEN_TTL"`
+}
+
+// CheckpointKey returns the key that signs audit checkpoints.
+func (c *Config) CheckpointKey() []byte {
+	if c.Audit.CheckpointKey != "" {
+		return []byte(c.Audit.CheckpointKey)
+	}
+	return []byte(c.Token.Secret)
+}
+
+// PreviousCheckpointKeys returns the keys that only verify audit checkpoints.
+func (c *Config) PreviousCheckpointKeys() [][]byte {
+	keys := make([][]byte, 0, len(c.Audit.PreviousCheckpointKeys))
+	for _, key := range c.Audit.PreviousCheckpointKeys {
+		keys = append(keys, []byte(key))
+	}
+	return keys
+}
+
+// SealingKey returns the key that seals stored signing keys.
+func (c TokenConfig) SealingKey() []byte {
+	if c.KeyEncryptionKey != "" {
+		return []byte(c.KeyEncryptionKey)
+	}
+	return []byte(c.Secret)
+}
+
+type VerificationConfig struct {
+	CodeTTL time.Duration `yaml:"code_ttl" env:"VERIFICATION_CODE_TTL"`
+	// URL is the page the verification email links to; the code is added as ?code=.
+	URL string `yaml:"url" env:"VERIFICATION_URL"`
+}
+
+type MailConfig struct {
+	Sender string `yaml:"sender" env:"SENDER_EMAIL"`
+}
+
+type JITConfig struct {
+	PendingTTL time.Duration `yaml:"pending_ttl" env:"JIT_PENDING_TTL"`
+}
+
+type AuditConfig struct {
+	CheckpointInterval time.Duration `yaml:"checkpoint_interval" env:"AUDIT_CHECKPOINT_INTERVAL"`
+	// CheckpointKey signs audit checkpoints. It is never stored, so database access alone
+	// cannot re-sign an edited log. When empty token.secret is used.
+	CheckpointKey string `yaml:"checkpoint_key" env:"AUDIT_CHECKPOINT_KEY" secret:"true"`
+	// PreviousCheckpointKeys keep checkpoints signed before a change of key verifying.
+	PreviousCheckpointKeys []string `yaml:"previous_checkpoint_keys" env:"AUDIT_PREVIOUS_CHECKPOINT_KEYS" secret:"true"`
+}
+
+type SchedulerConfig struct {
+	Disabled bool `yaml:"disabled" env:"SCHEDULER_DISABLED"`
+}
+
+type ReBACConfig struct {
+	// NamespaceFile replaces the built-in namespace configuration.
+	NamespaceFile string `yaml:"namespace_file" env:"REBAC_NAMESPACE_CONFIG"`
+}
+
+type SoDConfig struct {
+	// PolicyFile replaces the default separation-of-duties policy.
+	PolicyFile string `yaml:"policy_file" env:"SOD_POLICY_FILE"`
+}
+
+type BreakGlassConfig struct {
+	// Enabled break-glass access requires GroupID; off by default.
+	Enabled          bool          `yaml:"enabled" env:"BREAK_GLASS_ENABLED"`
+	Role             string        `yaml:"role" env:"BREAK_GLASS_ROLE"`
+	Duration         time.Duration `yaml:"duration" env:"BREAK_GLASS_DURATION"`
+	GroupID          string        `yaml:"group_id" env:"BREAK_GLASS_GROUP_ID"`
+	AlertEmails      []string      `yaml:"alert_emails" env:"BREAK_GLASS_ALERT_EMAILS"`
+	MinJustification int           `yaml:"min_justification" env:"BREAK_GLASS_MIN_JUSTIFICATION"`
+}
+
+// maxBreakGlassDuration caps the session duration; emergency access is meant to be short.
+const maxBreakGlassDuration = 4 * time.Hour
+
+// Validate checks the break-glass settings and warns when nobody would be alerted by email
+// about a session. Enabled break-glass access must be limited to a group: the endpoint is
+// open to every signed-in user.
+func (c BreakGlassConfig) Validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if c.GroupID == "" {
+		return fmt.Errorf("break-glass access requires an allowed group (BREAK_GLASS_GROUP_ID)")
+	}
+	if !roles.IsGlobalRole(roles.Role(c.Role)) {
+		return fmt.Errorf("break-glass role %q is not a global role", c.Role)
+	}
+	if c.Duration <= 0 || c.Duration > maxBreakGlassDuration {
+		return fmt.Errorf("break-glass duration must be a duration up to %s", maxBreakGlassDuration)
+	}
+	if c.MinJustification < 1 {
+		return fmt.Errorf("break-glass minimum justification must be a positive integer")
+	}
+
+	if len(c.AlertEmails) == 0 {
+		slog.Warn("break-glass access has no alert emails configured; only webhook subscriptions to " + events.BreakGlassActivated + " are notified")
+	}
+	return nil
+}
+
+// Policy returns the settings in the form the break-glass service uses.
+func (c BreakGlassConfig) Policy() roles.BreakGlassConfig {
+	policy := roles.BreakGlassConfig{
+		Enabled:          c.Enabled,
+		Role:             roles.Role(c.Role),
+		Duration:         c.Duration,
+		AlertEmails:      c.AlertEmails,
+		MinJustification: c.MinJustification,
+	}
+	if c.GroupID != "" {
+		group := c.GroupID
+		policy.AllowedGroup = &group
+	}
+	return policy
+}
+
+// Default returns the settings used when no source sets them.
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:        8080,
+			CORSOrigins: []string{"http://localhost:*"},
+		},
+		Log:     logging.DefaultConfig,
+		Tracing: tracing.DefaultConfig,
+		Database: database.Config{
+			Port:         5432,
+			Schema:       "public",
+			ReadTimeout:  database.DefaultReadTimeout,
+			WriteTimeout: database.DefaultWriteTimeout,
+		},
+		Token: TokenConfig{
+			Issuer:    "Artan Ebibi",
+			AccessTTL: 30 * time.Minute,
+		},
+		Verification: VerificationConfig{
+			CodeTTL: 15 * time.Minute,
+			URL:     "http://localhost:3000/verify",
+		},
+		JIT:   JITConfig{PendingTTL: 72 * time.Hour},
+		Audit: AuditConfig{CheckpointInterval: time.Hour},
+		BreakGlass: BreakGlassConfig{
+			Role:             string(roles.RoleAdmin),
+			Duration:         time.Hour,
+			MinJustification: 20,
+		},
+	}
+}
+
+// Validate checks every setting and reads the namespace and SoD policy files. It reports
+// all problems at once.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(c.Server.Port > 0 && c.Server.Port <= 65535, "server.port must be between 1 and 65535")
+	check(len(c.Server.CORSOrigins) > 0, "server.cors_origins must list at least one origin")
+	for _, proxy := range c.Server.TrustedProxies {
+		_, _, cidrErr := net.ParseCIDR(proxy)
+		check(cidrErr == nil || net.ParseIP(proxy) != nil, "server.trusted_proxies: %q is not an IP or CIDR", proxy)
+	}
+
+	if err := c.Log.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := c.Tracing.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	check(c.Database.Host != "", "database.host (DB_HOST) is required")
+	check(c.Database.Port > 0 && c.Database.Port <= 65535, "database.port must be between 1 and 65535")
+	check(c.Database.Username != "", "database.username (DB_USERNAME) is required")
+	check(c.Database.Database != "", "database.name (DB_DATABASE) is required")
+	check(c.Database.ReadTimeout > 0, "database.read_timeout must be positive")
+	check(c.Database.WriteTimeout > 0, "database.write_timeout must be positive")
+
+	check(c.Token.Secret != "", "token.secret (ACCESS_TOKEN_SECRET_KEY) is required")
+	check(c.Token.Secret == "" || len(c.Token.Secret) >= minSecretLength, "token.secret must be at least %d characters", minSecretLength)
+	check(c.Token.KeyEncryptionKey == "" || len(c.Token.KeyEncryptionKey) >= minSecretLength, "token.key_encryption_key must be at least %d characters", minSecretLength)
+	check(c.Token.Issuer != "", "token.issuer must not be empty")
+	check(c.Token.AccessTTL > 0, "token.access_ttl must be positive")
+
+	check(c.Verification.CodeTTL > 0, "verification.code_ttl must be positive")
+	u, err := url.Parse(c.Verification.URL)
+	check(err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != "", "verification.url must be an absolute http(s) URL")
+
+	check(c.JIT.PendingTTL > 0, "jit.pending_ttl must be positive")
+	check(c.Audit.CheckpointInterval > 0, "audit.checkpoint_interval must be positive")
+	check(c.Audit.CheckpointKey == "" || len(c.Audit.CheckpointKey) >= minSecretLength, "audit.checkpoint_key must be at least %d characters", minSecretLength)
+
+	if err := c.BreakGlass.Validate(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Namespaces, err = loadNamespaceConfig(c.ReBAC.NamespaceFile); err != nil {
+		errs = append(errs, err)
+	}
+	if c.SoDPolicy, err = loadSoDPolicy(c.SoD.PolicyFile); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// loadSoDPolicy reads the policy from the JSON file at path, or returns the default when path
+// is empty.
+func loadSoDPolicy(path string) (roles.SoDPolicy, error) {
+	policy := roles.DefaultSoDPolicy()
+
+	if path == "" {
+		return policy, nil
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return policy, fmt.Errorf("failed to read SoD policy %s: %v", path, err)
+	}
+	if err := json.Unmarshal(b, &policy); err != nil {
+		return policy, fmt.Errorf("invalid SoD policy %s: %v", path, err)
+	}
+
+	return policy, nil
+}
+
+// loadNamespaceConfig reads the ReBAC namespace configuration from path, falling back to the
+// built-in default.
+func loadNamespaceConfig(path string) (*rebac.Config, error) {
+	src := rebac.DefaultConfig
+	if path != "" {
+		b, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read namespace config %s: %v", path, err)
+		}
+		src = string(b)
+	}
+
+	config, err := rebac.ParseConfig(src)
+	if err != nil {
+		return nil, fmt.Errorf("invalid namespace config: %v", err)
+	}
+	return config, nil
+}
@@ -0,0 +1,138 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadPrecedence(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+
+	t.Setenv("DB_HOST", "env-host")
+	t.Setenv("DB_USERNAME", "env-user")
+	t.Setenv("DB_DATABASE", "env-db")
+	t.Setenv("ACCESS_TOKEN_TTL", "5m")
+	t.Setenv("DB_PASSWORD", "")
+	os.Unsetenv("DB_PASSWORD") // restored by t.Setenv
+
+	dotenv := "DB_USERNAME=dotenv-user\nDB_PASSWORD=dotenv-password\nDB_DATABASE=dotenv-db\nCONFIG_FILE=" + filepath.Join(dir, "auth.yaml") + "\n"
+	if err := os.WriteFile(DotEnvFile, []byte(dotenv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	file := "database:\n  name: file-db\n  port: 6543\nserver:\n  cors_origins: [https://a.example, https://b.example]\n"
+	if err := os.WriteFile("auth.yaml", []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"--database.port=7000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if cfg.Database.Host != "env-host" {
+		t.Errorf("host: got %q, want the environment value", cfg.Database.Host)
+	}
+	if cfg.Database.Username != "env-user" {
+		t.Errorf("username: got %q, want the environment to override .env", cfg.Database.Username)
+	}
+	if cfg.Database.Password != "dotenv-password" {
+		t.Errorf("password: got %q, want .env to fill in what the environment leaves unset", cfg.Database.Password)
+	}
+	if cfg.Database.Database != "file-db" {
+		t.Errorf("database: got %q, want the file to override .env", cfg.Database.Database)
+	}
+	if cfg.Database.Port != 7000 {
+		t.Errorf("port: got %d, want the flag to override the file", cfg.Database.Port)
+	}
+	if cfg.Token.AccessTTL != 5*time.Minute {
+		t.Errorf("access ttl: got %s", cfg.Token.AccessTTL)
+	}
+	if len(cfg.Server.CORSOrigins) != 2 {
+		t.Errorf("cors origins: got %v", cfg.Server.CORSOrigins)
+	}
+	if cfg.Token.Issuer != "Artan Ebibi" {
+		t.Errorf("issuer: got %q, want the default", cfg.Token.Issuer)
+	}
+}
+
+func TestLoadRejectsInvalidValues(t *testing.T) {
+	t.Chdir(t.TempDir())
+	t.Setenv("DB_PORT", "postgres")
+	if _, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), nil); err == nil || !strings.Contains(err.Error(), "DB_PORT") {
+		t.Fatalf("expected an error naming DB_PORT, got %v", err)
+	}
+
+	t.Setenv("DB_PORT", "5432")
+	if err := os.WriteFile("auth.yaml", []byte("database:\n  hots: typo\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(flag.NewFlagSet("test", flag.ContinueOnError), []string{"--config=auth.yaml"}); err == nil {
+		t.Fatal("expected unknown keys in the config file to be rejected")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	cfg := Default()
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected the defaults alone to be invalid")
+	}
+	for _, want := range []string{"database.host", "token.secret"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("expected %q in %v", want, err)
+		}
+	}
+
+	cfg.Database.Host = "localhost"
+	cfg.Database.Username = "auth"
+	cfg.Database.Database = "authdb"
+	cfg.Token.Secret = "short"
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "at least") {
+		t.Fatalf("expected a short secret to be rejected, got %v", err)
+	}
+
+	cfg.Token.Secret = strings.Repeat("s", minSecretLength)
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Namespaces == nil {
+		t.Fatal("namespace config not loaded")
+	}
+
+	cfg.BreakGlass.Enabled = true
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "BREAK_GLASS_GROUP_ID") {
+		t.Fatalf("expected break-glass without a group to be rejected, got %v", err)
+	}
+	cfg.BreakGlass.GroupID = "responders"
+	if err := cfg.Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg.Server.TrustedProxies = []string{"10.0.0.1", "192.168.0.0/16", "proxy.internal"}
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "proxy.internal") {
+		t.Fatalf("expected a hostname in trusted proxies to be rejected, got %v", err)
+	}
+}
+
+func TestPrintRedactsSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Token.Secret = "super-secret-signing-key"
+	cfg.Database.Password = "hunter2"
+	cfg.Audit.PreviousCheckpointKeys = []string{"retired-checkpoint-key"}
+
+	var out strings.Builder
+	if err := cfg.Print(&out); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(out.String(), "super-secret") || strings.Contains(out.String(), "hunter2") || strings.Contains(out.String(), "retired-checkpoint") {
+		t.Fatalf("secret printed:\n%s", out.String())
+	}
+	if cfg.Token.Secret != "super-secret-signing-key" || cfg.Audit.PreviousCheckpointKeys[0] != "retired-checkpoint-key" {
+		t.Fatal("Print modified the configuration")
+	}
+}
@@ -0,0 +1,212 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+// DotEnvFile is the .env file read from the working directory, if present.
+const DotEnvFile = ".env"
+
+// redacted replaces secret values in printed configuration.
+const redacted = "[redacted]"
+
+// setting is one leaf of Config, addressed by its yaml path.
+type setting struct {
+	path   string // yaml path and flag name, e.g. database.host
+	env    string
+	secret bool
+	value  reflect.Value
+}
+
+// settings lists the leaves of the struct v points to.
+func settings(v reflect.Value, prefix string) []setting {
+	var out []setting
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		path := prefix + name
+		if f.Type.Kind() == reflect.Struct && f.Type != reflect.TypeOf(time.Duration(0)) {
+			out = append(out, settings(v.Field(i), path+".")...)
+			continue
+		}
+		out = append(out, setting{
+			path:   path,
+			env:    f.Tag.Get("env"),
+			secret: f.Tag.Get("secret") == "true",
+			value:  v.Field(i),
+		})
+	}
+	return out
+}
+
+// set parses raw into the setting. Lists are comma separated.
+func (s setting) set(raw string) error {
+	v := s.value
+	switch {
+	case v.Type() == reflect.TypeOf(time.Duration(0)):
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration", raw)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", raw)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", raw)
+		}
+		v.SetBool(b)
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, item := range strings.Split(raw, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				items = append(items, item)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported setting type %s", v.Type())
+	}
+	return nil
+}
+
+// Load builds the configuration from, in increasing order of precedence: the defaults, the
+// .env file, the environment, the YAML file named by --config or CONFIG_FILE, and the
+// per-setting flags. It registers --config and one flag per setting on fs and parses args.
+// The result is not validated; call Validate before using it.
+func Load(fs *flag.FlagSet, args []string) (*Config, error) {
+	cfg := Default()
+	all := settings(reflect.ValueOf(cfg).Elem(), "")
+
+	configFile := fs.String("config", "", "YAML configuration file (default $CONFIG_FILE)")
+	overrides := map[string]string{}
+	for _, s := range all {
+		path := s.path
+		usage := "sets " + path
+		if s.env != "" {
+			usage += " (env " + s.env + ")"
+		}
+		fs.Func(path, usage, func(raw string) error {
+			overrides[path] = raw
+			return nil
+		})
+	}
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	apply := func(source string, lookup func(key string) (string, bool)) {
+		for _, s := range all {
+			if s.env == "" {
+				continue
+			}
+			if raw, ok := lookup(s.env); ok {
+				if err := s.set(raw); err != nil {
+					errs = append(errs, fmt.Errorf("%s from %s: %v", s.env, source, err))
+				}
+			}
+		}
+	}
+
+	// .env only fills in what the environment leaves unset, so an exported variable always
+	// wins over a file left in the working directory
+	dotenv, err := godotenv.Read(DotEnvFile)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read %s: %v", DotEnvFile, err)
+	}
+	apply(DotEnvFile, func(key string) (string, bool) {
+		raw, ok := dotenv[key]
+		return raw, ok
+	})
+	file := dotenv["CONFIG_FILE"]
+
+	apply("environment", os.LookupEnv)
+	if f, ok := os.LookupEnv("CONFIG_FILE"); ok {
+		file = f
+	}
+
+	if *configFile != "" {
+		file = *configFile
+	}
+	if file != "" {
+		if err := loadFile(cfg, file); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, s := range all {
+		if raw, ok := overrides[s.path]; ok {
+			if err := s.set(raw); err != nil {
+				errs = append(errs, fmt.Errorf("--%s: %v", s.path, err))
+			}
+		}
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// loadFile merges the YAML file at path into cfg. Settings missing from the file keep their
+// current value; unknown keys are an error so that typos do not go unnoticed.
+func loadFile(cfg *Config, path string) error {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %v", err)
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("invalid config file %s: %v", path, err)
+	}
+	return nil
+}
+
+// Print writes the configuration as YAML with secrets redacted.
+func (c *Config) Print(w io.Writer) error {
+	out := *c
+	for _, s := range settings(reflect.ValueOf(&out).Elem(), "") {
+		if !s.secret {
+			continue
+		}
+		switch {
+		case s.value.Kind() == reflect.String && s.value.String() != "":
+			s.value.SetString(redacted)
+		case s.value.Kind() == reflect.Slice && s.value.Len() > 0:
+			s.value.Set(reflect.ValueOf([]string{redacted}))
+		}
+	}
+
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&out); err != nil {
+		return err
+	}
+	return enc.Close()
+}
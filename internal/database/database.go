@@ -4,105 +4,124 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
-	"strconv"
+	"log/slog"
 	"time"
 
-	_ "github.com/jackc/pgx/v5/stdlib"
-	_ "github.com/joho/godotenv/autoload"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"go.opentelemetry.io/otel/trace"
+
+	"AuthServer/internal/tracing"
 )
 
 // Service represents a service that interacts with a database.
 type Service interface {
-	// Health returns a map of health status information.
-	// The keys and values in the map are service-specific.
-	Health() map[string]string
+	// Ping checks that the database accepts connections.
+	Ping(ctx context.Context) error
+
+	// PoolStats returns the connection pool statistics.
+	PoolStats() PoolStats
 
 	// Close terminates the database connection.
 	// It returns an error if the connection cannot be closed.
 	Close() error
 	DB() *sql.DB
+
+	// Timeouts returns the per-operation timeouts repositories on this database apply.
+	Timeouts() Timeouts
+
+	// WithTx runs fn inside a transaction that repositories can join with their WithTx method.
+	WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error
 }
 
 type service struct {
-	db *sql.DB
+	db       *sql.DB
+	name     string
+	timeouts Timeouts
 }
 
-var (
-	database   = os.Getenv("DB_DATABASE")
-	password   = os.Getenv("DB_PASSWORD")
-	username   = os.Getenv("DB_USERNAME")
-	port       = os.Getenv("DB_PORT")
-	host       = os.Getenv("DB_HOST")
-	schema     = os.Getenv("DB_SCHEMA")
-	dbInstance *service
-)
+// Config holds the connection settings for the Postgres database.
+type Config struct {
+	Host         string        `yaml:"host" env:"DB_HOST"`
+	Port         int           `yaml:"port" env:"DB_PORT"`
+	Username     string        `yaml:"username" env:"DB_USERNAME"`
+	Password     string        `yaml:"password" env:"DB_PASSWORD" secret:"true"`
+	Database     string        `yaml:"name" env:"DB_DATABASE"`
+	Schema       string        `yaml:"schema" env:"DB_SCHEMA"`
+	ReadTimeout  time.Duration `yaml:"read_timeout" env:"DB_READ_TIMEOUT"`
+	WriteTimeout time.Duration `yaml:"write_timeout" env:"DB_WRITE_TIMEOUT"`
+}
 
-func New() Service {
-	// Reuse Connection
-	if dbInstance != nil {
-		return dbInstance
-	}
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, port, database, schema)
-	db, err := sql.Open("pgx", connStr)
+// DSN returns the connection string for cfg.
+func (cfg Config) DSN() string {
+	return fmt.Sprintf("postgres://%s:%s@%s:%d/%s?sslmode=disable&search_path=%s", cfg.Username, cfg.Password, cfg.Host, cfg.Port, cfg.Database, cfg.Schema)
+}
+
+// Open returns a new connection pool for cfg whose statements are traced with tp. Each call
+// opens its own pool, so several independent services can run in one process; the caller
+// closes it.
+func Open(cfg Config, tp trace.TracerProvider) (Service, error) {
+	connConfig, err := pgx.ParseConfig(cfg.DSN())
 	if err != nil {
-		log.Fatal(err)
+		return nil, err
 	}
-	dbInstance = &service{
-		db: db,
-	}
-	return dbInstance
+	connConfig.Tracer = queryTracer{tracer: tp.Tracer(tracing.Scope)}
+	return &service{
+		db:       stdlib.OpenDB(*connConfig),
+		name:     cfg.Database,
+		timeouts: Timeouts{Read: cfg.ReadTimeout, Write: cfg.WriteTimeout},
+	}, nil
 }
 
-// Health checks the health of the database connection by pinging the database.
-// It returns a map with keys indicating various health statistics.
-func (s *service) Health() map[string]string {
-	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
-	defer cancel()
+// Ping checks that the database accepts connections.
+func (s *service) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
 
-	stats := make(map[string]string)
+// PoolStats describes the connection pool, with warnings when its numbers suggest it is
+// undersized or misconfigured.
+type PoolStats struct {
+	MaxOpenConnections int      `json:"max_open_connections"`
+	OpenConnections    int      `json:"open_connections"`
+	InUse              int      `json:"in_use"`
+	Idle               int      `json:"idle"`
+	WaitCount          int64    `json:"wait_count"`
+	WaitDuration       string   `json:"wait_duration"`
+	MaxIdleClosed      int64    `json:"max_idle_closed"`
+	MaxIdleTimeClosed  int64    `json:"max_idle_time_closed"`
+	MaxLifetimeClosed  int64    `json:"max_lifetime_closed"`
+	Warnings           []string `json:"warnings,omitempty"`
+}
 
-	// Ping the database
-	err := s.db.PingContext(ctx)
-	if err != nil {
-		stats["status"] = "down"
-		stats["error"] = fmt.Sprintf("db down: %v", err)
-		log.Fatalf("db down: %v", err) // Log the error and terminate the program
-		return stats
-	}
+func (s *service) PoolStats() PoolStats {
+	return newPoolStats(s.db.Stats())
+}
 
-	// Database is up, add more statistics
-	stats["status"] = "up"
-	stats["message"] = "It's healthy"
-
-	// Get database stats (like open connections, in use, idle, etc.)
-	dbStats := s.db.Stats()
-	stats["open_connections"] = strconv.Itoa(dbStats.OpenConnections)
-	stats["in_use"] = strconv.Itoa(dbStats.InUse)
-	stats["idle"] = strconv.Itoa(dbStats.Idle)
-	stats["wait_count"] = strconv.FormatInt(dbStats.WaitCount, 10)
-	stats["wait_duration"] = dbStats.WaitDuration.String()
-	stats["max_idle_closed"] = strconv.FormatInt(dbStats.MaxIdleClosed, 10)
-	stats["max_lifetime_closed"] = strconv.FormatInt(dbStats.MaxLifetimeClosed, 10)
-
-	// Evaluate stats to provide a health message
-	if dbStats.OpenConnections > 40 { // Assuming 50 is the max for this example
-		stats["message"] = "The database is experiencing heavy load."
+func newPoolStats(st sql.DBStats) PoolStats {
+	stats := PoolStats{
+		MaxOpenConnections: st.MaxOpenConnections,
+		OpenConnections:    st.OpenConnections,
+		InUse:              st.InUse,
+		Idle:               st.Idle,
+		WaitCount:          st.WaitCount,
+		WaitDuration:       st.WaitDuration.String(),
+		MaxIdleClosed:      st.MaxIdleClosed,
+		MaxIdleTimeClosed:  st.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  st.MaxLifetimeClosed,
 	}
 
-	if dbStats.WaitCount > 1000 {
-		stats["message"] = "The database has a high number of wait events, indicating potential bottlenecks."
+	if st.OpenConnections > 40 {
+		stats.Warnings = append(stats.Warnings, "The database is experiencing heavy load.")
 	}
-
-	if dbStats.MaxIdleClosed > int64(dbStats.OpenConnections)/2 {
-		stats["message"] = "Many idle connections are being closed, consider revising the connection pool settings."
+	if st.WaitCount > 1000 {
+		stats.Warnings = append(stats.Warnings, "The database has a high number of wait events, indicating potential bottlenecks.")
 	}
-
-	if dbStats.MaxLifetimeClosed > int64(dbStats.OpenConnections)/2 {
-		stats["message"] = "Many connections are being closed due to max lifetime, consider increasing max lifetime or revising the connection usage pattern."
+	if st.MaxIdleClosed > int64(st.OpenConnections)/2 {
+		stats.Warnings = append(stats.Warnings, "Many idle connections are being closed, consider revising the connection pool settings.")
+	}
+	if st.MaxLifetimeClosed > int64(st.OpenConnections)/2 {
+		stats.Warnings = append(stats.Warnings, "Many connections are being closed due to max lifetime, consider increasing max lifetime or revising the connection usage pattern.")
 	}
-
 	return stats
 }
 
@@ -111,10 +130,14 @@ func (s *service) Health() map[string]string {
 // If the connection is successfully closed, it returns nil.
 // If an error occurs while closing the connection, it returns the error.
 func (s *service) Close() error {
-	log.Printf("Disconnected from database: %s", database)
+	slog.Info("disconnected from database", "database", s.name)
 	return s.db.Close()
 }
 
 func (s *service) DB() *sql.DB {
 	return s.db
 }
+
+func (s *service) Timeouts() Timeouts {
+	return s.timeouts
+}
@@ -2,15 +2,29 @@ package database
 
 import (
 	"context"
+	"errors"
 	"log"
+	"slices"
 	"testing"
 	"time"
 
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/modules/postgres"
 	"github.com/testcontainers/testcontainers-go/wait"
+	"go.opentelemetry.io/otel/trace/noop"
 )
 
+// testConfig points at the container started by TestMain.
+var testConfig Config
+
+func mustOpen(t *testing.T) Service {
+	srv, err := Open(testConfig, noop.NewTracerProvider())
+	if err != nil {
+		t.Fatalf("Open() returned error: %v", err)
+	}
+	return srv
+}
+
 func mustStartPostgresContainer() (func(context.Context, ...testcontainers.TerminateOption) error, error) {
 	var (
 		dbName = "database"
@@ -33,9 +47,9 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 		return nil, err
 	}
 
-	database = dbName
-	password = dbPwd
-	username = dbUser
+	testConfig.Database = dbName
+	testConfig.Password = dbPwd
+	testConfig.Username = dbUser
 
 	dbHost, err := dbContainer.Host(context.Background())
 	if err != nil {
@@ -47,8 +61,8 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 		return dbContainer.Terminate, err
 	}
 
-	host = dbHost
-	port = dbPort.Port()
+	testConfig.Host = dbHost
+	testConfig.Port = dbPort.Int()
 
 	return dbContainer.Terminate, err
 }
@@ -66,35 +80,73 @@ func TestMain(m *testing.M) {
 	}
 }
 
-func TestNew(t *testing.T) {
-	srv := New()
+func TestOpen(t *testing.T) {
+	srv := mustOpen(t)
 	if srv == nil {
-		t.Fatal("New() returned nil")
+		t.Fatal("Open() returned nil")
 	}
 }
 
-func TestHealth(t *testing.T) {
-	srv := New()
-
-	stats := srv.Health()
+func TestPing(t *testing.T) {
+	srv := mustOpen(t)
 
-	if stats["status"] != "up" {
-		t.Fatalf("expected status to be up, got %s", stats["status"])
+	if err := srv.Ping(context.Background()); err != nil {
+		t.Fatalf("expected the database to be up, got %v", err)
 	}
 
-	if _, ok := stats["error"]; ok {
-		t.Fatalf("expected error not to be present")
+	stats := srv.PoolStats()
+	if stats.OpenConnections < 1 {
+		t.Fatalf("expected an open connection after Ping, got %d", stats.OpenConnections)
 	}
-
-	if stats["message"] != "It's healthy" {
-		t.Fatalf("expected message to be 'It's healthy', got %s", stats["message"])
+	if len(stats.Warnings) > 0 {
+		t.Fatalf("expected no pool warnings, got %v", stats.Warnings)
 	}
 }
 
 func TestClose(t *testing.T) {
-	srv := New()
+	srv := mustOpen(t)
 
 	if srv.Close() != nil {
 		t.Fatalf("expected Close() to return nil")
 	}
 }
+
+// TestMigrations applies every migration, rolls them all back and applies them again, so
+// that each down file undoes its up file.
+func TestMigrations(t *testing.T) {
+	srv := mustOpen(t)
+	defer srv.Close()
+
+	m, err := NewMigrator(srv.DB())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("up: %v", err)
+	}
+	if err := m.Down(); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	if err := m.Up(); err != nil {
+		t.Fatalf("up after down: %v", err)
+	}
+
+	ctx := context.Background()
+	if err := CheckMigrationVersion(ctx, srv.DB()); err != nil {
+		t.Fatalf("CheckMigrationVersion() = %v", err)
+	}
+	expected := map[string][]string{"user_roles": {"id", "project_id", "expires_at", "created_by"}}
+	if err := CheckSchema(ctx, srv.DB(), expected); err != nil {
+		t.Fatalf("CheckSchema() = %v", err)
+	}
+
+	expected["user_roles"] = append(expected["user_roles"], "missing_column")
+	expected["missing_table"] = []string{"id"}
+	var drift *SchemaDriftError
+	if err := CheckSchema(ctx, srv.DB(), expected); !errors.As(err, &drift) {
+		t.Fatalf("CheckSchema() = %v, want a SchemaDriftError", err)
+	}
+	if want := []string{"missing_table", "user_roles.missing_column"}; !slices.Equal(drift.Missing, want) {
+		t.Fatalf("missing = %v, want %v", drift.Missing, want)
+	}
+}
@@ -0,0 +1,4 @@
+package database
+
+// MustOpen opens the test container's database for tests in package database_test.
+var MustOpen = mustOpen
@@ -1,77 +1,103 @@
 package database
 
 import (
+	"context"
 	"database/sql"
+	"embed"
+	"errors"
 	"fmt"
-	"log"
-	"os"
-	"path/filepath"
+	"io/fs"
+	"log/slog"
+	"sync"
 
 	"github.com/golang-migrate/migrate/v4"
 	"github.com/golang-migrate/migrate/v4/database/postgres"
-	_ "github.com/golang-migrate/migrate/v4/source/file"
-	"github.com/joho/godotenv"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
 )
 
-func RunMigrations(db *sql.DB) {
+// migrationFiles is compiled into the binary, so migrations run from any working directory.
+//
+//go:embed migrations/*.sql
+var migrationFiles embed.FS
 
-	err := godotenv.Load()
+// NewMigrator returns a migrator for db using the embedded migrations.
+func NewMigrator(db *sql.DB) (*migrate.Migrate, error) {
+	source, err := iofs.New(migrationFiles, "migrations")
 	if err != nil {
-		log.Fatal("Error loading .env file")
-	} else {
-		//log.Print("Loading .env file")
+		return nil, fmt.Errorf("failed to read migrations: %v", err)
 	}
 
-	var migrationsPath string
-
-	migrationsPath, err = filepath.Abs("./internal/database/migrations")
-	migrationsPath = filepath.ToSlash(migrationsPath)
-
+	driver, err := postgres.WithInstance(db, &postgres.Config{})
 	if err != nil {
-		log.Fatalf("Failed to get absolute path: %v", err)
+		return nil, fmt.Errorf("failed to create migration driver: %v", err)
 	}
 
-	//if os.Getenv("BUILD_ENV") == "docker" {
-	//	migrationsPath = "/app/internal/database/migrations"
-	//} else {
-	//	var err error = nil
-	//	migrationsPath, err = filepath.Abs("./internal/database/migrations")
-	//	if err != nil {
-	//		log.Fatalf("Failed to get absolute path: %v", err)
-	//	}
-	//}
+	m, err := migrate.NewWithInstance("iofs", source, "authdb", driver)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize migrations: %v", err)
+	}
+	return m, nil
+}
 
-	if _, err := os.Stat(migrationsPath); os.IsNotExist(err) {
-		//pwd, _ := os.Getwd()
-		//dir, _ := os.ReadDir(fmt.Sprintf("%s/main", pwd))
-		//log.Fatalf("You are here: %s, and the dir\n%s", pwd, dir)
+// RunMigrations applies every pending migration.
+func RunMigrations(db *sql.DB) error {
+	m, err := NewMigrator(db)
+	if err != nil {
+		return err
+	}
 
-		log.Fatalf("Migrations directory not found at: %s", migrationsPath)
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		return fmt.Errorf("migration failed: %v", err)
 	}
 
-	driver, err := postgres.WithInstance(db, &postgres.Config{})
+	slog.Info("migrations completed")
+	return nil
+}
+
+// LatestMigration returns the version of the newest embedded migration.
+var LatestMigration = sync.OnceValues(func() (uint, error) {
+	source, err := iofs.New(migrationFiles, "migrations")
 	if err != nil {
-		log.Fatalf("Failed to create migration driver: %v", err)
+		return 0, fmt.Errorf("failed to read migrations: %v", err)
 	}
+	defer source.Close()
 
-	m, err := migrate.NewWithDatabaseInstance(
-		fmt.Sprintf("file://%s", migrationsPath),
-		"authdb",
-		driver,
-	)
-
+	version, err := source.First()
 	if err != nil {
-		log.Fatalf("Failed to initialize migrations: %v", err)
+		return 0, fmt.Errorf("failed to read migrations: %v", err)
 	}
-	if m == nil {
-		log.Fatal("Migration instance is nil")
+	for {
+		next, err := source.Next(version)
+		if errors.Is(err, fs.ErrNotExist) {
+			return version, nil
+		}
+		if err != nil {
+			return 0, fmt.Errorf("failed to read migrations: %v", err)
+		}
+		version = next
 	}
+})
 
-	// Run migrations
-	if err := m.Up(); err != nil && err != migrate.ErrNoChange {
-		log.Fatalf("Migration failed: %v", err)
+// CheckMigrationVersion returns an error unless the database is at the newest embedded
+// migration and no migration was left half applied.
+func CheckMigrationVersion(ctx context.Context, db *sql.DB) error {
+	want, err := LatestMigration()
+	if err != nil {
+		return err
 	}
 
-	log.Print("Migrations completed successfully")
-
+	var version uint
+	var dirty bool
+	err = db.QueryRowContext(ctx, `SELECT version, dirty FROM schema_migrations LIMIT 1`).Scan(&version, &dirty)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return errors.New("no migrations applied")
+	case err != nil:
+		return fmt.Errorf("failed to read migration version: %v", err)
+	case dirty:
+		return fmt.Errorf("migration %d is dirty", version)
+	case version != want:
+		return fmt.Errorf("schema at version %d, want %d", version, want)
+	}
+	return nil
 }
@@ -1,2 +1,2 @@
-DROP TABLE IF EXISTS project CASCADE;
 DROP TABLE IF EXISTS user_roles CASCADE;
+DROP TABLE IF EXISTS project CASCADE;
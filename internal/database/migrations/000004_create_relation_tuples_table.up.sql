@@ -0,0 +1,15 @@
+CREATE TABLE relation_tuples
+(
+    namespace         TEXT        NOT NULL,
+    object_id         TEXT        NOT NULL,
+    relation          TEXT        NOT NULL,
+    subject_namespace TEXT        NOT NULL,
+    subject_id        TEXT        NOT NULL,
+    subject_relation  TEXT        NOT NULL DEFAULT '', -- empty for a concrete subject
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+);
+
+CREATE INDEX idx_relation_tuples_subject ON relation_tuples (subject_namespace, subject_id, subject_relation);
+CREATE INDEX idx_relation_tuples_namespace_relation ON relation_tuples (namespace, relation);
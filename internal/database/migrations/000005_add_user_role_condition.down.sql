@@ -0,0 +1 @@
+ALTER TABLE user_roles DROP COLUMN IF EXISTS condition;
@@ -0,0 +1,2 @@
+-- optional attribute-based condition, evaluated on every permission check
+ALTER TABLE user_roles ADD COLUMN IF NOT EXISTS condition TEXT NULL;
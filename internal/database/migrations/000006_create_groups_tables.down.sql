@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS group_roles CASCADE;
+DROP TABLE IF EXISTS group_members CASCADE;
+DROP TABLE IF EXISTS user_groups CASCADE;
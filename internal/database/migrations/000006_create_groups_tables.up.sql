@@ -0,0 +1,35 @@
+CREATE TABLE user_groups
+(
+    id          TEXT PRIMARY KEY,
+    name        VARCHAR(100) UNIQUE NOT NULL,
+    description TEXT         NOT NULL DEFAULT '',
+    created_by  TEXT         NULL REFERENCES users (id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ  NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE group_members
+(
+    group_id    TEXT        NOT NULL REFERENCES user_groups (id) ON DELETE CASCADE,
+    member_type TEXT        NOT NULL CHECK (member_type IN ('user', 'group')),
+    member_id   TEXT        NOT NULL, -- users.id or user_groups.id depending on member_type
+    added_by    TEXT        NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (group_id, member_type, member_id)
+);
+
+CREATE INDEX idx_group_members_member ON group_members (member_type, member_id);
+
+CREATE TABLE group_roles
+(
+    id         TEXT PRIMARY KEY,
+    group_id   TEXT        NOT NULL REFERENCES user_groups (id) ON DELETE CASCADE,
+    role       TEXT        NOT NULL,
+    project_id TEXT        NULL REFERENCES project (id) ON DELETE CASCADE,
+    expires_at TIMESTAMPTZ NULL,
+    condition  TEXT        NULL,
+    created_by TEXT        NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_group_roles_group_id ON group_roles (group_id);
@@ -0,0 +1,15 @@
+CREATE TABLE sod_violations
+(
+    id               TEXT PRIMARY KEY,
+    rule             TEXT        NOT NULL,
+    message          TEXT        NOT NULL,
+    actor_id         TEXT        NOT NULL,
+    target_user_id   TEXT        NOT NULL,
+    role             TEXT        NOT NULL,
+    resource_id      TEXT        NULL,
+    conflicting_role TEXT        NULL,
+    request_id       TEXT        NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_sod_violations_created_at ON sod_violations (created_at DESC);
@@ -0,0 +1,7 @@
+ALTER TABLE jit_requests
+    DROP COLUMN IF EXISTS policy_id,
+    DROP COLUMN IF EXISTS approval_tier,
+    DROP COLUMN IF EXISTS escalated_at;
+
+DROP TABLE IF EXISTS jit_approvals CASCADE;
+DROP TABLE IF EXISTS jit_approval_policies CASCADE;
@@ -0,0 +1,35 @@
+CREATE TABLE jit_approval_policies
+(
+    id                       TEXT PRIMARY KEY,
+    role                     TEXT        NOT NULL,
+    resource_id              TEXT        NULL,                                  -- NULL applies to every resource
+    required_approvals       INTEGER     NOT NULL DEFAULT 1 CHECK (required_approvals >= 1),
+    approver_group_id        TEXT        NULL REFERENCES user_groups (id) ON DELETE SET NULL,
+    auto_approve_max_minutes INTEGER     NULL,                                  -- auto-approve requests up to this duration
+    escalation_after_minutes INTEGER     NULL,                                  -- move to tier 2 after this long
+    escalation_group_id      TEXT        NULL REFERENCES user_groups (id) ON DELETE SET NULL,
+    created_by               TEXT        NULL,
+    created_at               TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX idx_jit_approval_policies_scope ON jit_approval_policies (role, COALESCE(resource_id, ''));
+
+CREATE TABLE jit_approvals
+(
+    id          TEXT PRIMARY KEY,
+    request_id  TEXT        NOT NULL REFERENCES jit_requests (id) ON DELETE CASCADE,
+    approver_id TEXT        NULL REFERENCES users (id) ON DELETE SET NULL, -- NULL for automatic approvals
+    tier        INTEGER     NOT NULL DEFAULT 1,
+    decision    TEXT        NOT NULL CHECK (decision IN ('approved', 'rejected', 'auto_approved')),
+    comment     TEXT        NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+
+    UNIQUE (request_id, approver_id)
+);
+
+CREATE INDEX idx_jit_approvals_request_id ON jit_approvals (request_id);
+
+ALTER TABLE jit_requests
+    ADD COLUMN policy_id     TEXT        NULL REFERENCES jit_approval_policies (id) ON DELETE SET NULL,
+    ADD COLUMN approval_tier INTEGER     NOT NULL DEFAULT 1,
+    ADD COLUMN escalated_at  TIMESTAMPTZ NULL;
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_jit_requests_parent_request_id;
+DROP INDEX IF EXISTS idx_jit_requests_granted_role_id;
+
+ALTER TABLE jit_requests
+    DROP CONSTRAINT IF EXISTS jit_requests_status_check,
+    DROP COLUMN IF EXISTS ended_at,
+    DROP COLUMN IF EXISTS ended_by,
+    DROP COLUMN IF EXISTS parent_request_id,
+    DROP COLUMN IF EXISTS grant_expires_at,
+    DROP COLUMN IF EXISTS granted_role_id;
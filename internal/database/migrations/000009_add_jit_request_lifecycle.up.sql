@@ -0,0 +1,11 @@
+ALTER TABLE jit_requests
+    ADD COLUMN granted_role_id   TEXT        NULL REFERENCES user_roles (id) ON DELETE SET NULL, -- user_roles row created on approval
+    ADD COLUMN grant_expires_at  TIMESTAMPTZ NULL,
+    ADD COLUMN parent_request_id TEXT        NULL REFERENCES jit_requests (id) ON DELETE SET NULL, -- set on extension requests
+    ADD COLUMN ended_by          TEXT        NULL REFERENCES users (id) ON DELETE SET NULL,        -- who cancelled or revoked
+    ADD COLUMN ended_at          TIMESTAMPTZ NULL,
+    ADD CONSTRAINT jit_requests_status_check
+        CHECK (status IN ('pending', 'approved', 'rejected', 'expired', 'cancelled', 'revoked'));
+
+CREATE INDEX idx_jit_requests_granted_role_id ON jit_requests (granted_role_id);
+CREATE INDEX idx_jit_requests_parent_request_id ON jit_requests (parent_request_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS break_glass_access_log CASCADE;
+DROP TABLE IF EXISTS break_glass_sessions CASCADE;
@@ -0,0 +1,31 @@
+CREATE TABLE break_glass_sessions
+(
+    id              TEXT PRIMARY KEY,
+    user_id         TEXT        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role            TEXT        NOT NULL,
+    justification   TEXT        NOT NULL,
+    granted_role_id TEXT        NULL REFERENCES user_roles (id) ON DELETE SET NULL,
+    started_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    expires_at      TIMESTAMPTZ NOT NULL,
+    ended_at        TIMESTAMPTZ NULL,                                -- set when ended before expiry
+    review_status   TEXT        NOT NULL DEFAULT 'open' CHECK (review_status IN ('open', 'closed')),
+    reviewed_by     TEXT        NULL REFERENCES users (id) ON DELETE SET NULL,
+    reviewed_at     TIMESTAMPTZ NULL,
+    review_notes    TEXT        NULL
+);
+
+CREATE INDEX idx_break_glass_sessions_user_id ON break_glass_sessions (user_id);
+CREATE INDEX idx_break_glass_sessions_review_status ON break_glass_sessions (review_status);
+
+CREATE TABLE break_glass_access_log
+(
+    id          BIGSERIAL PRIMARY KEY,
+    session_id  TEXT        NOT NULL REFERENCES break_glass_sessions (id) ON DELETE CASCADE,
+    method      TEXT        NOT NULL,
+    path        TEXT        NOT NULL,
+    status      INTEGER     NOT NULL,
+    ip          TEXT        NOT NULL,
+    occurred_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_break_glass_access_log_session_id ON break_glass_access_log (session_id);
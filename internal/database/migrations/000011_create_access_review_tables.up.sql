@@ -0,0 +1,38 @@
+CREATE TABLE access_review_campaigns
+(
+    id                     TEXT PRIMARY KEY,
+    name                   TEXT        NOT NULL,
+    scope_role             TEXT        NULL,
+    scope_project_id       TEXT        NULL REFERENCES project (id) ON DELETE CASCADE,
+    reviewer_id            TEXT        NULL REFERENCES users (id) ON DELETE SET NULL,
+    due_at                 TIMESTAMPTZ NOT NULL,
+    overdue_action         TEXT        NOT NULL DEFAULT 'revoke' CHECK (overdue_action IN ('revoke', 'escalate')),
+    escalation_reviewer_id TEXT        NULL REFERENCES users (id) ON DELETE SET NULL,
+    escalation_grace_hours INTEGER     NOT NULL DEFAULT 72,
+    status                 TEXT        NOT NULL DEFAULT 'active' CHECK (status IN ('active', 'completed')),
+    created_by             TEXT        NOT NULL REFERENCES users (id),
+    created_at             TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    completed_at           TIMESTAMPTZ NULL
+);
+
+CREATE INDEX idx_access_review_campaigns_status ON access_review_campaigns (status);
+
+CREATE TABLE access_review_items
+(
+    id           TEXT PRIMARY KEY,
+    campaign_id  TEXT        NOT NULL REFERENCES access_review_campaigns (id) ON DELETE CASCADE,
+    user_role_id TEXT        NULL REFERENCES user_roles (id) ON DELETE SET NULL,
+    user_id      TEXT        NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    role         TEXT        NOT NULL,
+    project_id   TEXT        NULL,
+    reviewer_id  TEXT        NOT NULL REFERENCES users (id),
+    decision     TEXT        NULL CHECK (decision IN ('keep', 'revoke')), -- NULL while pending
+    comment      TEXT        NULL,
+    decided_by   TEXT        NULL REFERENCES users (id) ON DELETE SET NULL,
+    decided_at   TIMESTAMPTZ NULL,
+    auto_decided BOOLEAN     NOT NULL DEFAULT FALSE,
+    escalated_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX idx_access_review_items_campaign_id ON access_review_items (campaign_id);
+CREATE INDEX idx_access_review_items_reviewer_pending ON access_review_items (reviewer_id) WHERE decision IS NULL;
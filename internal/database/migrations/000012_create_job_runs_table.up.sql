@@ -0,0 +1,13 @@
+CREATE TABLE job_runs
+(
+    id          BIGSERIAL PRIMARY KEY,
+    job_name    TEXT        NOT NULL,
+    instance_id TEXT        NOT NULL,                   -- hostname and pid of the replica that ran the job
+    started_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    finished_at TIMESTAMPTZ NULL,
+    status      TEXT        NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed')),
+    items       INTEGER     NOT NULL DEFAULT 0,         -- rows or entries the run changed
+    error       TEXT        NULL
+);
+
+CREATE INDEX idx_job_runs_job_name_started_at ON job_runs (job_name, started_at DESC);
@@ -0,0 +1,41 @@
+CREATE TABLE audit_events
+(
+    id           BIGSERIAL PRIMARY KEY,
+    occurred_at  TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    actor_id     TEXT        NULL,                  -- user id or system:* actor; no foreign key so events outlive users
+    action       TEXT        NOT NULL,              -- e.g. role.assign, jit.approve, auth.login
+    target_type  TEXT        NULL,
+    target_id    TEXT        NULL,
+    outcome      TEXT        NOT NULL DEFAULT 'success' CHECK (outcome IN ('success', 'failure')),
+    reason       TEXT        NULL,                  -- why the action failed
+    before_state JSONB       NULL,
+    after_state  JSONB       NULL,
+    ip           TEXT        NULL,
+    user_agent   TEXT        NULL,
+    request_id   TEXT        NULL
+);
+
+CREATE INDEX idx_audit_events_occurred_at ON audit_events (occurred_at DESC);
+CREATE INDEX idx_audit_events_actor_id ON audit_events (actor_id, occurred_at DESC);
+CREATE INDEX idx_audit_events_target ON audit_events (target_type, target_id);
+CREATE INDEX idx_audit_events_action ON audit_events (action);
+
+-- The audit log is append-only
+CREATE FUNCTION audit_events_append_only() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'audit_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_events_no_update
+    BEFORE UPDATE OR DELETE
+    ON audit_events
+    FOR EACH ROW
+EXECUTE FUNCTION audit_events_append_only();
+
+CREATE TRIGGER audit_events_no_truncate
+    BEFORE TRUNCATE
+    ON audit_events
+    FOR EACH STATEMENT
+EXECUTE FUNCTION audit_events_append_only();
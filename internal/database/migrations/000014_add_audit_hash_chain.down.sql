@@ -0,0 +1,5 @@
+DROP TABLE IF EXISTS audit_checkpoints CASCADE;
+
+ALTER TABLE audit_events
+    DROP COLUMN IF EXISTS prev_hash,
+    DROP COLUMN IF EXISTS hash;
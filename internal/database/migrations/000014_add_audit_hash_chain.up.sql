@@ -0,0 +1,22 @@
+-- Rows written before this migration stay unchained; the chain starts with the first hashed row
+ALTER TABLE audit_events
+    ADD COLUMN prev_hash TEXT NULL,                 -- hash of the previous chained record, NULL for the first
+    ADD COLUMN hash      TEXT NULL;                 -- SHA-256 over prev_hash and the record contents
+
+CREATE TABLE audit_checkpoints
+(
+    id         BIGSERIAL PRIMARY KEY,
+    event_id   BIGINT      NOT NULL,                -- newest audit event covered; no foreign key so deletions stay detectable
+    event_hash TEXT        NOT NULL,
+    key_id     TEXT        NOT NULL,                -- fingerprint of the signing key
+    signature  TEXT        NOT NULL,                -- HMAC-SHA256 over event_id, event_hash and created_at
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_audit_checkpoints_event_id ON audit_checkpoints (event_id);
+
+CREATE TRIGGER audit_checkpoints_no_update
+    BEFORE UPDATE OR DELETE
+    ON audit_checkpoints
+    FOR EACH ROW
+EXECUTE FUNCTION audit_events_append_only();
@@ -0,0 +1,35 @@
+CREATE TABLE webhook_subscriptions
+(
+    id                   TEXT PRIMARY KEY,
+    url                  TEXT        NOT NULL,
+    description          TEXT        NULL,
+    events               JSONB       NOT NULL,                  -- array of event types, e.g. ["role.assigned"]
+    secret               TEXT        NOT NULL,                  -- HMAC key for payload signatures
+    active               BOOLEAN     NOT NULL DEFAULT TRUE,
+    consecutive_failures INTEGER     NOT NULL DEFAULT 0,        -- failed attempts since the last success
+    disabled_at          TIMESTAMPTZ NULL,                      -- set when disabled automatically
+    disabled_reason      TEXT        NULL,
+    created_by           TEXT        NULL REFERENCES users (id) ON DELETE SET NULL,
+    created_at           TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE webhook_deliveries
+(
+    id              TEXT PRIMARY KEY,
+    subscription_id TEXT        NOT NULL REFERENCES webhook_subscriptions (id) ON DELETE CASCADE,
+    event_id        TEXT        NOT NULL,                       -- shared by redeliveries of the same event
+    event_type      TEXT        NOT NULL,
+    payload         JSONB       NOT NULL,
+    status          TEXT        NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'succeeded', 'failed')),
+    attempts        INTEGER     NOT NULL DEFAULT 0,
+    next_attempt_at TIMESTAMPTZ NULL,                           -- also leases the delivery while an attempt is in flight
+    last_attempt_at TIMESTAMPTZ NULL,
+    response_status INTEGER     NULL,
+    response_body   TEXT        NULL,                           -- truncated
+    error           TEXT        NULL,
+    redelivery_of   TEXT        NULL REFERENCES webhook_deliveries (id) ON DELETE SET NULL,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX idx_webhook_deliveries_subscription_id_created_at ON webhook_deliveries (subscription_id, created_at DESC);
+CREATE INDEX idx_webhook_deliveries_due ON webhook_deliveries (next_attempt_at) WHERE status = 'pending';
@@ -0,0 +1,2 @@
+-- disabled users keep their data and roles but can no longer sign in
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMPTZ NULL;
@@ -0,0 +1,11 @@
+-- access token signing keys; the newest unretired key signs, retired keys keep verifying
+-- until the tokens they signed have expired
+CREATE TABLE IF NOT EXISTS signing_keys
+(
+    id         TEXT PRIMARY KEY,
+    secret     BYTEA       NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    retired_at TIMESTAMPTZ NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_signing_keys_created_at ON signing_keys (created_at DESC);
@@ -0,0 +1,10 @@
+DROP INDEX IF EXISTS idx_user_roles_expires_at;
+DROP INDEX IF EXISTS idx_user_roles_user_id;
+
+-- global roles cannot be kept once project_id is required again
+DELETE FROM user_roles WHERE project_id IS NULL;
+
+ALTER TABLE user_roles
+    ALTER COLUMN project_id SET NOT NULL,
+    DROP COLUMN IF EXISTS created_by,
+    DROP COLUMN IF EXISTS expires_at;
@@ -0,0 +1,9 @@
+-- expires_at and created_by were written by the repository without a migration creating
+-- them, and global roles are stored with no project
+ALTER TABLE user_roles
+    ADD COLUMN IF NOT EXISTS expires_at TIMESTAMPTZ NULL, -- NULL for permanent roles
+    ADD COLUMN IF NOT EXISTS created_by TEXT        NULL, -- user id or system:* actor
+    ALTER COLUMN project_id DROP NOT NULL;               -- NULL for global roles
+
+CREATE INDEX IF NOT EXISTS idx_user_roles_user_id ON user_roles (user_id);
+CREATE INDEX IF NOT EXISTS idx_user_roles_expires_at ON user_roles (expires_at) WHERE expires_at IS NOT NULL;
@@ -0,0 +1,2 @@
+-- set the first time the user redeems a code sent to their email
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ NULL;
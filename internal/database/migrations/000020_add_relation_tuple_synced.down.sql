@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_relation_tuples_synced;
+ALTER TABLE relation_tuples DROP COLUMN IF EXISTS synced;
@@ -0,0 +1,5 @@
+-- tuples written by the project role sync are marked so that re-running it can delete those
+-- whose assignment is gone without touching tuples written through the API
+ALTER TABLE relation_tuples ADD COLUMN IF NOT EXISTS synced BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE INDEX IF NOT EXISTS idx_relation_tuples_synced ON relation_tuples (namespace) WHERE synced;
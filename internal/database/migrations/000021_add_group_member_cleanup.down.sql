@@ -0,0 +1,4 @@
+DROP TRIGGER IF EXISTS user_groups_delete_group_members ON user_groups;
+DROP TRIGGER IF EXISTS users_delete_group_members ON users;
+DROP FUNCTION IF EXISTS group_members_delete_group();
+DROP FUNCTION IF EXISTS group_members_delete_user();
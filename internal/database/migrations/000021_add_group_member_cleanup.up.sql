@@ -0,0 +1,33 @@
+-- group_members.member_id points at users or user_groups depending on member_type, so it
+-- cannot have a foreign key. Triggers remove the memberships of deleted members instead.
+DELETE FROM group_members
+WHERE (member_type = 'user' AND member_id NOT IN (SELECT id FROM users))
+   OR (member_type = 'group' AND member_id NOT IN (SELECT id FROM user_groups));
+
+CREATE FUNCTION group_members_delete_user() RETURNS trigger AS
+$$
+BEGIN
+    DELETE FROM group_members WHERE member_type = 'user' AND member_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE FUNCTION group_members_delete_group() RETURNS trigger AS
+$$
+BEGIN
+    DELETE FROM group_members WHERE member_type = 'group' AND member_id = OLD.id;
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER users_delete_group_members
+    AFTER DELETE
+    ON users
+    FOR EACH ROW
+EXECUTE FUNCTION group_members_delete_user();
+
+CREATE TRIGGER user_groups_delete_group_members
+    AFTER DELETE
+    ON user_groups
+    FOR EACH ROW
+EXECUTE FUNCTION group_members_delete_group();
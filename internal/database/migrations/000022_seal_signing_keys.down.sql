@@ -0,0 +1,2 @@
+DELETE FROM signing_keys;
+ALTER TABLE signing_keys RENAME COLUMN sealed_secret TO secret;
@@ -0,0 +1,5 @@
+-- signing key secrets are now stored sealed with the key-encryption key from configuration.
+-- Keys stored in clear are dropped rather than sealed: anyone who read the table could sign
+-- tokens with them. Servers sign with the configured secret until the next rotation.
+DELETE FROM signing_keys;
+ALTER TABLE signing_keys RENAME COLUMN secret TO sealed_secret;
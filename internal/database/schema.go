@@ -0,0 +1,73 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SchemaDriftError lists the tables and columns the code expects but the database lacks.
+type SchemaDriftError struct {
+	Missing []string // "table" or "table.column"
+}
+
+func (e *SchemaDriftError) Error() string {
+	return "schema drift, missing: " + strings.Join(e.Missing, ", ")
+}
+
+// CheckSchema compares the live schema with expected, a map of table name to the columns
+// queries use. It returns a *SchemaDriftError naming everything missing. Extra tables and
+// columns are ignored.
+func CheckSchema(ctx context.Context, db DBTX, expected map[string][]string) error {
+	ctx, cancel := Timeouts{}.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := db.QueryContext(ctx,
+		`SELECT table_name, column_name FROM information_schema.columns
+		 WHERE table_schema = current_schema()`)
+	if err != nil {
+		return fmt.Errorf("failed to read schema: %v", err)
+	}
+	defer rows.Close()
+
+	actual := make(map[string]map[string]bool)
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return err
+		}
+		if actual[table] == nil {
+			actual[table] = make(map[string]bool)
+		}
+		actual[table][column] = true
+	}
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if missing := diffSchema(expected, actual); len(missing) > 0 {
+		return &SchemaDriftError{Missing: missing}
+	}
+	return nil
+}
+
+// diffSchema returns, sorted, the expected tables absent from actual and the expected
+// columns absent from tables that exist.
+func diffSchema(expected map[string][]string, actual map[string]map[string]bool) []string {
+	var missing []string
+	for table, columns := range expected {
+		have, ok := actual[table]
+		if !ok {
+			missing = append(missing, table)
+			continue
+		}
+		for _, column := range columns {
+			if !have[column] {
+				missing = append(missing, table+"."+column)
+			}
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
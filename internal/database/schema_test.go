@@ -0,0 +1,22 @@
+package database_test
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/repository"
+	"context"
+	"testing"
+)
+
+// TestRepositorySchema checks that the embedded migrations create every column the
+// repositories use.
+func TestRepositorySchema(t *testing.T) {
+	srv := database.MustOpen(t)
+	defer srv.Close()
+
+	if err := database.RunMigrations(srv.DB()); err != nil {
+		t.Fatal(err)
+	}
+	if err := database.CheckSchema(context.Background(), srv.DB(), repository.Schema); err != nil {
+		t.Fatalf("CheckSchema() = %v", err)
+	}
+}
@@ -0,0 +1,36 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Default per-operation timeouts.
+const (
+	DefaultReadTimeout  = 5 * time.Second
+	DefaultWriteTimeout = 10 * time.Second
+)
+
+// Timeouts bounds the operations repositories run on one database. A zero duration means
+// the default, so the zero Timeouts is ready to use.
+type Timeouts struct {
+	Read  time.Duration
+	Write time.Duration
+}
+
+// ReadContext bounds a read query by the read timeout. An earlier deadline or cancellation
+// of ctx, such as a client disconnect, still ends the query first.
+func (t Timeouts) ReadContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.Read <= 0 {
+		return context.WithTimeout(ctx, DefaultReadTimeout)
+	}
+	return context.WithTimeout(ctx, t.Read)
+}
+
+// WriteContext bounds a statement that modifies data by the write timeout.
+func (t Timeouts) WriteContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.Write <= 0 {
+		return context.WithTimeout(ctx, DefaultWriteTimeout)
+	}
+	return context.WithTimeout(ctx, t.Write)
+}
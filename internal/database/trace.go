@@ -0,0 +1,57 @@
+package database
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"AuthServer/internal/tracing"
+)
+
+// queryTracer starts a span for each statement pgx runs, including those inside
+// transactions. Statements are recorded as written; their arguments, which can hold
+// password hashes and tokens, are not.
+type queryTracer struct {
+	tracer trace.Tracer
+}
+
+type querySpanKey struct{}
+
+func (t queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	op := operation(data.SQL)
+	ctx, span := t.tracer.Start(ctx, op,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system.name", "postgresql"),
+			attribute.String("db.operation.name", op),
+			attribute.String("db.query.text", data.SQL),
+		),
+	)
+	return context.WithValue(ctx, querySpanKey{}, span)
+}
+
+func (t queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span, ok := ctx.Value(querySpanKey{}).(trace.Span)
+	if !ok {
+		return
+	}
+	if data.Err == nil {
+		span.SetAttributes(attribute.Int64("db.response.rows_affected", data.CommandTag.RowsAffected()))
+	}
+	tracing.End(span, data.Err)
+}
+
+// operation returns the statement's first keyword, such as SELECT, which names its span.
+func operation(sql string) string {
+	sql = strings.TrimSpace(sql)
+	if i := strings.IndexFunc(sql, func(r rune) bool { return r == ' ' || r == '\n' || r == '\t' || r == '(' }); i > 0 {
+		sql = sql[:i]
+	}
+	if sql == "" {
+		return "query"
+	}
+	return strings.ToUpper(sql)
+}
@@ -0,0 +1,60 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+)
+
+// DBTX is implemented by both *sql.DB and *sql.Tx, so a repository can run its queries
+// either directly or inside a transaction it was handed. Only the context-aware methods are
+// included so every query can be cancelled.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Transactor runs a function inside a database transaction.
+type Transactor interface {
+	WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error
+}
+
+func (s *service) WithTx(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	return WithTx(ctx, s.db, fn)
+}
+
+// WithTx begins a transaction, runs fn and commits. The transaction is rolled back if fn
+// returns an error or panics; the panic is re-raised after the rollback.
+func WithTx(ctx context.Context, db *sql.DB, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+
+	defer func() {
+		if p := recover(); p != nil {
+			tx.Rollback()
+			panic(p)
+		}
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err = fn(tx); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("commit transaction: %w", err)
+	}
+	return nil
+}
+
+// LockKey takes a transaction-scoped advisory lock on key. It blocks until concurrent
+// transactions holding the same key finish and is released on commit or rollback.
+func LockKey(ctx context.Context, tx *sql.Tx, key string) error {
+	_, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock(hashtext($1))", key)
+	return err
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// Campaign states.
+const (
+	CampaignActive    = "active"
+	CampaignCompleted = "completed"
+)
+
+// What happens to items still unanswered when a campaign is due.
+const (
+	OverdueRevoke   = "revoke"
+	OverdueEscalate = "escalate"
+)
+
+// Reviewer decisions on an item.
+const (
+	ReviewKeep   = "keep"
+	ReviewRevoke = "revoke"
+)
+
+// AccessReviewCampaign asks reviewers to recertify the permanent role assignments in its scope.
+// A nil ScopeRole or ScopeProjectID does not restrict the scope.
+type AccessReviewCampaign struct {
+	ID                   string     `json:"id"`
+	Name                 string     `json:"name"`
+	ScopeRole            *string    `json:"scope_role,omitempty"`
+	ScopeProjectID       *string    `json:"scope_project_id,omitempty"`
+	ReviewerID           *string    `json:"reviewer_id,omitempty"` // nil assigns project owners, then the creator
+	DueAt                time.Time  `json:"due_at"`
+	OverdueAction        string     `json:"overdue_action"`
+	EscalationReviewerID *string    `json:"escalation_reviewer_id,omitempty"`
+	EscalationGraceHours int        `json:"escalation_grace_hours"`
+	Status               string     `json:"status"`
+	CreatedBy            string     `json:"created_by"`
+	CreatedAt            time.Time  `json:"created_at"`
+	CompletedAt          *time.Time `json:"completed_at,omitempty"`
+}
+
+// AccessReviewItem is one assignment under review. The assignment details are copied at launch
+// so the report stays complete after the user_roles row is revoked.
+type AccessReviewItem struct {
+	ID          string     `json:"id"`
+	CampaignID  string     `json:"campaign_id"`
+	UserRoleID  *string    `json:"user_role_id,omitempty"`
+	UserID      string     `json:"user_id"`
+	Username    string     `json:"username"`
+	Role        string     `json:"role"`
+	ProjectID   *string    `json:"project_id,omitempty"`
+	ReviewerID  string     `json:"reviewer_id"`
+	Decision    *string    `json:"decision,omitempty"`
+	Comment     *string    `json:"comment,omitempty"`
+	DecidedBy   *string    `json:"decided_by,omitempty"` // nil when decided automatically
+	DecidedAt   *time.Time `json:"decided_at,omitempty"`
+	AutoDecided bool       `json:"auto_decided"`
+	EscalatedAt *time.Time `json:"escalated_at,omitempty"`
+}
+
+// AccessReviewSummary counts the items of a campaign by outcome.
+type AccessReviewSummary struct {
+	Total       int `json:"total"`
+	Kept        int `json:"kept"`
+	Revoked     int `json:"revoked"`
+	AutoRevoked int `json:"auto_revoked"`
+	Pending     int `json:"pending"`
+	Escalated   int `json:"escalated"`
+}
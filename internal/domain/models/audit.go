@@ -0,0 +1,114 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Audit event outcomes.
+const (
+	AuditSuccess = "success"
+	AuditFailure = "failure"
+)
+
+// Audited actions. Actions are grouped by the prefix before the first dot.
+const (
+	AuditRoleAssign = "role.assign"
+	AuditRoleRevoke = "role.revoke"
+
+	AuditJITRequest      = "jit.request"
+	AuditJITApprove      = "jit.approve"
+	AuditJITReject       = "jit.reject"
+	AuditJITCancel       = "jit.cancel"
+	AuditJITExtend       = "jit.extend"
+	AuditJITRevoke       = "jit.revoke"
+	AuditJITPolicyCreate = "jit.policy.create"
+	AuditJITPolicyDelete = "jit.policy.delete"
+
+	AuditProjectCreate       = "project.create"
+	AuditProjectUpdate       = "project.update"
+	AuditProjectDelete       = "project.delete"
+	AuditProjectMemberAdd    = "project.member.add"
+	AuditProjectMemberRemove = "project.member.remove"
+
+	AuditRegister           = "auth.register"
+	AuditLogin              = "auth.login"
+	AuditLoginVerify        = "auth.login.verify"
+	AuditVerificationResend = "auth.verification.resend"
+	// AuditFailuresSuppressed summarizes anonymous failures left out of the log by the limits.
+	AuditFailuresSuppressed = "auth.failures.suppressed"
+
+	AuditUserCreate  = "user.create"
+	AuditUserDisable = "user.disable"
+	AuditUserEnable  = "user.enable"
+
+	AuditSigningKeyRotate = "signing_key.rotate"
+	AuditDataImport       = "data.import"
+)
+
+// Audit target types.
+const (
+	AuditTargetUser       = "user"
+	AuditTargetUserRole   = "user_role"
+	AuditTargetJITRequest = "jit_request"
+	AuditTargetJITPolicy  = "jit_policy"
+	AuditTargetProject    = "project"
+	AuditTargetSigningKey = "signing_key"
+)
+
+// AuditEvent is one entry of the append-only audit log. Before and After hold the JSON state
+// of the target around the change, when known. Hash chains the event to PrevHash, the hash of
+// the event before it; events written before the chain existed have no hash.
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	ActorID    *string         `json:"actor_id,omitempty"`
+	Action     string          `json:"action"`
+	TargetType string          `json:"target_type,omitempty"`
+	TargetID   string          `json:"target_id,omitempty"`
+	Outcome    string          `json:"outcome"`
+	Reason     *string         `json:"reason,omitempty"`
+	Before     json.RawMessage `json:"before,omitempty"`
+	After      json.RawMessage `json:"after,omitempty"`
+	IP         string          `json:"ip,omitempty"`
+	UserAgent  string          `json:"user_agent,omitempty"`
+	RequestID  string          `json:"request_id,omitempty"`
+	PrevHash   string          `json:"prev_hash,omitempty"`
+	Hash       string          `json:"hash,omitempty"`
+}
+
+// AuditCheckpoint signs the hash of the newest audit event at a point in time. Checkpoints
+// kept outside the database let a verifier detect a log that was truncated or rewritten.
+type AuditCheckpoint struct {
+	ID        int64     `json:"id"`
+	EventID   int64     `json:"event_id"`
+	EventHash string    `json:"event_hash"`
+	KeyID     string    `json:"key_id"` // signing key ID, or the fingerprint of the configured secret
+	Signature string    `json:"signature"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AuditVerification is the result of walking the audit chain. When OK is false, BrokenAt
+// names the first event (or CheckpointID the checkpoint) that failed and Problem says why.
+type AuditVerification struct {
+	OK                 bool   `json:"ok"`
+	EventsChecked      int    `json:"events_checked"`
+	UnchainedEvents    int    `json:"unchained_events"`
+	CheckpointsChecked int    `json:"checkpoints_checked"`
+	BrokenAt           int64  `json:"broken_at,omitempty"`
+	CheckpointID       int64  `json:"checkpoint_id,omitempty"`
+	Problem            string `json:"problem,omitempty"`
+}
+
+// AuditFilter selects audit events. Empty fields and zero times do not filter.
+type AuditFilter struct {
+	ActorID    string
+	Action     string // exact action, or a prefix ending in "." such as "jit."
+	TargetType string
+	TargetID   string
+	Outcome    string
+	From       time.Time
+	To         time.Time
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"AuthServer/internal/domain/roles"
+	"time"
+)
+
+// ExportVersion is the format version written by export and accepted by import.
+const ExportVersion = 1
+
+// Export is a dump of the users, projects and direct role assignments, for moving data
+// between installations. It holds password hashes and must be kept as securely as the
+// database itself.
+type Export struct {
+	Version    int              `json:"version"`
+	ExportedAt time.Time        `json:"exported_at"`
+	Users      []ExportedUser   `json:"users"`
+	Projects   []Project        `json:"projects"`
+	Roles      []roles.UserRole `json:"roles"`
+}
+
+// ExportedUser is a user with the fields User keeps out of API responses.
+type ExportedUser struct {
+	ID           string     `json:"id"`
+	FullName     string     `json:"full_name"`
+	Username     string     `json:"username"`
+	Email        string     `json:"email"`
+	PasswordHash string     `json:"password_hash"`
+	CreatedAt    time.Time  `json:"created_at"`
+	DisabledAt   *time.Time `json:"disabled_at,omitempty"`
+}
+
+// ImportResult counts the records an import inserted and the ones it skipped because a
+// record with the same ID already existed.
+type ImportResult struct {
+	UsersImported    int `json:"users_imported"`
+	UsersSkipped     int `json:"users_skipped"`
+	ProjectsImported int `json:"projects_imported"`
+	ProjectsSkipped  int `json:"projects_skipped"`
+	RolesImported    int `json:"roles_imported"`
+	RolesSkipped     int `json:"roles_skipped"`
+}
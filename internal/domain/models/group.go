@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+type Group struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedBy   *string   `json:"created_by,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Member types of a group; groups can be nested inside other groups.
+const (
+	MemberTypeUser  = "user"
+	MemberTypeGroup = "group"
+)
+
+type GroupMember struct {
+	GroupID    string    `json:"group_id"`
+	MemberType string    `json:"member_type"`
+	MemberID   string    `json:"member_id"`
+	AddedBy    *string   `json:"added_by,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
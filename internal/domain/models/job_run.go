@@ -0,0 +1,22 @@
+package models
+
+import "time"
+
+// Job run states.
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// JobRun is one execution of a scheduled background job.
+type JobRun struct {
+	ID         int64      `json:"id"`
+	JobName    string     `json:"job_name"`
+	InstanceID string     `json:"instance_id"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+	Status     string     `json:"status"`
+	Items      int        `json:"items"`
+	Error      *string    `json:"error,omitempty"`
+}
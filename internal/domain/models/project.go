@@ -8,3 +8,15 @@ type Project struct {
 	Description string    `json:"description"`
 	CreatedAt   time.Time `json:"created_at"`
 }
+
+// ProjectMember is a user holding a resource-specific role on a project.
+type ProjectMember struct {
+	AssignmentID string     `json:"assignment_id"`
+	UserID       string     `json:"user_id"`
+	Username     string     `json:"username"`
+	FullName     string     `json:"full_name"`
+	Role         string     `json:"role"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	CreatedBy    *string    `json:"created_by,omitempty"`
+	CreatedAt    time.Time  `json:"created_at"`
+}
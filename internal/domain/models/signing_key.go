@@ -0,0 +1,13 @@
+package models
+
+import "time"
+
+// SigningKey is an access token signing key. Tokens name the key that signed them in their
+// kid header. The secret never leaves the server; the database only holds it sealed.
+type SigningKey struct {
+	ID        string     `json:"id"`
+	Secret    []byte     `json:"-"`
+	Sealed    []byte     `json:"-"` // Secret encrypted with the key-encryption key
+	CreatedAt time.Time  `json:"created_at"`
+	RetiredAt *time.Time `json:"retired_at,omitempty"` // set when a newer key replaced it
+}
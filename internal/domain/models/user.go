@@ -3,12 +3,15 @@ package models
 import "time"
 
 type User struct {
-	ID        string    `json:"-"`
-	FullName  string    `json:"full_name"`
-	Username  string    `json:"username"`
-	Email     string    `json:"email"`
-	Password  string    `json:"-"`
-	CreatedAt time.Time `json:"created_at"`
+	ID         string     `json:"-"`
+	FullName   string     `json:"full_name"`
+	Username   string     `json:"username"`
+	Email      string     `json:"email"`
+	Password   string     `json:"-"`
+	CreatedAt  time.Time  `json:"created_at"`
+	DisabledAt *time.Time `json:"disabled_at,omitempty"` // disabled users cannot sign in
+	// EmailVerifiedAt is when the user first redeemed a code sent to their email.
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
 }
 
 //RefreshToken           string        `gorm:"size:255"`
@@ -0,0 +1,56 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Webhook delivery states.
+const (
+	WebhookPending   = "pending"
+	WebhookSucceeded = "succeeded"
+	WebhookFailed    = "failed"
+)
+
+// WebhookSubscription sends the selected events to URL. The secret is only shown when the
+// subscription is created or its secret rotated.
+type WebhookSubscription struct {
+	ID                  string     `json:"id"`
+	URL                 string     `json:"url"`
+	Description         *string    `json:"description,omitempty"`
+	Events              []string   `json:"events"`
+	Secret              string     `json:"-"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	DisabledAt          *time.Time `json:"disabled_at,omitempty"` // set when disabled after repeated failures
+	DisabledReason      *string    `json:"disabled_reason,omitempty"`
+	CreatedBy           *string    `json:"created_by,omitempty"`
+	CreatedAt           time.Time  `json:"created_at"`
+}
+
+// WebhookEvent is the body POSTed to subscribers.
+type WebhookEvent struct {
+	ID        string          `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// WebhookDelivery is one event queued for one subscription, with the outcome of its latest
+// attempt.
+type WebhookDelivery struct {
+	ID             string          `json:"id"`
+	SubscriptionID string          `json:"subscription_id"`
+	EventID        string          `json:"event_id"`
+	EventType      string          `json:"event_type"`
+	Payload        json.RawMessage `json:"payload"`
+	Status         string          `json:"status"`
+	Attempts       int             `json:"attempts"`
+	NextAttemptAt  *time.Time      `json:"next_attempt_at,omitempty"`
+	LastAttemptAt  *time.Time      `json:"last_attempt_at,omitempty"`
+	ResponseStatus *int            `json:"response_status,omitempty"`
+	ResponseBody   *string         `json:"response_body,omitempty"`
+	Error          *string         `json:"error,omitempty"`
+	RedeliveryOf   *string         `json:"redelivery_of,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
@@ -0,0 +1,241 @@
+package rebac
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"strings"
+)
+
+// DefaultConfig is the namespace configuration used when no custom file is configured.
+//
+//go:embed namespaces.conf
+var DefaultConfig string
+
+// RewriteKind describes how one operand of a relation rewrite produces subjects.
+type RewriteKind string
+
+const (
+	// RewriteThis uses the tuples stored directly for the relation.
+	RewriteThis RewriteKind = "this"
+	// RewriteComputedUserset uses another relation on the same object.
+	RewriteComputedUserset RewriteKind = "computed_userset"
+	// RewriteTupleToUserset follows a relation to other objects and uses a relation there.
+	RewriteTupleToUserset RewriteKind = "tuple_to_userset"
+)
+
+// Rewrite is a single operand of the union that defines a relation.
+type Rewrite struct {
+	Kind RewriteKind `json:"kind"`
+	// Relation is the computed relation (computed_userset and tuple_to_userset).
+	Relation string `json:"relation,omitempty"`
+	// Tupleset is the relation followed to reach other objects (tuple_to_userset only).
+	Tupleset string `json:"tupleset,omitempty"`
+}
+
+// Relation is a named relation together with its userset rewrite.
+type Relation struct {
+	Name     string    `json:"name"`
+	Rewrites []Rewrite `json:"rewrites"`
+}
+
+// Namespace groups the relations that are valid on objects of one type.
+type Namespace struct {
+	Name      string               `json:"name"`
+	Relations map[string]*Relation `json:"relations"`
+}
+
+// Config is a parsed namespace configuration.
+type Config struct {
+	Namespaces map[string]*Namespace `json:"namespaces"`
+}
+
+// Relation looks up a relation definition.
+func (c *Config) Relation(namespace, relation string) (*Relation, error) {
+	ns, ok := c.Namespaces[namespace]
+	if !ok {
+		return nil, fmt.Errorf("unknown namespace %q", namespace)
+	}
+	rel, ok := ns.Relations[relation]
+	if !ok {
+		return nil, fmt.Errorf("unknown relation %q in namespace %q", relation, namespace)
+	}
+	return rel, nil
+}
+
+// ValidateTuple checks that a tuple only uses namespaces and relations that are configured.
+func (c *Config) ValidateTuple(t RelationTuple) error {
+	if _, err := c.Relation(t.Object.Namespace, t.Relation); err != nil {
+		return err
+	}
+	if _, ok := c.Namespaces[t.Subject.Namespace]; !ok {
+		return fmt.Errorf("unknown subject namespace %q", t.Subject.Namespace)
+	}
+	if t.Subject.IsSet() {
+		if _, err := c.Relation(t.Subject.Namespace, t.Subject.Relation); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ParseConfig parses the namespace configuration language:
+//
+//	# comment
+//	namespace user {}
+//
+//	namespace project {
+//	    relation parent
+//	    relation owner
+//	    relation editor = this | owner | parent->editor
+//	    relation viewer = this | editor
+//	}
+//
+// A relation without "=" is equivalent to "= this". Operands are "this" (stored tuples),
+// another relation of the same namespace, or tupleset->relation.
+func ParseConfig(src string) (*Config, error) {
+	config := &Config{Namespaces: map[string]*Namespace{}}
+
+	var current *Namespace
+	scanner := bufio.NewScanner(strings.NewReader(src))
+	lineNo := 0
+
+	for scanner.Scan() {
+		lineNo++
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+
+		switch {
+		case strings.HasPrefix(line, "namespace "):
+			if current != nil {
+				return nil, fmt.Errorf("line %d: namespace %q is not closed", lineNo, current.Name)
+			}
+			rest := strings.TrimSpace(strings.TrimPrefix(line, "namespace "))
+			closed := strings.HasSuffix(rest, "{}")
+			if !closed && !strings.HasSuffix(rest, "{") {
+				return nil, fmt.Errorf("line %d: expected '{' after namespace name", lineNo)
+			}
+			name := strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(rest, "{}"), "{"))
+			if !isIdentifier(name) {
+				return nil, fmt.Errorf("line %d: invalid namespace name %q", lineNo, name)
+			}
+			if _, exists := config.Namespaces[name]; exists {
+				return nil, fmt.Errorf("line %d: namespace %q declared twice", lineNo, name)
+			}
+			ns := &Namespace{Name: name, Relations: map[string]*Relation{}}
+			config.Namespaces[name] = ns
+			if !closed {
+				current = ns
+			}
+
+		case line == "}":
+			if current == nil {
+				return nil, fmt.Errorf("line %d: unexpected '}'", lineNo)
+			}
+			current = nil
+
+		case strings.HasPrefix(line, "relation "):
+			if current == nil {
+				return nil, fmt.Errorf("line %d: relation outside of a namespace", lineNo)
+			}
+			rel, err := parseRelation(strings.TrimPrefix(line, "relation "))
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %v", lineNo, err)
+			}
+			if _, exists := current.Relations[rel.Name]; exists {
+				return nil, fmt.Errorf("line %d: relation %q declared twice in namespace %q", lineNo, rel.Name, current.Name)
+			}
+			current.Relations[rel.Name] = rel
+
+		default:
+			return nil, fmt.Errorf("line %d: unexpected %q", lineNo, line)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if current != nil {
+		return nil, fmt.Errorf("namespace %q is not closed", current.Name)
+	}
+
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+
+	return config, nil
+}
+
+func parseRelation(def string) (*Relation, error) {
+	name, expr, hasExpr := strings.Cut(def, "=")
+	name = strings.TrimSpace(name)
+	if !isIdentifier(name) {
+		return nil, fmt.Errorf("invalid relation name %q", name)
+	}
+
+	rel := &Relation{Name: name}
+	if !hasExpr {
+		rel.Rewrites = []Rewrite{{Kind: RewriteThis}}
+		return rel, nil
+	}
+
+	for _, operand := range strings.Split(expr, "|") {
+		operand = strings.TrimSpace(operand)
+		switch {
+		case operand == "this":
+			rel.Rewrites = append(rel.Rewrites, Rewrite{Kind: RewriteThis})
+		case strings.Contains(operand, "->"):
+			tupleset, computed, _ := strings.Cut(operand, "->")
+			tupleset, computed = strings.TrimSpace(tupleset), strings.TrimSpace(computed)
+			if !isIdentifier(tupleset) || !isIdentifier(computed) {
+				return nil, fmt.Errorf("invalid operand %q in relation %q", operand, name)
+			}
+			rel.Rewrites = append(rel.Rewrites, Rewrite{Kind: RewriteTupleToUserset, Tupleset: tupleset, Relation: computed})
+		case isIdentifier(operand):
+			rel.Rewrites = append(rel.Rewrites, Rewrite{Kind: RewriteComputedUserset, Relation: operand})
+		default:
+			return nil, fmt.Errorf("invalid operand %q in relation %q", operand, name)
+		}
+	}
+
+	return rel, nil
+}
+
+// validate makes sure every relation referenced by a rewrite exists in its namespace.
+func (c *Config) validate() error {
+	for _, ns := range c.Namespaces {
+		for _, rel := range ns.Relations {
+			for _, rw := range rel.Rewrites {
+				switch rw.Kind {
+				case RewriteComputedUserset:
+					if _, ok := ns.Relations[rw.Relation]; !ok {
+						return fmt.Errorf("relation %s#%s references unknown relation %q", ns.Name, rel.Name, rw.Relation)
+					}
+				case RewriteTupleToUserset:
+					if _, ok := ns.Relations[rw.Tupleset]; !ok {
+						return fmt.Errorf("relation %s#%s references unknown tupleset %q", ns.Name, rel.Name, rw.Tupleset)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for _, r := range s {
+		if !(r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')) {
+			return false
+		}
+	}
+	return true
+}
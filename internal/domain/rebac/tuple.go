@@ -0,0 +1,132 @@
+package rebac
+
+import (
+	"AuthServer/internal/domain/roles"
+	"fmt"
+	"strings"
+)
+
+// Object identifies a resource as namespace:id, e.g. project:42.
+type Object struct {
+	Namespace string `json:"namespace"`
+	ID        string `json:"id"`
+}
+
+// Subject is either a concrete subject (user:alice) or a subject set
+// (group:eng#member) when Relation is not empty.
+type Subject struct {
+	Namespace string `json:"namespace"`
+	ID        string `json:"id"`
+	Relation  string `json:"relation,omitempty"`
+}
+
+// RelationTuple states that Subject has Relation on Object (object#relation@subject).
+type RelationTuple struct {
+	Object   Object  `json:"object"`
+	Relation string  `json:"relation"`
+	Subject  Subject `json:"subject"`
+}
+
+func (o Object) String() string {
+	return o.Namespace + ":" + o.ID
+}
+
+func (s Subject) String() string {
+	if s.Relation == "" {
+		return s.Namespace + ":" + s.ID
+	}
+	return s.Namespace + ":" + s.ID + "#" + s.Relation
+}
+
+// IsSet reports whether the subject refers to a set of subjects rather than a single one.
+func (s Subject) IsSet() bool {
+	return s.Relation != ""
+}
+
+// Object returns the object a subject set points at.
+func (s Subject) Object() Object {
+	return Object{Namespace: s.Namespace, ID: s.ID}
+}
+
+func (t RelationTuple) String() string {
+	return t.Object.String() + "#" + t.Relation + "@" + t.Subject.String()
+}
+
+// UserSubject returns the subject for a user id.
+func UserSubject(userID string) Subject {
+	return Subject{Namespace: "user", ID: userID}
+}
+
+// ParseObject parses "namespace:id".
+func ParseObject(s string) (Object, error) {
+	ns, id, ok := strings.Cut(s, ":")
+	if !ok || ns == "" || id == "" {
+		return Object{}, fmt.Errorf("invalid object %q, expected namespace:id", s)
+	}
+	return Object{Namespace: ns, ID: id}, nil
+}
+
+// ParseSubject parses "namespace:id" or "namespace:id#relation".
+func ParseSubject(s string) (Subject, error) {
+	objectPart, relation, hasRelation := strings.Cut(s, "#")
+	object, err := ParseObject(objectPart)
+	if err != nil {
+		return Subject{}, fmt.Errorf("invalid subject %q: %v", s, err)
+	}
+	if hasRelation && relation == "" {
+		return Subject{}, fmt.Errorf("invalid subject %q, empty relation", s)
+	}
+	return Subject{Namespace: object.Namespace, ID: object.ID, Relation: relation}, nil
+}
+
+// ParseTuple parses the textual form object#relation@subject,
+// e.g. "project:p1#viewer@group:eng#member".
+func ParseTuple(s string) (RelationTuple, error) {
+	left, subjectPart, ok := strings.Cut(s, "@")
+	if !ok {
+		return RelationTuple{}, fmt.Errorf("invalid tuple %q, missing @subject", s)
+	}
+
+	objectPart, relation, ok := strings.Cut(left, "#")
+	if !ok || relation == "" {
+		return RelationTuple{}, fmt.Errorf("invalid tuple %q, missing #relation", s)
+	}
+
+	object, err := ParseObject(objectPart)
+	if err != nil {
+		return RelationTuple{}, err
+	}
+
+	subject, err := ParseSubject(subjectPart)
+	if err != nil {
+		return RelationTuple{}, err
+	}
+
+	return RelationTuple{Object: object, Relation: relation, Subject: subject}, nil
+}
+
+// projectRoleRelations maps resource-specific roles onto relations of the project namespace.
+var projectRoleRelations = map[roles.Role]string{
+	roles.RoleProjectOwner:  "owner",
+	roles.RoleProjectEditor: "editor",
+	roles.RoleProjectViewer: "viewer",
+}
+
+// TupleFromUserRole expresses a project role assignment as a relation tuple.
+// It returns false for global roles and roles without a project relation.
+func TupleFromUserRole(ur roles.UserRole) (RelationTuple, bool) {
+	if ur.ResourceID == nil {
+		return RelationTuple{}, false
+	}
+
+	relation, ok := projectRoleRelations[ur.Role]
+	if !ok {
+		return RelationTuple{}, false
+	}
+
+	return RelationTuple{
+		Object:   Object{Namespace: "project", ID: *ur.ResourceID},
+		Relation: relation,
+		Subject:  UserSubject(ur.UserID),
+	}, true
+}
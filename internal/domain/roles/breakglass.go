@@ -0,0 +1,53 @@
+package roles
+
+import "time"
+
+// Review states of a break-glass session.
+const (
+	BreakGlassReviewOpen   = "open"
+	BreakGlassReviewClosed = "closed"
+)
+
+// BreakGlassConfig describes the emergency role granted by the break-glass endpoint and who
+// is alerted when it is used.
+type BreakGlassConfig struct {
+	Enabled          bool          `json:"enabled"`
+	Role             Role          `json:"role"`
+	Duration         time.Duration `json:"duration"`
+	AllowedGroup     *string       `json:"allowed_group_id,omitempty"` // required when enabled
+	AlertEmails      []string      `json:"alert_emails"`
+	MinJustification int           `json:"min_justification_length"`
+}
+
+// BreakGlassSession is one use of emergency access. It stays open for review until an admin
+// other than the user closes it.
+type BreakGlassSession struct {
+	ID            string     `json:"id"`
+	UserID        string     `json:"user_id"`
+	Role          Role       `json:"role"`
+	Justification string     `json:"justification"`
+	GrantedRoleID *string    `json:"granted_role_id,omitempty"`
+	StartedAt     time.Time  `json:"started_at"`
+	ExpiresAt     time.Time  `json:"expires_at"`
+	EndedAt       *time.Time `json:"ended_at,omitempty"`
+	ReviewStatus  string     `json:"review_status"`
+	ReviewedBy    *string    `json:"reviewed_by,omitempty"`
+	ReviewedAt    *time.Time `json:"reviewed_at,omitempty"`
+	ReviewNotes   *string    `json:"review_notes,omitempty"`
+}
+
+// Active reports whether the emergency role is still in effect.
+func (s *BreakGlassSession) Active(now time.Time) bool {
+	return s.EndedAt == nil && now.Before(s.ExpiresAt)
+}
+
+// BreakGlassAccess is one request made while a break-glass session was active.
+type BreakGlassAccess struct {
+	ID         int64     `json:"id"`
+	SessionID  string    `json:"session_id"`
+	Method     string    `json:"method"`
+	Path       string    `json:"path"`
+	Status     int       `json:"status"`
+	IP         string    `json:"ip"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
@@ -0,0 +1,11 @@
+package roles
+
+import "time"
+
+// AccessContext carries the request attributes that role assignment conditions are evaluated against.
+type AccessContext struct {
+	Time     time.Time      `json:"time"`
+	IP       string         `json:"ip"`
+	User     map[string]any `json:"user,omitempty"`
+	Resource map[string]any `json:"resource,omitempty"`
+}
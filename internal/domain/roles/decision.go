@@ -0,0 +1,50 @@
+package roles
+
+import "time"
+
+// Outcomes recorded for each assignment considered during a permission check.
+const (
+	OutcomeGranted        = "granted"
+	OutcomeExpired        = "skipped_expired"
+	OutcomeScopeMismatch  = "scope_mismatch"
+	OutcomeRoleMismatch   = "role_mismatch"
+	OutcomeConditionFalse = "condition_false"
+	OutcomeConditionError = "condition_error"
+)
+
+// TraceStep describes how one role assignment was evaluated.
+type TraceStep struct {
+	AssignmentID string  `json:"assignment_id,omitempty"`
+	Role         Role    `json:"role"`
+	ResourceID   *string `json:"resource_id,omitempty"`
+	Outcome      string  `json:"outcome"`
+	Detail       string  `json:"detail"`
+}
+
+// Decision is the result of a permission check together with its evaluation trace.
+type Decision struct {
+	UserID       string      `json:"user_id"`
+	RequiredRole Role        `json:"required_role"`
+	ResourceID   *string     `json:"resource_id,omitempty"`
+	Allowed      bool        `json:"allowed"`
+	Reason       string      `json:"reason"`
+	Trace        []TraceStep `json:"trace"`
+}
+
+// SimulatedChange is a proposed grant or revoke that is evaluated without being stored.
+type SimulatedChange struct {
+	Op           string     `json:"op" binding:"required,oneof=grant revoke"`
+	AssignmentID string     `json:"assignment_id,omitempty"` // revoke a specific assignment
+	Role         Role       `json:"role,omitempty"`
+	ResourceID   *string    `json:"resource_id,omitempty"`
+	ExpiresAt    *time.Time `json:"expires_at,omitempty"`
+	Condition    *string    `json:"condition,omitempty"`
+}
+
+// Simulation compares a decision before and after a set of proposed changes.
+type Simulation struct {
+	Changes []SimulatedChange `json:"changes"`
+	Before  *Decision         `json:"before"`
+	After   *Decision         `json:"after"`
+	Changed bool              `json:"changed"`
+}
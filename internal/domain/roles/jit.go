@@ -0,0 +1,64 @@
+package roles
+
+import "time"
+
+// JIT request states. Pending requests end as approved, rejected, cancelled or expired;
+// approved grants end as expired or revoked.
+const (
+	JITStatusPending   = "pending"
+	JITStatusApproved  = "approved"
+	JITStatusRejected  = "rejected"
+	JITStatusExpired   = "expired"
+	JITStatusCancelled = "cancelled"
+	JITStatusRevoked   = "revoked"
+)
+
+// Decisions recorded in jit_approvals.
+const (
+	ApprovalApproved     = "approved"
+	ApprovalRejected     = "rejected"
+	ApprovalAutoApproved = "auto_approved"
+)
+
+// JITApprovalPolicy defines how requests for a role (optionally on one resource) are approved.
+type JITApprovalPolicy struct {
+	ID                     string    `json:"id"`
+	Role                   Role      `json:"role"`
+	ResourceID             *string   `json:"resource_id,omitempty"`
+	RequiredApprovals      int       `json:"required_approvals"`
+	ApproverGroupID        *string   `json:"approver_group_id,omitempty"`
+	AutoApproveMaxMinutes  *int      `json:"auto_approve_max_minutes,omitempty"`
+	EscalationAfterMinutes *int      `json:"escalation_after_minutes,omitempty"`
+	EscalationGroupID      *string   `json:"escalation_group_id,omitempty"`
+	CreatedBy              *string   `json:"created_by,omitempty"`
+	CreatedAt              time.Time `json:"created_at"`
+}
+
+// DefaultJITApprovalPolicy applies when no policy matches: one approval by an admin or manager.
+func DefaultJITApprovalPolicy(role Role, resourceID *string) *JITApprovalPolicy {
+	return &JITApprovalPolicy{
+		Role:              role,
+		ResourceID:        resourceID,
+		RequiredApprovals: 1,
+	}
+}
+
+// JITApproval is a single approval step recorded against a request.
+type JITApproval struct {
+	ID         string    `json:"id"`
+	RequestID  string    `json:"request_id"`
+	ApproverID *string   `json:"approver_id,omitempty"`
+	Tier       int       `json:"tier"`
+	Decision   string    `json:"decision"`
+	Comment    *string   `json:"comment,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// JITApprovalProgress summarises where a request stands after a decision.
+type JITApprovalProgress struct {
+	RequestID string `json:"request_id"`
+	Status    string `json:"status"`
+	Tier      int    `json:"tier"`
+	Approvals int    `json:"approvals"`
+	Required  int    `json:"required"`
+}
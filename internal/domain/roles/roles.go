@@ -19,6 +19,7 @@ const (
 
 // resource‑specific roles
 const (
+	RoleProjectOwner  Role = "project_owner"
 	RoleProjectEditor Role = "project_editor"
 	RoleProjectViewer Role = "project_viewer"
 )
@@ -29,24 +30,70 @@ var RoleHierarchy = map[Role]int{
 	RoleUser:    1,
 }
 
+// ResourceRoleHierarchy orders the roles that are assigned on a single resource.
+// Levels are only comparable within the same hierarchy.
+var ResourceRoleHierarchy = map[Role]int{
+	RoleProjectOwner:  3,
+	RoleProjectEditor: 2,
+	RoleProjectViewer: 1,
+}
+
+// CompareLevels returns the levels of both roles and whether they belong to the same hierarchy.
+func CompareLevels(userRole, requiredRole Role) (int, int, bool) {
+	for _, hierarchy := range []map[Role]int{RoleHierarchy, ResourceRoleHierarchy} {
+		userLevel, userExists := hierarchy[userRole]
+		requiredLevel, requiredExists := hierarchy[requiredRole]
+		if userExists && requiredExists {
+			return userLevel, requiredLevel, true
+		}
+	}
+	return 0, 0, false
+}
+
 // UserRole represents a role assignment, optionally resource-specific and JIT-bound.
 type UserRole struct {
+	ID         string     `json:"id,omitempty"`
 	UserID     string     `json:"user_id"`
 	Role       Role       `json:"role"`
 	ResourceID *string    `json:"resource_id,omitempty"` // if nil then global role
 	ExpiresAt  *time.Time `json:"expires_at,omitempty"`  // if nil then permanent role
+	Condition  *string    `json:"condition,omitempty"`   // if nil then unconditional
+	GroupID    *string    `json:"group_id,omitempty"`    // set when inherited through group membership
+	GroupName  *string    `json:"group_name,omitempty"`
+}
+
+// IsResourceRole reports whether the role can only be assigned on a specific resource.
+func IsResourceRole(role Role) bool {
+	_, ok := ResourceRoleHierarchy[role]
+	return ok
+}
+
+// IsGlobalRole reports whether the role can be assigned without a resource.
+func IsGlobalRole(role Role) bool {
+	if _, ok := RoleHierarchy[role]; ok {
+		return true
+	}
+	return role == RolePlatformModerator || role == RoleReporter
 }
 
 // JITRequest represents a user's request to temporarily activate a role.
 type JITRequestDB struct {
-	ID              string    `db:"id" json:"id"`
-	UserID          string    `db:"user_id" json:"user_id"`
-	Role            string    `db:"role" json:"role"`
-	ResourceID      *string   `db:"resource_id" json:"resource_id,omitempty"` // nullable
-	DurationMinutes int       `db:"duration_minutes" json:"duration_minutes"`
-	Reason          *string   `db:"reason" json:"reason,omitempty"`
-	Status          string    `db:"status" json:"status"` // pending, approved, rejected
-	ApprovedBy      *string   `db:"approved_by" json:"approved_by,omitempty"`
-	CreatedAt       time.Time `db:"created_at" json:"created_at"`
-	UpdatedAt       time.Time `db:"updated_at" json:"updated_at"`
+	ID              string     `db:"id" json:"id"`
+	UserID          string     `db:"user_id" json:"user_id"`
+	Role            string     `db:"role" json:"role"`
+	ResourceID      *string    `db:"resource_id" json:"resource_id,omitempty"` // nullable
+	DurationMinutes int        `db:"duration_minutes" json:"duration_minutes"`
+	Reason          *string    `db:"reason" json:"reason,omitempty"`
+	Status          string     `db:"status" json:"status"` // one of the JITStatus constants
+	ApprovedBy      *string    `db:"approved_by" json:"approved_by,omitempty"`
+	PolicyID        *string    `db:"policy_id" json:"policy_id,omitempty"`
+	ApprovalTier    int        `db:"approval_tier" json:"approval_tier"`
+	EscalatedAt     *time.Time `db:"escalated_at" json:"escalated_at,omitempty"`
+	GrantedRoleID   *string    `db:"granted_role_id" json:"granted_role_id,omitempty"`
+	GrantExpiresAt  *time.Time `db:"grant_expires_at" json:"grant_expires_at,omitempty"`
+	ParentRequestID *string    `db:"parent_request_id" json:"parent_request_id,omitempty"` // set on extension requests
+	EndedBy         *string    `db:"ended_by" json:"ended_by,omitempty"`
+	EndedAt         *time.Time `db:"ended_at" json:"ended_at,omitempty"`
+	CreatedAt       time.Time  `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time  `db:"updated_at" json:"updated_at"`
 }
@@ -0,0 +1,49 @@
+package roles
+
+import "time"
+
+// Separation-of-duties rule names, reported in violations.
+const (
+	SoDMutuallyExclusive = "mutually_exclusive_roles"
+	SoDNoSelfApproval    = "no_self_approval"
+	SoDNoSelfGrant       = "no_self_grant"
+	SoDApproverOutranks  = "approver_must_outrank_requester"
+)
+
+// SoDPolicy configures which separation-of-duties rules are enforced. The outrank rule
+// applies to approvers who qualify through their role; members of a policy's approver group
+// were chosen for the request and are not compared by rank.
+type SoDPolicy struct {
+	MutuallyExclusive            [][2]Role `json:"mutually_exclusive"`
+	NoSelfApproval               bool      `json:"no_self_approval"`
+	NoSelfGrant                  bool      `json:"no_self_grant"`
+	ApproverMustOutrankRequester bool      `json:"approver_must_outrank_requester"`
+}
+
+// DefaultSoDPolicy enforces every rule except mutually exclusive pairs, which are deployment specific.
+func DefaultSoDPolicy() SoDPolicy {
+	return SoDPolicy{
+		MutuallyExclusive:            [][2]Role{},
+		NoSelfApproval:               true,
+		NoSelfGrant:                  true,
+		ApproverMustOutrankRequester: true,
+	}
+}
+
+// SoDViolation is returned (and recorded) when an assignment or approval breaks a rule.
+type SoDViolation struct {
+	ID              string    `json:"id,omitempty"`
+	Rule            string    `json:"rule"`
+	Message         string    `json:"message"`
+	ActorID         string    `json:"actor_id"`
+	TargetUserID    string    `json:"target_user_id"`
+	Role            Role      `json:"role"`
+	ResourceID      *string   `json:"resource_id,omitempty"`
+	ConflictingRole *Role     `json:"conflicting_role,omitempty"`
+	RequestID       *string   `json:"request_id,omitempty"` // JIT request, for approval violations
+	CreatedAt       time.Time `json:"created_at"`
+}
+
+func (v *SoDViolation) Error() string {
+	return "separation of duties violation (" + v.Rule + "): " + v.Message
+}
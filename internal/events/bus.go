@@ -0,0 +1,220 @@
+// Package events is an in-process bus for identity and authorization events. Services
+// publish to it; the server-sent event stream and the webhook dispatcher consume from it.
+package events
+
+import (
+	"AuthServer/internal/domain/roles"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Event types.
+const (
+	UserRegistered = "user.registered"
+	UserVerified   = "user.verified"
+
+	RoleAssigned = "role.assigned"
+	RoleRevoked  = "role.revoked"
+
+	JITRequested        = "jit.requested"
+	JITApprovalRecorded = "jit.approval_recorded" // one approval of a multi-approver request
+	JITApproved         = "jit.approved"
+	JITRejected         = "jit.rejected"
+	JITCancelled        = "jit.cancelled"
+	JITRevoked          = "jit.revoked"
+
+	ProjectCreated = "project.created"
+	ProjectDeleted = "project.deleted"
+
+	BreakGlassActivated = "break_glass.activated"
+)
+
+// Types lists every event type, in the order above.
+var Types = []string{
+	UserRegistered,
+	UserVerified,
+	RoleAssigned,
+	RoleRevoked,
+	JITRequested,
+	JITApprovalRecorded,
+	JITApproved,
+	JITRejected,
+	JITCancelled,
+	JITRevoked,
+	ProjectCreated,
+	ProjectDeleted,
+	BreakGlassActivated,
+}
+
+// audience is the lowest global role that may see each event type in the stream. The user
+// an event is about may always see it.
+var audience = map[string]roles.Role{
+	UserRegistered:      roles.RoleAdmin,
+	UserVerified:        roles.RoleAdmin,
+	RoleAssigned:        roles.RoleManager,
+	RoleRevoked:         roles.RoleManager,
+	JITRequested:        roles.RoleManager,
+	JITApprovalRecorded: roles.RoleManager,
+	JITApproved:         roles.RoleManager,
+	JITRejected:         roles.RoleManager,
+	JITCancelled:        roles.RoleManager,
+	JITRevoked:          roles.RoleManager,
+	ProjectCreated:      roles.RoleAdmin,
+	ProjectDeleted:      roles.RoleAdmin,
+	BreakGlassActivated: roles.RoleAdmin,
+}
+
+// DefaultHistorySize is how many recent events are kept for replay.
+const DefaultHistorySize = 1000
+
+// subscriberBuffer is how many events a subscriber may fall behind before it is dropped.
+const subscriberBuffer = 64
+
+// Event is one published event. IDs increase across the life of the bus and start from the
+// time the bus was created, so IDs issued before a restart sort before the new ones.
+type Event struct {
+	ID         uint64          `json:"id"`
+	Type       string          `json:"type"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	UserID     string          `json:"user_id,omitempty"` // the user the event is about
+	Data       json.RawMessage `json:"data"`
+}
+
+// VisibleTo reports whether a user whose highest global role has the given hierarchy level
+// may see the event.
+func (e Event) VisibleTo(userID string, level int) bool {
+	if e.UserID != "" && e.UserID == userID {
+		return true
+	}
+	role, ok := audience[e.Type]
+	if !ok {
+		role = roles.RoleAdmin
+	}
+	return level >= roles.RoleHierarchy[role]
+}
+
+// Handler is called synchronously for every published event.
+type Handler func(ctx context.Context, e Event)
+
+// Bus fans events out to handlers and subscribers and keeps a short history so that
+// subscribers can resume after a reconnect.
+type Bus struct {
+	mu       sync.Mutex
+	nextID   uint64
+	history  []Event // ring buffer of the last len(history) events
+	count    int     // events stored in history
+	handlers []Handler
+	subs     map[*Subscription]struct{}
+}
+
+func NewBus(historySize int) *Bus {
+	if historySize <= 0 {
+		historySize = DefaultHistorySize
+	}
+	return &Bus{
+		nextID:  uint64(time.Now().UnixMicro()),
+		history: make([]Event, historySize),
+		subs:    make(map[*Subscription]struct{}),
+	}
+}
+
+// Handle registers h for every event published from now on.
+func (b *Bus) Handle(h Handler) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, h)
+}
+
+// Publish records an event about userID, which may be empty, and delivers it. Handlers run
+// in the caller's goroutine; subscribers that cannot keep up are dropped. A nil bus discards
+// the event.
+func (b *Bus) Publish(ctx context.Context, eventType, userID string, data any) {
+	if b == nil {
+		return
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode event", "type", eventType, "error", err)
+		return
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	e := Event{
+		ID:         b.nextID,
+		Type:       eventType,
+		OccurredAt: time.Now().UTC(),
+		UserID:     userID,
+		Data:       raw,
+	}
+	b.history[int(e.ID%uint64(len(b.history)))] = e
+	if b.count < len(b.history) {
+		b.count++
+	}
+	for sub := range b.subs {
+		select {
+		case sub.ch <- e:
+		default:
+			b.dropLocked(sub)
+		}
+	}
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	for _, h := range handlers {
+		h(ctx, e)
+	}
+}
+
+// Subscription receives events published after it was created. C is closed when the
+// subscription is closed or dropped for falling behind.
+type Subscription struct {
+	C      <-chan Event
+	ch     chan Event
+	bus    *Bus
+	closed bool
+}
+
+// Subscribe returns the events after lastID that are still in the history, and a
+// subscription for everything published afterwards. complete is false if lastID is set but
+// some events after it are no longer in the history, so the caller has missed events.
+func (b *Bus) Subscribe(lastID uint64) (sub *Subscription, replay []Event, complete bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if lastID != 0 {
+		oldest := b.nextID - uint64(b.count) + 1
+		if lastID+1 < oldest || lastID > b.nextID {
+			complete = false
+		}
+		for id := max(lastID+1, oldest); id <= b.nextID && b.count > 0; id++ {
+			replay = append(replay, b.history[int(id%uint64(len(b.history)))])
+		}
+	}
+
+	ch := make(chan Event, subscriberBuffer)
+	sub = &Subscription{C: ch, ch: ch, bus: b}
+	b.subs[sub] = struct{}{}
+	return sub, replay, complete
+}
+
+// Close stops the subscription and closes C.
+func (s *Subscription) Close() {
+	s.bus.mu.Lock()
+	defer s.bus.mu.Unlock()
+	s.bus.dropLocked(s)
+}
+
+func (b *Bus) dropLocked(s *Subscription) {
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(b.subs, s)
+	close(s.ch)
+}
@@ -0,0 +1,81 @@
+package events
+
+import (
+	"context"
+	"testing"
+)
+
+func TestBusReplay(t *testing.T) {
+	bus := NewBus(3)
+	ctx := context.Background()
+
+	first, _, _ := bus.Subscribe(0)
+	defer first.Close()
+	for i := 0; i < 5; i++ {
+		bus.Publish(ctx, JITRequested, "u1", map[string]int{"n": i})
+	}
+
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		ids = append(ids, (<-first.C).ID)
+	}
+	for i := 1; i < len(ids); i++ {
+		if ids[i] != ids[i-1]+1 {
+			t.Fatalf("event ids not consecutive: %v", ids)
+		}
+	}
+
+	sub, replay, complete := bus.Subscribe(ids[2])
+	sub.Close()
+	if !complete || len(replay) != 2 || replay[0].ID != ids[3] || replay[1].ID != ids[4] {
+		t.Fatalf("resume after %d: complete=%v replay=%v", ids[2], complete, replay)
+	}
+
+	// ids[0] and ids[1] have left the three-event history
+	sub, replay, complete = bus.Subscribe(ids[0])
+	sub.Close()
+	if complete || len(replay) != 3 || replay[0].ID != ids[2] {
+		t.Fatalf("resume after evicted id: complete=%v replay=%v", complete, replay)
+	}
+
+	// An id from before a restart is unknown to a new bus
+	sub, replay, complete = NewBus(3).Subscribe(ids[4])
+	sub.Close()
+	if complete || len(replay) != 0 {
+		t.Fatalf("resume on new bus: complete=%v replay=%v", complete, replay)
+	}
+}
+
+func TestBusDropsSlowSubscriber(t *testing.T) {
+	bus := NewBus(0)
+	sub, _, _ := bus.Subscribe(0)
+
+	for i := 0; i <= subscriberBuffer; i++ {
+		bus.Publish(context.Background(), RoleAssigned, "u1", nil)
+	}
+
+	n := 0
+	for range sub.C {
+		n++
+	}
+	if n != subscriberBuffer {
+		t.Fatalf("expected %d buffered events before the drop, got %d", subscriberBuffer, n)
+	}
+	sub.Close()
+}
+
+func TestEventVisibility(t *testing.T) {
+	e := Event{Type: RoleAssigned, UserID: "u1"}
+	if !e.VisibleTo("u1", 1) {
+		t.Error("user cannot see an event about themselves")
+	}
+	if e.VisibleTo("u2", 1) {
+		t.Error("plain user can see another user's role change")
+	}
+	if !e.VisibleTo("u2", 2) {
+		t.Error("manager cannot see a role change")
+	}
+	if (Event{Type: UserRegistered, UserID: "u3"}).VisibleTo("u2", 2) {
+		t.Error("manager can see an admin-only event")
+	}
+}
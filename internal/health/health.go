@@ -0,0 +1,110 @@
+// Package health runs the dependency checks behind the readiness probe. Checks run
+// concurrently, each under its own timeout, so one slow dependency cannot hide the state of
+// the others or hold the probe past its deadline.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Statuses of a check and of a whole report.
+const (
+	StatusUp       = "up"
+	StatusDown     = "down"
+	StatusDegraded = "degraded" // only non-critical checks failed
+)
+
+// Check is one dependency of the server.
+type Check struct {
+	Name string
+	// Critical checks make the server unready when they fail; others only degrade it.
+	Critical bool
+	Run      func(ctx context.Context) error
+}
+
+// Result is the outcome of one check.
+type Result struct {
+	Name      string  `json:"name"`
+	Status    string  `json:"status"`
+	Critical  bool    `json:"critical"`
+	LatencyMS float64 `json:"latency_ms"`
+	Error     string  `json:"error,omitempty"`
+}
+
+// Report is the outcome of every check, in the order they were given.
+type Report struct {
+	Status string   `json:"status"`
+	Checks []Result `json:"checks"`
+}
+
+// Ready reports whether every critical check passed.
+func (r Report) Ready() bool {
+	return r.Status != StatusDown
+}
+
+// WithoutErrors returns a copy of r without the error messages, which can name hosts and
+// files, for showing to unauthenticated callers.
+func (r Report) WithoutErrors() Report {
+	checks := make([]Result, len(r.Checks))
+	for i, c := range r.Checks {
+		c.Error = ""
+		checks[i] = c
+	}
+	return Report{Status: r.Status, Checks: checks}
+}
+
+// Run runs checks concurrently, giving each at most timeout, and collects their results.
+func Run(ctx context.Context, timeout time.Duration, checks []Check) Report {
+	results := make([]Result, len(checks))
+
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = run(ctx, timeout, check)
+		}()
+	}
+	wg.Wait()
+
+	report := Report{Status: StatusUp, Checks: results}
+	for _, r := range results {
+		if r.Status == StatusUp {
+			continue
+		}
+		if r.Critical {
+			report.Status = StatusDown
+			break
+		}
+		report.Status = StatusDegraded
+	}
+	return report
+}
+
+func run(ctx context.Context, timeout time.Duration, check Check) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	result := Result{Name: check.Name, Status: StatusUp, Critical: check.Critical}
+	start := time.Now()
+
+	// A check that ignores its context still cannot hold the probe past the timeout
+	done := make(chan error, 1)
+	go func() { done <- check.Run(ctx) }()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+
+	result.LatencyMS = float64(time.Since(start).Microseconds()) / 1000
+	if err != nil {
+		result.Status = StatusDown
+		result.Error = err.Error()
+	}
+	return result
+}
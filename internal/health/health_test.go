@@ -0,0 +1,56 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func check(name string, critical bool, err error) Check {
+	return Check{Name: name, Critical: critical, Run: func(context.Context) error { return err }}
+}
+
+func TestRunStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		checks []Check
+		want   string
+	}{
+		{"all up", []Check{check("db", true, nil), check("mail", false, nil)}, StatusUp},
+		{"non-critical down", []Check{check("db", true, nil), check("mail", false, errors.New("unreachable"))}, StatusDegraded},
+		{"critical down", []Check{check("mail", false, errors.New("unreachable")), check("db", true, errors.New("refused"))}, StatusDown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := Run(context.Background(), time.Second, tt.checks)
+			if report.Status != tt.want {
+				t.Fatalf("status %s, want %s", report.Status, tt.want)
+			}
+			for i, c := range tt.checks {
+				if report.Checks[i].Name != c.Name {
+					t.Fatalf("results out of order: %+v", report.Checks)
+				}
+			}
+		})
+	}
+}
+
+func TestRunTimesOutStuckCheck(t *testing.T) {
+	stuck := Check{Name: "mail", Critical: true, Run: func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	}}
+
+	start := time.Now()
+	report := Run(context.Background(), 20*time.Millisecond, []Check{stuck})
+	if time.Since(start) > 500*time.Millisecond {
+		t.Fatal("a check that ignores its context held the probe")
+	}
+	if report.Ready() || report.Checks[0].Error == "" {
+		t.Fatalf("expected the stuck check to fail: %+v", report)
+	}
+	if report.WithoutErrors().Checks[0].Error != "" {
+		t.Fatal("WithoutErrors kept the error")
+	}
+}
@@ -0,0 +1,156 @@
+// Package logging sets up the process-wide structured logger. Records are written as JSON
+// (or text for local development), carry the request and trace IDs found in their context,
+// and have passwords, verification codes, tokens and other secrets redacted before they are
+// written.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Redacted replaces secret values in log records.
+const Redacted = "[redacted]"
+
+// Config selects the minimum level and the output format.
+type Config struct {
+	Level  string `yaml:"level" env:"LOG_LEVEL"`   // debug, info, warn or error
+	Format string `yaml:"format" env:"LOG_FORMAT"` // json or text
+}
+
+// DefaultConfig logs JSON at info level.
+var DefaultConfig = Config{Level: "info", Format: "json"}
+
+// Validate reports whether the level and format are known.
+func (c Config) Validate() error {
+	if _, err := ParseLevel(c.Level); err != nil {
+		return err
+	}
+	if c.Format != "json" && c.Format != "text" {
+		return fmt.Errorf("log.format must be json or text, not %q", c.Format)
+	}
+	return nil
+}
+
+// ParseLevel parses debug, info, warn or error, in any case.
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(s)); err != nil {
+		return 0, fmt.Errorf("log.level must be debug, info, warn or error, not %q", s)
+	}
+	return level, nil
+}
+
+// New returns a logger writing to w as cfg describes.
+func New(w io.Writer, cfg Config) (*slog.Logger, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	level, _ := ParseLevel(cfg.Level)
+
+	opts := &slog.HandlerOptions{Level: level, ReplaceAttr: redactAttr}
+	var h slog.Handler
+	if cfg.Format == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(contextHandler{h}), nil
+}
+
+// Setup makes a logger writing to stderr the default for slog and for the log package.
+func Setup(cfg Config) error {
+	logger, err := New(os.Stderr, cfg)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a context whose log records carry id as request_id.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID stored in ctx, or "".
+func RequestID(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// contextHandler adds the request ID and the current span from the record's context.
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, r slog.Record) error {
+	if id := RequestID(ctx); id != "" {
+		r.AddAttrs(slog.String("request_id", id))
+	}
+	if sc := trace.SpanContextFromContext(ctx); sc.IsValid() {
+		r.AddAttrs(slog.String("trace_id", sc.TraceID().String()), slog.String("span_id", sc.SpanID().String()))
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
+
+// sensitiveKeys are attribute keys, and query parameters, whose values are never logged.
+// Keys containing password, secret or token are redacted as well.
+var sensitiveKeys = map[string]bool{
+	"code":              true,
+	"verification_code": true,
+	"authorization":     true,
+	"cookie":            true,
+	"set-cookie":        true,
+	"api_key":           true,
+}
+
+// IsSensitive reports whether values under key must be redacted.
+func IsSensitive(key string) bool {
+	key = strings.ToLower(key)
+	return sensitiveKeys[key] ||
+		strings.Contains(key, "password") ||
+		strings.Contains(key, "secret") ||
+		strings.Contains(key, "token")
+}
+
+// sensitiveParam finds key=value pairs with a sensitive key inside free text, such as a URL
+// or a message built with fmt.
+var sensitiveParam = regexp.MustCompile(`(?i)\b([a-z_-]*(?:password|secret|token)[a-z_-]*|code|verification_code|api_key)=([^&\s"]+)`)
+
+// RedactString replaces the values of sensitive key=value pairs in s.
+func RedactString(s string) string {
+	if !strings.Contains(s, "=") {
+		return s
+	}
+	return sensitiveParam.ReplaceAllString(s, "$1="+Redacted)
+}
+
+func redactAttr(_ []string, a slog.Attr) slog.Attr {
+	if IsSensitive(a.Key) {
+		return slog.String(a.Key, Redacted)
+	}
+	if a.Value.Kind() == slog.KindString {
+		if s := a.Value.String(); strings.Contains(s, "=") {
+			return slog.String(a.Key, RedactString(s))
+		}
+	}
+	return a
+}
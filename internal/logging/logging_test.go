@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestRedaction(t *testing.T) {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Config{Level: "debug", Format: "json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := WithRequestID(context.Background(), "req-1")
+	logger.InfoContext(ctx, "login code=123456 sent",
+		"password", "hunter2",
+		slog.Group("body", "access_token", "abc", "username", "alice"),
+		"path", "/api/events/stream?access_token=xyz&types=role.assigned",
+		"status_code", 200,
+	)
+
+	out := buf.String()
+	for _, secret := range []string{"hunter2", "abc", "xyz", "123456"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log contains %q: %s", secret, out)
+		}
+	}
+
+	var record map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &record); err != nil {
+		t.Fatal(err)
+	}
+	if record["request_id"] != "req-1" {
+		t.Errorf("request_id = %v, want req-1", record["request_id"])
+	}
+	if record["path"] != "/api/events/stream?access_token=[redacted]&types=role.assigned" {
+		t.Errorf("path = %v", record["path"])
+	}
+	if record["status_code"] != float64(200) || record["body"].(map[string]any)["username"] != "alice" {
+		t.Errorf("non-secret values were changed: %s", out)
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	if err := DefaultConfig.Validate(); err != nil {
+		t.Fatalf("default config invalid: %v", err)
+	}
+	if err := (Config{Level: "WARN", Format: "text"}).Validate(); err != nil {
+		t.Fatalf("upper-case level rejected: %v", err)
+	}
+	if (Config{Level: "verbose", Format: "json"}).Validate() == nil {
+		t.Fatal("unknown level accepted")
+	}
+	if (Config{Level: "info", Format: "xml"}).Validate() == nil {
+		t.Fatal("unknown format accepted")
+	}
+}
@@ -0,0 +1,216 @@
+// Package metrics collects the Prometheus series the server exposes on /metrics. Each
+// Metrics has its own registry rather than using the global one, so several apps can run in
+// one process without their series colliding.
+package metrics
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Login attempt outcomes.
+const (
+	LoginSuccess       = "success"
+	LoginUnknownUser   = "unknown_user"
+	LoginWrongPassword = "wrong_password"
+	LoginDisabled      = "disabled"
+)
+
+// Verification code redemption outcomes.
+const (
+	CodeRedeemed = "success"
+	CodeInvalid  = "invalid"
+	CodeExpired  = "expired"
+	CodeRejected = "rejected" // valid code, but the user is unknown or disabled
+)
+
+// collectTimeout bounds the queries run while a scrape is collected.
+const collectTimeout = 5 * time.Second
+
+// Metrics holds the server's series. The recording methods do nothing on a nil *Metrics, so
+// services built without one, as in tests, need no checks.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	httpRequests       *prometheus.CounterVec
+	httpDuration       *prometheus.HistogramVec
+	loginAttempts      *prometheus.CounterVec
+	codesIssued        *prometheus.CounterVec
+	codesRedeemed      *prometheus.CounterVec
+	permissionChecks   *prometheus.CounterVec
+	permissionDuration prometheus.Histogram
+	mailSends          *prometheus.CounterVec
+}
+
+// New returns a Metrics with the Go runtime and process collectors registered.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_requests_total",
+			Help: "HTTP requests by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "http_request_duration_seconds",
+			Help:    "HTTP request latency by method and route.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		loginAttempts: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_login_attempts_total",
+			Help: "Password login attempts by outcome.",
+		}, []string{"outcome"}),
+		codesIssued: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_verification_codes_issued_total",
+			Help: "Verification codes issued, by the step that issued them.",
+		}, []string{"reason"}),
+		codesRedeemed: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "auth_verification_codes_redeemed_total",
+			Help: "Verification code redemptions by outcome.",
+		}, []string{"outcome"}),
+		permissionChecks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "rbac_permission_checks_total",
+			Help: "RBAC permission checks by decision.",
+		}, []string{"decision"}),
+		permissionDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "rbac_permission_check_duration_seconds",
+			Help:    "RBAC permission check latency, including loading the user's roles.",
+			Buckets: []float64{.0005, .001, .0025, .005, .01, .025, .05, .1, .25, .5, 1},
+		}),
+		mailSends: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "mail_send_total",
+			Help: "Mail sends by outcome.",
+		}, []string{"outcome"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.httpRequests,
+		m.httpDuration,
+		m.loginAttempts,
+		m.codesIssued,
+		m.codesRedeemed,
+		m.permissionChecks,
+		m.permissionDuration,
+		m.mailSends,
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus exposition format. A collector that fails
+// is logged and left out rather than failing the whole scrape.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		ErrorLog:      slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
+		ErrorHandling: promhttp.ContinueOnError,
+		Registry:      m.registry,
+	})
+}
+
+// ObserveRequest records a completed HTTP request. route is the route pattern, not the
+// path, so IDs in paths don't create a series each.
+func (m *Metrics) ObserveRequest(method, route string, status int, d time.Duration) {
+	if m == nil {
+		return
+	}
+	m.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	m.httpDuration.WithLabelValues(method, route).Observe(d.Seconds())
+}
+
+// LoginAttempt counts a password login with one of the Login outcomes.
+func (m *Metrics) LoginAttempt(outcome string) {
+	if m == nil {
+		return
+	}
+	m.loginAttempts.WithLabelValues(outcome).Inc()
+}
+
+// VerificationCodeIssued counts a code issued by reason: register, login or resend.
+func (m *Metrics) VerificationCodeIssued(reason string) {
+	if m == nil {
+		return
+	}
+	m.codesIssued.WithLabelValues(reason).Inc()
+}
+
+// VerificationCodeRedeemed counts a redemption with one of the Code outcomes.
+func (m *Metrics) VerificationCodeRedeemed(outcome string) {
+	if m == nil {
+		return
+	}
+	m.codesRedeemed.WithLabelValues(outcome).Inc()
+}
+
+// PermissionCheck records a HasPermission decision. Checks that fail with an error are
+// counted with the decision "error".
+func (m *Metrics) PermissionCheck(allowed bool, err error, d time.Duration) {
+	if m == nil {
+		return
+	}
+	decision := "deny"
+	switch {
+	case err != nil:
+		decision = "error"
+	case allowed:
+		decision = "allow"
+	}
+	m.permissionChecks.WithLabelValues(decision).Inc()
+	m.permissionDuration.Observe(d.Seconds())
+}
+
+// MailSent counts a mail send, which failed when err is not nil.
+func (m *Metrics) MailSent(err error) {
+	if m == nil {
+		return
+	}
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	m.mailSends.WithLabelValues(outcome).Inc()
+}
+
+// RegisterDB exports the connection pool statistics of db, labelled with name.
+func (m *Metrics) RegisterDB(db *sql.DB, name string) {
+	m.registry.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// RegisterJITStates exports the number of JIT requests in each state, counted by count
+// when the registry is scraped.
+func (m *Metrics) RegisterJITStates(count func(ctx context.Context) (map[string]int, error)) {
+	m.registry.MustRegister(&jitCollector{
+		count: count,
+		desc:  prometheus.NewDesc("jit_requests", "JIT elevation requests by state.", []string{"state"}, nil),
+	})
+}
+
+type jitCollector struct {
+	count func(ctx context.Context) (map[string]int, error)
+	desc  *prometheus.Desc
+}
+
+func (c *jitCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.desc
+}
+
+func (c *jitCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), collectTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(c.desc, err)
+		return
+	}
+	for state, n := range counts {
+		ch <- prometheus.MustNewConstMetric(c.desc, prometheus.GaugeValue, float64(n), state)
+	}
+}
@@ -0,0 +1,79 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestNilMetricsRecordNothing(t *testing.T) {
+	var m *Metrics
+	m.ObserveRequest("GET", "/health", 200, time.Millisecond)
+	m.LoginAttempt(LoginSuccess)
+	m.PermissionCheck(true, nil, time.Millisecond)
+	m.MailSent(errors.New("down"))
+}
+
+func TestObserveRequest(t *testing.T) {
+	m := New()
+	m.ObserveRequest("GET", "/api/projects/:id", 200, time.Millisecond)
+	m.ObserveRequest("GET", "/api/projects/:id", 200, time.Millisecond)
+	m.ObserveRequest("GET", "/api/projects/:id", 404, time.Millisecond)
+
+	if got := testutil.ToFloat64(m.httpRequests.WithLabelValues("GET", "/api/projects/:id", "200")); got != 2 {
+		t.Fatalf("expected 2 requests with status 200, got %v", got)
+	}
+}
+
+func TestPermissionCheckDecisions(t *testing.T) {
+	m := New()
+	m.PermissionCheck(true, nil, time.Millisecond)
+	m.PermissionCheck(false, nil, time.Millisecond)
+	m.PermissionCheck(true, errors.New("db down"), time.Millisecond)
+
+	for _, decision := range []string{"allow", "deny", "error"} {
+		if got := testutil.ToFloat64(m.permissionChecks.WithLabelValues(decision)); got != 1 {
+			t.Errorf("expected one %s decision, got %v", decision, got)
+		}
+	}
+}
+
+func TestScrapeSurvivesFailingCollector(t *testing.T) {
+	m := New()
+	m.RegisterJITStates(func(context.Context) (map[string]int, error) {
+		return nil, errors.New("db down")
+	})
+	m.LoginAttempt(LoginWrongPassword)
+
+	rec := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), `auth_login_attempts_total{outcome="wrong_password"} 1`) {
+		t.Fatalf("login attempts missing from scrape:\n%s", rec.Body.String())
+	}
+}
+
+func TestJITStates(t *testing.T) {
+	m := New()
+	m.RegisterJITStates(func(context.Context) (map[string]int, error) {
+		return map[string]int{"pending": 3, "approved": 1}, nil
+	})
+
+	want := `
+# HELP jit_requests JIT elevation requests by state.
+# TYPE jit_requests gauge
+jit_requests{state="approved"} 1
+jit_requests{state="pending"} 3
+`
+	if err := testutil.GatherAndCompare(m.registry, strings.NewReader(want), "jit_requests"); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/service"
+	"context"
+	"log/slog"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BreakGlassAccessLog records every authenticated request made by a user while their
+// break-glass session is active. It runs after the handler, once RequireRole has set user_id
+// and user_roles, and looks the session up only for users holding a possible break-glass grant.
+func BreakGlassAccessLog(breakGlass *service.BreakGlassService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		userID := c.GetString("user_id")
+		if userID == "" {
+			return
+		}
+		userRoles, _ := c.Get("user_roles")
+		if held, _ := userRoles.([]roles.UserRole); !breakGlass.HoldsGrant(held) {
+			return
+		}
+
+		// The request may be cancelled by now; the access must be logged regardless
+		ctx := context.WithoutCancel(c.Request.Context())
+
+		session, err := breakGlass.ActiveSession(ctx, userID)
+		if err != nil {
+			slog.ErrorContext(ctx, "break-glass access log: failed to look up session", "user_id", userID, "error", err)
+			return
+		}
+		if session == nil {
+			return
+		}
+
+		entry := &roles.BreakGlassAccess{
+			SessionID: session.ID,
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Status:    c.Writer.Status(),
+			IP:        c.ClientIP(),
+		}
+		if err := breakGlass.RecordAccess(ctx, entry); err != nil {
+			slog.ErrorContext(ctx, "break-glass access log: failed to record access", "session_id", session.ID, "method", entry.Method, "path", entry.Path, "error", err)
+		}
+	}
+}
@@ -0,0 +1,17 @@
+package middleware
+
+import "github.com/gin-gonic/gin"
+
+// TokenFromQuery lets clients that cannot set headers, such as the browser EventSource,
+// pass the access token as a query parameter. The token is moved into the Authorization
+// header before RequireRole runs; requests that already carry the header are unchanged.
+func TokenFromQuery(param string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetHeader("Authorization") == "" {
+			if token := c.Query(param); token != "" {
+				c.Request.Header.Set("Authorization", "Bearer "+token)
+			}
+		}
+		c.Next()
+	}
+}
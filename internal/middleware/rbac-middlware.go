@@ -3,9 +3,10 @@ package middleware
 import (
 	"AuthServer/internal/domain/roles"
 	"AuthServer/internal/service"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -45,7 +46,7 @@ func RequireRole(rbacService *service.RBACService, tokenService service.ITokenSe
 			}
 		}
 
-		hasPermission, err := rbacService.HasPermission(userID, requiredRole, resourceID)
+		hasPermission, userRoles, err := rbacService.Authorize(c.Request.Context(), userID, requiredRole, resourceID, AccessContext(c))
 		if err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "permission check failed"})
 			c.Abort()
@@ -59,6 +60,7 @@ func RequireRole(rbacService *service.RBACService, tokenService service.ITokenSe
 		}
 
 		c.Set("user_id", userID)
+		c.Set("user_roles", userRoles)
 		c.Next()
 	}
 }
@@ -75,7 +77,7 @@ func RequireAnyRole(rbacService *service.RBACService, tokenService service.IToke
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 		claims, err := tokenService.DecodeAccessToken(tokenString)
 		if err != nil {
-			log.Printf("Token decode error: %v", err) // DEBUG
+			slog.DebugContext(c.Request.Context(), "invalid access token", "error", err)
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
@@ -83,14 +85,11 @@ func RequireAnyRole(rbacService *service.RBACService, tokenService service.IToke
 
 		userID, ok := claims["user-id"].(string)
 		if !ok {
-			log.Printf("Claims user-id not found: %+v", claims) // DEBUG
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token claims"})
 			c.Abort()
 			return
 		}
 
-		log.Printf("User ID from token: %s", userID) // DEBUG
-
 		var resourceID *string
 		if resourceIDParam != "" {
 			rid := c.Param(resourceIDParam)
@@ -102,14 +101,15 @@ func RequireAnyRole(rbacService *service.RBACService, tokenService service.IToke
 			}
 		}
 
+		ac := AccessContext(c)
 		hasPermission := false
+		var userRoles []roles.UserRole
 		for _, role := range requiredRoles {
-			log.Printf("Checking if user %s has role %s", userID, role) // DEBUG
-			permitted, err := rbacService.HasPermission(userID, role, resourceID)
+			permitted, loaded, err := rbacService.Authorize(c.Request.Context(), userID, role, resourceID, ac)
+			userRoles = loaded
 			if err != nil {
-				log.Printf("Permission check error: %v", err) // DEBUG
+				slog.ErrorContext(c.Request.Context(), "permission check failed", "user_id", userID, "role", role, "error", err)
 			}
-			log.Printf("Has permission: %v", permitted) // DEBUG
 			if err == nil && permitted {
 				hasPermission = true
 				break
@@ -117,13 +117,21 @@ func RequireAnyRole(rbacService *service.RBACService, tokenService service.IToke
 		}
 
 		if !hasPermission {
-			log.Printf("User %s does not have required roles: %v", userID, requiredRoles) // DEBUG
 			c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions"})
 			c.Abort()
 			return
 		}
 
 		c.Set("user_id", userID)
+		c.Set("user_roles", userRoles)
 		c.Next()
 	}
 }
+
+// AccessContext collects the request attributes role conditions are evaluated against.
+func AccessContext(c *gin.Context) roles.AccessContext {
+	return roles.AccessContext{
+		Time: time.Now(),
+		IP:   c.ClientIP(),
+	}
+}
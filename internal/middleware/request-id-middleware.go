@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"AuthServer/internal/logging"
+	"regexp"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in both directions.
+const RequestIDHeader = "X-Request-ID"
+
+// validRequestID limits client-supplied IDs to short tokens that are safe to log.
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._:-]{1,128}$`)
+
+// RequestID keeps the caller's X-Request-ID if it is well formed and otherwise generates
+// one. The ID is stored as request_id in the gin context and in the request context, where
+// log records written by repositories and services pick it up, and echoed in the response.
+func RequestID() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = uuid.New().String()
+		}
+
+		c.Set("request_id", id)
+		c.Request = c.Request.WithContext(logging.WithRequestID(c.Request.Context(), id))
+		c.Header(RequestIDHeader, id)
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestTracingContinuesCallerTrace(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	tracer := tp.Tracer("test")
+
+	r := gin.New()
+	r.Use(RequestID(), Tracing(tracer))
+	r.GET("/api/projects/:id", func(c *gin.Context) {
+		_, span := tracer.Start(c.Request.Context(), "child")
+		span.End()
+		c.Status(http.StatusInternalServerError)
+	})
+
+	const traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	req := httptest.NewRequest(http.MethodGet, "/api/projects/42", nil)
+	req.Header.Set("traceparent", "00-"+traceID+"-00f067aa0ba902b7-01")
+	r.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := exporter.GetSpans()
+	if len(spans) != 2 {
+		t.Fatalf("expected a request span and a child span, got %d", len(spans))
+	}
+	child, server := spans[0], spans[1]
+
+	if server.Name != "GET /api/projects/:id" {
+		t.Errorf("span named %q, want the route pattern", server.Name)
+	}
+	if server.SpanContext.TraceID().String() != traceID || server.Parent.SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("request span did not continue the caller's trace")
+	}
+	if child.Parent.SpanID() != server.SpanContext.SpanID() {
+		t.Errorf("handler span is not a child of the request span")
+	}
+	if server.Status.Code != codes.Error {
+		t.Errorf("status %v, want Error for a 500", server.Status.Code)
+	}
+	if !hasAttr(server.Attributes, attribute.Int("http.response.status_code", 500)) {
+		t.Errorf("status code attribute missing: %v", server.Attributes)
+	}
+}
+
+func hasAttr(attrs []attribute.KeyValue, want attribute.KeyValue) bool {
+	for _, a := range attrs {
+		if a == want {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,392 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+const campaignColumns = `id, name, scope_role, scope_project_id, reviewer_id, due_at, overdue_action,
+	escalation_reviewer_id, escalation_grace_hours, status, created_by, created_at, completed_at`
+
+const reviewItemColumns = `i.id, i.campaign_id, i.user_role_id, i.user_id, u.username, i.role, i.project_id, i.reviewer_id,
+	i.decision, i.comment, i.decided_by, i.decided_at, i.auto_decided, i.escalated_at`
+
+type AccessReviewRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewAccessReviewRepository(s database.Service) *AccessReviewRepository {
+	return &AccessReviewRepository{
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
+	}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *AccessReviewRepository) WithTx(tx *sql.Tx) *AccessReviewRepository {
+	return &AccessReviewRepository{db: tx, timeouts: r.timeouts}
+}
+
+func (r *AccessReviewRepository) CreateCampaign(ctx context.Context, c *models.AccessReviewCampaign) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	c.ID = uuid.New().String()
+	c.Status = models.CampaignActive
+
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO access_review_campaigns (id, name, scope_role, scope_project_id, reviewer_id, due_at, overdue_action,
+		     escalation_reviewer_id, escalation_grace_hours, status, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NOW())
+		 RETURNING created_at`,
+		c.ID,
+		c.Name,
+		c.ScopeRole,
+		c.ScopeProjectID,
+		c.ReviewerID,
+		c.DueAt,
+		c.OverdueAction,
+		c.EscalationReviewerID,
+		c.EscalationGraceHours,
+		c.Status,
+		c.CreatedBy,
+	).Scan(&c.CreatedAt)
+}
+
+func (r *AccessReviewRepository) GetCampaign(ctx context.Context, id string) (*models.AccessReviewCampaign, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	c, err := scanCampaign(r.db.QueryRowContext(ctx, `SELECT `+campaignColumns+` FROM access_review_campaigns WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("campaign not found")
+	}
+	return c, err
+}
+
+// ListCampaigns returns campaigns, newest first, optionally filtered by status.
+func (r *AccessReviewRepository) ListCampaigns(ctx context.Context, status string) ([]models.AccessReviewCampaign, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+campaignColumns+`
+		 FROM access_review_campaigns
+		 WHERE $1 = '' OR status = $1
+		 ORDER BY created_at DESC`,
+		status,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCampaigns(ctx, rows)
+}
+
+// ListOverdueCampaigns returns active campaigns whose due date has passed.
+func (r *AccessReviewRepository) ListOverdueCampaigns(ctx context.Context) ([]models.AccessReviewCampaign, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+campaignColumns+`
+		 FROM access_review_campaigns
+		 WHERE status = 'active' AND due_at <= NOW()
+		 ORDER BY due_at`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanCampaigns(ctx, rows)
+}
+
+// CompleteCampaign marks an active campaign completed once none of its items is pending.
+// It reports whether the campaign was completed.
+func (r *AccessReviewRepository) CompleteCampaign(ctx context.Context, id string) (bool, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE access_review_campaigns
+		 SET status = 'completed', completed_at = NOW()
+		 WHERE id = $1 AND status = 'active'
+		 AND NOT EXISTS (SELECT 1 FROM access_review_items WHERE campaign_id = $1 AND decision IS NULL)`,
+		id,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// SnapshotAssignments returns the permanent direct assignments in scope as unsaved review items.
+func (r *AccessReviewRepository) SnapshotAssignments(ctx context.Context, role, projectID *string) ([]models.AccessReviewItem, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT ur.id, ur.user_id, u.username, ur.role, ur.project_id
+		 FROM user_roles ur
+		 JOIN users u ON u.id = ur.user_id
+		 WHERE ur.expires_at IS NULL
+		 AND ($1::text IS NULL OR ur.role = $1)
+		 AND ($2::text IS NULL OR ur.project_id = $2)
+		 ORDER BY u.username, ur.role`,
+		role,
+		projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.AccessReviewItem
+	for rows.Next() {
+		var item models.AccessReviewItem
+		var userRoleID string
+		if err := rows.Scan(&userRoleID, &item.UserID, &item.Username, &item.Role, &item.ProjectID); err != nil {
+			slog.ErrorContext(ctx, "failed to scan assignment for review", "error", err)
+			continue
+		}
+		item.UserRoleID = &userRoleID
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *AccessReviewRepository) AddItem(ctx context.Context, item *models.AccessReviewItem) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	item.ID = uuid.New().String()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO access_review_items (id, campaign_id, user_role_id, user_id, role, project_id, reviewer_id)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+		item.ID,
+		item.CampaignID,
+		item.UserRoleID,
+		item.UserID,
+		item.Role,
+		item.ProjectID,
+		item.ReviewerID,
+	)
+	return err
+}
+
+func (r *AccessReviewRepository) GetItem(ctx context.Context, id string) (*models.AccessReviewItem, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	item, err := scanReviewItem(r.db.QueryRowContext(ctx,
+		`SELECT `+reviewItemColumns+`
+		 FROM access_review_items i
+		 JOIN users u ON u.id = i.user_id
+		 WHERE i.id = $1`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("review item not found")
+	}
+	return item, err
+}
+
+func (r *AccessReviewRepository) ListItems(ctx context.Context, campaignID string) ([]models.AccessReviewItem, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+reviewItemColumns+`
+		 FROM access_review_items i
+		 JOIN users u ON u.id = i.user_id
+		 WHERE i.campaign_id = $1
+		 ORDER BY u.username, i.role`,
+		campaignID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReviewItems(ctx, rows)
+}
+
+// ListPendingItems returns the undecided items of a campaign.
+func (r *AccessReviewRepository) ListPendingItems(ctx context.Context, campaignID string) ([]models.AccessReviewItem, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+reviewItemColumns+`
+		 FROM access_review_items i
+		 JOIN users u ON u.id = i.user_id
+		 WHERE i.campaign_id = $1 AND i.decision IS NULL`,
+		campaignID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReviewItems(ctx, rows)
+}
+
+// ListReviewerTasks returns the undecided items of active campaigns assigned to the reviewer.
+func (r *AccessReviewRepository) ListReviewerTasks(ctx context.Context, reviewerID string) ([]models.AccessReviewItem, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+reviewItemColumns+`
+		 FROM access_review_items i
+		 JOIN users u ON u.id = i.user_id
+		 JOIN access_review_campaigns c ON c.id = i.campaign_id
+		 WHERE i.reviewer_id = $1 AND i.decision IS NULL AND c.status = 'active'
+		 ORDER BY c.due_at, u.username`,
+		reviewerID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanReviewItems(ctx, rows)
+}
+
+// Decide records the decision on a pending item. decidedBy is nil for automatic decisions.
+func (r *AccessReviewRepository) Decide(ctx context.Context, itemID, decision string, decidedBy, comment *string, auto bool) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE access_review_items
+		 SET decision = $2, decided_by = $3, comment = $4, auto_decided = $5, decided_at = NOW()
+		 WHERE id = $1 AND decision IS NULL`,
+		itemID,
+		decision,
+		decidedBy,
+		comment,
+		auto,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("review item already decided")
+	}
+
+	return nil
+}
+
+// Escalate hands a pending item to another reviewer.
+func (r *AccessReviewRepository) Escalate(ctx context.Context, itemID, reviewerID string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE access_review_items
+		 SET reviewer_id = $2, escalated_at = NOW()
+		 WHERE id = $1 AND decision IS NULL`,
+		itemID,
+		reviewerID,
+	)
+	return err
+}
+
+func scanCampaigns(ctx context.Context, rows *sql.Rows) ([]models.AccessReviewCampaign, error) {
+	var campaigns []models.AccessReviewCampaign
+	for rows.Next() {
+		c, err := scanCampaign(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan access review campaign", "error", err)
+			continue
+		}
+		campaigns = append(campaigns, *c)
+	}
+
+	return campaigns, rows.Err()
+}
+
+func scanCampaign(row rowScanner) (*models.AccessReviewCampaign, error) {
+	var c models.AccessReviewCampaign
+	err := row.Scan(
+		&c.ID,
+		&c.Name,
+		&c.ScopeRole,
+		&c.ScopeProjectID,
+		&c.ReviewerID,
+		&c.DueAt,
+		&c.OverdueAction,
+		&c.EscalationReviewerID,
+		&c.EscalationGraceHours,
+		&c.Status,
+		&c.CreatedBy,
+		&c.CreatedAt,
+		&c.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func scanReviewItems(ctx context.Context, rows *sql.Rows) ([]models.AccessReviewItem, error) {
+	var items []models.AccessReviewItem
+	for rows.Next() {
+		item, err := scanReviewItem(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan access review item", "error", err)
+			continue
+		}
+		items = append(items, *item)
+	}
+
+	return items, rows.Err()
+}
+
+func scanReviewItem(row rowScanner) (*models.AccessReviewItem, error) {
+	var i models.AccessReviewItem
+	err := row.Scan(
+		&i.ID,
+		&i.CampaignID,
+		&i.UserRoleID,
+		&i.UserID,
+		&i.Username,
+		&i.Role,
+		&i.ProjectID,
+		&i.ReviewerID,
+		&i.Decision,
+		&i.Comment,
+		&i.DecidedBy,
+		&i.DecidedAt,
+		&i.AutoDecided,
+		&i.EscalatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &i, nil
+}
@@ -0,0 +1,252 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+const auditEventColumns = `id, occurred_at, actor_id, action, COALESCE(target_type, ''), COALESCE(target_id, ''), outcome, reason,
+	before_state, after_state, COALESCE(ip, ''), COALESCE(user_agent, ''), COALESCE(request_id, ''),
+	COALESCE(prev_hash, ''), COALESCE(hash, '')`
+
+const auditCheckpointColumns = `id, event_id, event_hash, key_id, signature, created_at`
+
+// auditFilterClause matches the arguments built by auditFilterArgs.
+const auditFilterClause = `
+	WHERE ($1 = '' OR actor_id = $1)
+	AND ($2 = '' OR action = $2 OR (right($2, 1) = '.' AND starts_with(action, $2)))
+	AND ($3 = '' OR target_type = $3)
+	AND ($4 = '' OR target_id = $4)
+	AND ($5 = '' OR outcome = $5)
+	AND ($6::timestamptz IS NULL OR occurred_at >= $6)
+	AND ($7::timestamptz IS NULL OR occurred_at < $7)`
+
+type AuditRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewAuditRepository(s database.Service) *AuditRepository {
+	return &AuditRepository{
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
+	}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *AuditRepository) WithTx(tx *sql.Tx) *AuditRepository {
+	return &AuditRepository{db: tx, timeouts: r.timeouts}
+}
+
+// NextID reserves the ID of the next event, so that it can be hashed before it is inserted.
+func (r *AuditRepository) NextID(ctx context.Context) (int64, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, `SELECT nextval(pg_get_serial_sequence('audit_events', 'id'))`).Scan(&id)
+	return id, err
+}
+
+// Last returns the newest chained event, or nil if no event has a hash yet.
+func (r *AuditRepository) Last(ctx context.Context) (*models.AuditEvent, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+auditEventColumns+`
+		 FROM audit_events
+		 WHERE hash IS NOT NULL
+		 ORDER BY id DESC
+		 LIMIT 1`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	events, err := scanAuditEvents(ctx, rows)
+	if err != nil || len(events) == 0 {
+		return nil, err
+	}
+	return &events[0], nil
+}
+
+// Append stores the event with the ID, timestamp and hashes already set on it. Events are
+// never updated.
+func (r *AuditRepository) Append(ctx context.Context, e *models.AuditEvent) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO audit_events (id, occurred_at, actor_id, action, target_type, target_id, outcome, reason,
+		     before_state, after_state, ip, user_agent, request_id, prev_hash, hash)
+		 VALUES ($1, $2, $3, $4, NULLIF($5, ''), NULLIF($6, ''), $7, $8, $9, $10,
+		     NULLIF($11, ''), NULLIF($12, ''), NULLIF($13, ''), NULLIF($14, ''), $15)`,
+		e.ID,
+		e.OccurredAt,
+		e.ActorID,
+		e.Action,
+		e.TargetType,
+		e.TargetID,
+		e.Outcome,
+		e.Reason,
+		nullJSON(e.Before),
+		nullJSON(e.After),
+		e.IP,
+		e.UserAgent,
+		e.RequestID,
+		e.PrevHash,
+		e.Hash,
+	)
+	return err
+}
+
+// Scan returns up to limit events with IDs greater than afterID, oldest first.
+func (r *AuditRepository) Scan(ctx context.Context, afterID int64, limit int) ([]models.AuditEvent, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+auditEventColumns+`
+		 FROM audit_events
+		 WHERE id > $1
+		 ORDER BY id
+		 LIMIT $2`,
+		afterID, limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanAuditEvents(ctx, rows)
+}
+
+// List returns one page of matching events, newest first, and the total number of matches.
+func (r *AuditRepository) List(ctx context.Context, f models.AuditFilter) ([]models.AuditEvent, int, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	args := auditFilterArgs(f)
+
+	var total int
+	if err := r.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM audit_events`+auditFilterClause, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+auditEventColumns+`
+		 FROM audit_events`+auditFilterClause+`
+		 ORDER BY id DESC
+		 LIMIT $8 OFFSET $9`,
+		append(args, f.Limit, f.Offset)...,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	events, err := scanAuditEvents(ctx, rows)
+	return events, total, err
+}
+
+// CreateCheckpoint stores a signed checkpoint and fills in its ID.
+func (r *AuditRepository) CreateCheckpoint(ctx context.Context, cp *models.AuditCheckpoint) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO audit_checkpoints (event_id, event_hash, key_id, signature, created_at)
+		 VALUES ($1, $2, $3, $4, $5)
+		 RETURNING id`,
+		cp.EventID,
+		cp.EventHash,
+		cp.KeyID,
+		cp.Signature,
+		cp.CreatedAt,
+	).Scan(&cp.ID)
+}
+
+// LastCheckpoint returns the newest checkpoint, or nil if there is none.
+func (r *AuditRepository) LastCheckpoint(ctx context.Context) (*models.AuditCheckpoint, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	var cp models.AuditCheckpoint
+	err := r.db.QueryRowContext(ctx,
+		`SELECT `+auditCheckpointColumns+` FROM audit_checkpoints ORDER BY id DESC LIMIT 1`,
+	).Scan(&cp.ID, &cp.EventID, &cp.EventHash, &cp.KeyID, &cp.Signature, &cp.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &cp, nil
+}
+
+// ListCheckpoints returns every checkpoint, oldest first.
+func (r *AuditRepository) ListCheckpoints(ctx context.Context) ([]models.AuditCheckpoint, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+auditCheckpointColumns+` FROM audit_checkpoints ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var checkpoints []models.AuditCheckpoint
+	for rows.Next() {
+		var cp models.AuditCheckpoint
+		if err := rows.Scan(&cp.ID, &cp.EventID, &cp.EventHash, &cp.KeyID, &cp.Signature, &cp.CreatedAt); err != nil {
+			slog.ErrorContext(ctx, "failed to scan audit checkpoint", "error", err)
+			continue
+		}
+		checkpoints = append(checkpoints, cp)
+	}
+
+	return checkpoints, rows.Err()
+}
+
+func scanAuditEvents(ctx context.Context, rows *sql.Rows) ([]models.AuditEvent, error) {
+	var events []models.AuditEvent
+	for rows.Next() {
+		var e models.AuditEvent
+		var before, after []byte
+		if err := rows.Scan(&e.ID, &e.OccurredAt, &e.ActorID, &e.Action, &e.TargetType, &e.TargetID, &e.Outcome, &e.Reason,
+			&before, &after, &e.IP, &e.UserAgent, &e.RequestID, &e.PrevHash, &e.Hash); err != nil {
+			slog.ErrorContext(ctx, "failed to scan audit event", "error", err)
+			continue
+		}
+		e.Before = before
+		e.After = after
+		events = append(events, e)
+	}
+
+	return events, rows.Err()
+}
+
+func auditFilterArgs(f models.AuditFilter) []any {
+	return []any{f.ActorID, f.Action, f.TargetType, f.TargetID, f.Outcome, nullTime(f.From), nullTime(f.To)}
+}
+
+func nullTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+// nullJSON stores absent state as SQL NULL rather than a JSON null.
+func nullJSON(raw []byte) any {
+	if len(raw) == 0 {
+		return nil
+	}
+	return string(raw)
+}
@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/roles"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+const breakGlassColumns = `id, user_id, role, justification, granted_role_id, started_at, expires_at, ended_at,
+	review_status, reviewed_by, reviewed_at, review_notes`
+
+type BreakGlassRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewBreakGlassRepository(s database.Service) *BreakGlassRepository {
+	return &BreakGlassRepository{
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
+	}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *BreakGlassRepository) WithTx(tx *sql.Tx) *BreakGlassRepository {
+	return &BreakGlassRepository{db: tx, timeouts: r.timeouts}
+}
+
+func (r *BreakGlassRepository) Create(ctx context.Context, session *roles.BreakGlassSession) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	session.ID = uuid.New().String()
+	session.ReviewStatus = roles.BreakGlassReviewOpen
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO break_glass_sessions (id, user_id, role, justification, granted_role_id, started_at, expires_at, review_status)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8)`,
+		session.ID,
+		session.UserID,
+		string(session.Role),
+		session.Justification,
+		session.GrantedRoleID,
+		session.StartedAt,
+		session.ExpiresAt,
+		session.ReviewStatus,
+	)
+	return err
+}
+
+func (r *BreakGlassRepository) GetByID(ctx context.Context, id string) (*roles.BreakGlassSession, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	session, err := scanBreakGlassSession(r.db.QueryRowContext(ctx, `SELECT `+breakGlassColumns+` FROM break_glass_sessions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("break-glass session not found")
+	}
+	return session, err
+}
+
+// FindActive returns the user's session that is still in effect, or nil.
+func (r *BreakGlassRepository) FindActive(ctx context.Context, userID string) (*roles.BreakGlassSession, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	session, err := scanBreakGlassSession(r.db.QueryRowContext(ctx,
+		`SELECT `+breakGlassColumns+`
+		 FROM break_glass_sessions
+		 WHERE user_id = $1 AND ended_at IS NULL AND expires_at > NOW()
+		 ORDER BY started_at DESC
+		 LIMIT 1`,
+		userID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return session, err
+}
+
+// List returns sessions, newest first, optionally filtered by review status.
+func (r *BreakGlassRepository) List(ctx context.Context, reviewStatus string) ([]roles.BreakGlassSession, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+breakGlassColumns+`
+		 FROM break_glass_sessions
+		 WHERE $1 = '' OR review_status = $1
+		 ORDER BY started_at DESC`,
+		reviewStatus,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []roles.BreakGlassSession
+	for rows.Next() {
+		session, err := scanBreakGlassSession(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan break-glass session", "error", err)
+			continue
+		}
+		sessions = append(sessions, *session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// End marks an active session as ended now.
+func (r *BreakGlassRepository) End(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE break_glass_sessions SET ended_at = NOW() WHERE id = $1 AND ended_at IS NULL AND expires_at > NOW()",
+		id,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("break-glass session is not active")
+	}
+
+	return nil
+}
+
+// CloseReview records the review outcome of a session that is still open for review.
+func (r *BreakGlassRepository) CloseReview(ctx context.Context, id, reviewerID, notes string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE break_glass_sessions
+		 SET review_status = 'closed', reviewed_by = $2, reviewed_at = NOW(), review_notes = $3
+		 WHERE id = $1 AND review_status = 'open'`,
+		id,
+		reviewerID,
+		notes,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("break-glass review already closed")
+	}
+
+	return nil
+}
+
+func (r *BreakGlassRepository) LogAccess(ctx context.Context, entry *roles.BreakGlassAccess) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO break_glass_access_log (session_id, method, path, status, ip, occurred_at)
+		 VALUES ($1, $2, $3, $4, $5, NOW())
+		 RETURNING id, occurred_at`,
+		entry.SessionID,
+		entry.Method,
+		entry.Path,
+		entry.Status,
+		entry.IP,
+	).Scan(&entry.ID, &entry.OccurredAt)
+}
+
+func (r *BreakGlassRepository) GetAccessLog(ctx context.Context, sessionID string) ([]roles.BreakGlassAccess, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, session_id, method, path, status, ip, occurred_at
+		 FROM break_glass_access_log
+		 WHERE session_id = $1
+		 ORDER BY occurred_at`,
+		sessionID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var entries []roles.BreakGlassAccess
+	for rows.Next() {
+		var e roles.BreakGlassAccess
+		if err := rows.Scan(&e.ID, &e.SessionID, &e.Method, &e.Path, &e.Status, &e.IP, &e.OccurredAt); err != nil {
+			slog.ErrorContext(ctx, "failed to scan break-glass access", "error", err)
+			continue
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, rows.Err()
+}
+
+func scanBreakGlassSession(row rowScanner) (*roles.BreakGlassSession, error) {
+	var s roles.BreakGlassSession
+	var role string
+	err := row.Scan(
+		&s.ID,
+		&s.UserID,
+		&role,
+		&s.Justification,
+		&s.GrantedRoleID,
+		&s.StartedAt,
+		&s.ExpiresAt,
+		&s.EndedAt,
+		&s.ReviewStatus,
+		&s.ReviewedBy,
+		&s.ReviewedAt,
+		&s.ReviewNotes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	s.Role = roles.Role(role)
+	return &s, nil
+}
@@ -0,0 +1,415 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/domain/roles"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// userGroupsCTE resolves every group a user belongs to, directly or through nested groups.
+// UNION (not UNION ALL) stops the recursion on membership cycles.
+const userGroupsCTE = `WITH RECURSIVE memberships(group_id) AS (
+	SELECT group_id FROM group_members WHERE member_type = 'user' AND member_id = $1
+	UNION
+	SELECT gm.group_id FROM group_members gm
+	JOIN memberships m ON gm.member_type = 'group' AND gm.member_id = m.group_id
+)`
+
+type GroupRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewGroupRepository(s database.Service) *GroupRepository {
+	return &GroupRepository{
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
+	}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *GroupRepository) WithTx(tx *sql.Tx) *GroupRepository {
+	return &GroupRepository{db: tx, timeouts: r.timeouts}
+}
+
+func (r *GroupRepository) Create(ctx context.Context, group models.Group) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"INSERT INTO user_groups (id, name, description, created_by, created_at) VALUES ($1, $2, $3, $4, $5)",
+		group.ID,
+		group.Name,
+		group.Description,
+		group.CreatedBy,
+		group.CreatedAt,
+	)
+	return err
+}
+
+func (r *GroupRepository) FindById(ctx context.Context, id string) (*models.Group, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	var group models.Group
+	err := r.db.QueryRowContext(ctx,
+		"SELECT id, name, description, created_by, created_at FROM user_groups WHERE id = $1",
+		id,
+	).Scan(&group.ID, &group.Name, &group.Description, &group.CreatedBy, &group.CreatedAt)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, fmt.Errorf("group not found")
+		}
+		return nil, fmt.Errorf("failed to scan group: %v", err)
+	}
+
+	return &group, nil
+}
+
+func (r *GroupRepository) FindAll(ctx context.Context) ([]models.Group, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, "SELECT id, name, description, created_by, created_at FROM user_groups ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanGroups(ctx, rows)
+}
+
+func (r *GroupRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM user_groups WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("group not found")
+	}
+
+	return nil
+}
+
+func (r *GroupRepository) AddMember(ctx context.Context, groupID, memberType, memberID, addedBy string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO group_members (group_id, member_type, member_id, added_by, created_at)
+		 VALUES ($1, $2, $3, $4, NOW())
+		 ON CONFLICT DO NOTHING`,
+		groupID,
+		memberType,
+		memberID,
+		addedBy,
+	)
+	return err
+}
+
+func (r *GroupRepository) RemoveMember(ctx context.Context, groupID, memberType, memberID string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM group_members WHERE group_id = $1 AND member_type = $2 AND member_id = $3",
+		groupID,
+		memberType,
+		memberID,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("member not found")
+	}
+
+	return nil
+}
+
+func (r *GroupRepository) ListMembers(ctx context.Context, groupID string) ([]models.GroupMember, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT group_id, member_type, member_id, added_by, created_at
+		 FROM group_members
+		 WHERE group_id = $1
+		 ORDER BY member_type, created_at`,
+		groupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.GroupMember
+	for rows.Next() {
+		var m models.GroupMember
+		if err := rows.Scan(&m.GroupID, &m.MemberType, &m.MemberID, &m.AddedBy, &m.CreatedAt); err != nil {
+			slog.ErrorContext(ctx, "failed to scan group member", "error", err)
+			continue
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// ContainsGroup reports whether candidate is groupID itself or nested anywhere inside it.
+func (r *GroupRepository) ContainsGroup(ctx context.Context, groupID, candidate string) (bool, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	if groupID == candidate {
+		return true, nil
+	}
+
+	var contains bool
+	err := r.db.QueryRowContext(ctx,
+		`WITH RECURSIVE nested(id) AS (
+			SELECT member_id FROM group_members WHERE group_id = $1 AND member_type = 'group'
+			UNION
+			SELECT gm.member_id FROM group_members gm
+			JOIN nested n ON gm.group_id = n.id AND gm.member_type = 'group'
+		)
+		SELECT EXISTS (SELECT 1 FROM nested WHERE id = $2)`,
+		groupID,
+		candidate,
+	).Scan(&contains)
+
+	return contains, err
+}
+
+// FindUserGroups returns every group the user belongs to, including through nested groups.
+func (r *GroupRepository) FindUserGroups(ctx context.Context, userID string) ([]models.Group, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		userGroupsCTE+`
+		SELECT g.id, g.name, g.description, g.created_by, g.created_at
+		FROM user_groups g
+		JOIN memberships m ON m.group_id = g.id
+		ORDER BY g.name`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanGroups(ctx, rows)
+}
+
+// UserMemberIDs returns the users in a group, directly or through nested groups, ordered
+// by ID.
+func (r *GroupRepository) UserMemberIDs(ctx context.Context, groupID string) ([]string, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`WITH RECURSIVE nested(id) AS (
+			SELECT $1::text
+			UNION
+			SELECT gm.member_id FROM group_members gm
+			JOIN nested n ON gm.group_id = n.id AND gm.member_type = 'group'
+		)
+		SELECT DISTINCT gm.member_id
+		FROM group_members gm
+		JOIN nested n ON gm.group_id = n.id
+		WHERE gm.member_type = 'user'
+		ORDER BY gm.member_id`,
+		groupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		userIDs = append(userIDs, id)
+	}
+
+	return userIDs, rows.Err()
+}
+
+// GetMemberRoles returns the active roles a member of the group receives: those assigned
+// to the group itself and to every group it is nested in.
+func (r *GroupRepository) GetMemberRoles(ctx context.Context, groupID string) ([]roles.UserRole, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`WITH RECURSIVE ancestors(id) AS (
+			SELECT $1::text
+			UNION
+			SELECT gm.group_id FROM group_members gm
+			JOIN ancestors a ON gm.member_type = 'group' AND gm.member_id = a.id
+		)
+		SELECT gr.id, gr.role, gr.project_id, gr.expires_at, gr.condition, g.id, g.name
+		FROM group_roles gr
+		JOIN ancestors a ON a.id = gr.group_id
+		JOIN user_groups g ON g.id = gr.group_id
+		WHERE gr.expires_at IS NULL OR gr.expires_at > NOW()
+		ORDER BY gr.created_at DESC`,
+		groupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanGroupRoles(ctx, rows, "")
+}
+
+func (r *GroupRepository) AssignRole(ctx context.Context, groupID string, role roles.Role, resourceID *string, expiresAt *time.Time, createdBy string, condition *string) (string, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	id := uuid.New().String()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO group_roles (id, group_id, role, project_id, expires_at, condition, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())`,
+		id,
+		groupID,
+		string(role),
+		resourceID,
+		expiresAt,
+		condition,
+		createdBy,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+func (r *GroupRepository) RevokeRole(ctx context.Context, groupID, roleID string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM group_roles WHERE id = $1 AND group_id = $2", roleID, groupID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("role not found")
+	}
+
+	return nil
+}
+
+// GetGroupRoles returns the roles assigned directly to a group.
+func (r *GroupRepository) GetGroupRoles(ctx context.Context, groupID string) ([]roles.UserRole, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT gr.id, gr.role, gr.project_id, gr.expires_at, gr.condition, g.id, g.name
+		 FROM group_roles gr
+		 JOIN user_groups g ON g.id = gr.group_id
+		 WHERE gr.group_id = $1
+		 ORDER BY gr.created_at DESC`,
+		groupID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanGroupRoles(ctx, rows, "")
+}
+
+// GetInheritedRoles returns the roles a user holds through group membership, each labelled
+// with the group it comes from. Expired assignments are only included when requested.
+func (r *GroupRepository) GetInheritedRoles(ctx context.Context, userID string, includeExpired bool) ([]roles.UserRole, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		userGroupsCTE+`
+		SELECT gr.id, gr.role, gr.project_id, gr.expires_at, gr.condition, g.id, g.name
+		FROM group_roles gr
+		JOIN memberships m ON m.group_id = gr.group_id
+		JOIN user_groups g ON g.id = gr.group_id
+		WHERE $2 OR gr.expires_at IS NULL OR gr.expires_at > NOW()
+		ORDER BY gr.created_at DESC`,
+		userID,
+		includeExpired,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanGroupRoles(ctx, rows, userID)
+}
+
+func (r *GroupRepository) scanGroupRoles(ctx context.Context, rows *sql.Rows, userID string) ([]roles.UserRole, error) {
+	var groupRoles []roles.UserRole
+	for rows.Next() {
+		var ur roles.UserRole
+		var roleStr string
+		err := rows.Scan(&ur.ID, &roleStr, &ur.ResourceID, &ur.ExpiresAt, &ur.Condition, &ur.GroupID, &ur.GroupName)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan group role", "error", err)
+			continue
+		}
+		ur.UserID = userID
+		ur.Role = roles.Role(roleStr)
+		groupRoles = append(groupRoles, ur)
+	}
+
+	return groupRoles, rows.Err()
+}
+
+func (r *GroupRepository) scanGroups(ctx context.Context, rows *sql.Rows) ([]models.Group, error) {
+	var groups []models.Group
+	for rows.Next() {
+		var group models.Group
+		if err := rows.Scan(&group.ID, &group.Name, &group.Description, &group.CreatedBy, &group.CreatedAt); err != nil {
+			slog.ErrorContext(ctx, "failed to scan group", "error", err)
+			continue
+		}
+		groups = append(groups, group)
+	}
+
+	return groups, rows.Err()
+}
@@ -0,0 +1,232 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/roles"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+const jitPolicyColumns = `id, role, resource_id, required_approvals, approver_group_id, auto_approve_max_minutes,
+	escalation_after_minutes, escalation_group_id, created_by, created_at`
+
+// JITApprovalRepository stores approval policies and the approval steps taken on requests.
+type JITApprovalRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewJITApprovalRepository(s database.Service) *JITApprovalRepository {
+	return &JITApprovalRepository{
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
+	}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *JITApprovalRepository) WithTx(tx *sql.Tx) *JITApprovalRepository {
+	return &JITApprovalRepository{db: tx, timeouts: r.timeouts}
+}
+
+func (r *JITApprovalRepository) CreatePolicy(ctx context.Context, p *roles.JITApprovalPolicy) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	p.ID = uuid.New().String()
+
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO jit_approval_policies (id, role, resource_id, required_approvals, approver_group_id,
+		     auto_approve_max_minutes, escalation_after_minutes, escalation_group_id, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		 RETURNING created_at`,
+		p.ID,
+		string(p.Role),
+		p.ResourceID,
+		p.RequiredApprovals,
+		p.ApproverGroupID,
+		p.AutoApproveMaxMinutes,
+		p.EscalationAfterMinutes,
+		p.EscalationGroupID,
+		p.CreatedBy,
+	).Scan(&p.CreatedAt)
+}
+
+func (r *JITApprovalRepository) ListPolicies(ctx context.Context) ([]roles.JITApprovalPolicy, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT `+jitPolicyColumns+` FROM jit_approval_policies ORDER BY role, resource_id NULLS LAST`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var policies []roles.JITApprovalPolicy
+	for rows.Next() {
+		p, err := scanPolicy(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan JIT approval policy", "error", err)
+			continue
+		}
+		policies = append(policies, *p)
+	}
+
+	return policies, rows.Err()
+}
+
+func (r *JITApprovalRepository) GetPolicy(ctx context.Context, id string) (*roles.JITApprovalPolicy, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	p, err := scanPolicy(r.db.QueryRowContext(ctx, `SELECT `+jitPolicyColumns+` FROM jit_approval_policies WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("approval policy not found")
+	}
+	return p, err
+}
+
+// FindPolicy returns the most specific policy for a role and resource: the resource-specific
+// policy if one exists, otherwise the role-wide one. It returns nil when neither exists.
+func (r *JITApprovalRepository) FindPolicy(ctx context.Context, role roles.Role, resourceID *string) (*roles.JITApprovalPolicy, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	p, err := scanPolicy(r.db.QueryRowContext(ctx,
+		`SELECT `+jitPolicyColumns+`
+		 FROM jit_approval_policies
+		 WHERE role = $1 AND (resource_id = $2 OR resource_id IS NULL)
+		 ORDER BY resource_id NULLS LAST
+		 LIMIT 1`,
+		string(role),
+		resourceID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return p, err
+}
+
+func (r *JITApprovalRepository) DeletePolicy(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM jit_approval_policies WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("approval policy not found")
+	}
+
+	return nil
+}
+
+func (r *JITApprovalRepository) RecordApproval(ctx context.Context, requestID string, approverID *string, tier int, decision string, comment *string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO jit_approvals (id, request_id, approver_id, tier, decision, comment, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW())`,
+		uuid.New().String(),
+		requestID,
+		approverID,
+		tier,
+		decision,
+		comment,
+	)
+	return err
+}
+
+func (r *JITApprovalRepository) ListApprovals(ctx context.Context, requestID string) ([]roles.JITApproval, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, request_id, approver_id, tier, decision, comment, created_at
+		 FROM jit_approvals
+		 WHERE request_id = $1
+		 ORDER BY created_at`,
+		requestID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var approvals []roles.JITApproval
+	for rows.Next() {
+		var a roles.JITApproval
+		if err := rows.Scan(&a.ID, &a.RequestID, &a.ApproverID, &a.Tier, &a.Decision, &a.Comment, &a.CreatedAt); err != nil {
+			slog.ErrorContext(ctx, "failed to scan JIT approval", "error", err)
+			continue
+		}
+		approvals = append(approvals, a)
+	}
+
+	return approvals, rows.Err()
+}
+
+// CountApprovals returns how many approvals were given for a request at the given tier.
+func (r *JITApprovalRepository) CountApprovals(ctx context.Context, requestID string, tier int) (int, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		"SELECT COUNT(*) FROM jit_approvals WHERE request_id = $1 AND tier = $2 AND decision = 'approved'",
+		requestID,
+		tier,
+	).Scan(&count)
+	return count, err
+}
+
+// HasDecided reports whether the approver already recorded a decision on the request.
+func (r *JITApprovalRepository) HasDecided(ctx context.Context, requestID, approverID string) (bool, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	var exists bool
+	err := r.db.QueryRowContext(ctx,
+		"SELECT EXISTS (SELECT 1 FROM jit_approvals WHERE request_id = $1 AND approver_id = $2)",
+		requestID,
+		approverID,
+	).Scan(&exists)
+	return exists, err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanPolicy(row rowScanner) (*roles.JITApprovalPolicy, error) {
+	var p roles.JITApprovalPolicy
+	var role string
+	err := row.Scan(
+		&p.ID,
+		&role,
+		&p.ResourceID,
+		&p.RequiredApprovals,
+		&p.ApproverGroupID,
+		&p.AutoApproveMaxMinutes,
+		&p.EscalationAfterMinutes,
+		&p.EscalationGroupID,
+		&p.CreatedBy,
+		&p.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	p.Role = roles.Role(role)
+	return &p, nil
+}
@@ -3,39 +3,53 @@ package repository
 import (
 	"AuthServer/internal/database"
 	"AuthServer/internal/domain/roles"
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+const jitRequestColumns = `id, user_id, role, resource_id, duration_minutes, reason, status, approved_by, policy_id, approval_tier, escalated_at,
+	granted_role_id, grant_expires_at, parent_request_id, ended_by, ended_at, created_at, updated_at`
+
 type JITRequestRepository struct {
-	db *sql.DB
+	db       database.DBTX
+	timeouts database.Timeouts
 }
 
 func NewJITRequestRepository(s database.Service) *JITRequestRepository {
 	return &JITRequestRepository{
-		db: s.DB(),
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
 	}
 }
 
-func (r *JITRequestRepository) Create(userID string, role roles.Role, resourceID *string, durationMinutes int, reason string) (*roles.JITRequestDB, error) {
-	log.Printf("Creating JIT request for user %s, role %s", userID, role)
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *JITRequestRepository) WithTx(tx *sql.Tx) *JITRequestRepository {
+	return &JITRequestRepository{db: tx, timeouts: r.timeouts}
+}
+
+func (r *JITRequestRepository) Create(ctx context.Context, userID string, role roles.Role, resourceID *string, durationMinutes int, reason string, policyID, parentRequestID *string) (*roles.JITRequestDB, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
 
 	id := uuid.New().String()
 	now := time.Now()
 
-	_, err := r.db.Exec(
-		`INSERT INTO jit_requests (id, user_id, role, resource_id, duration_minutes, reason, status, created_at, updated_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7, $8)`,
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO jit_requests (id, user_id, role, resource_id, duration_minutes, reason, status, policy_id, approval_tier, parent_request_id, created_at, updated_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, 'pending', $7, 1, $8, $9, $10)`,
 		id,
 		userID,
 		string(role),
 		resourceID,
 		durationMinutes,
 		reason,
+		policyID,
+		parentRequestID,
 		now,
 		now,
 	)
@@ -52,33 +66,26 @@ func (r *JITRequestRepository) Create(userID string, role roles.Role, resourceID
 		DurationMinutes: durationMinutes,
 		Reason:          &reason,
 		Status:          "pending",
+		PolicyID:        policyID,
+		ApprovalTier:    1,
+		ParentRequestID: parentRequestID,
 		CreatedAt:       now,
 		UpdatedAt:       now,
 	}, nil
 }
 
-func (r *JITRequestRepository) GetByID(id string) (*roles.JITRequestDB, error) {
-	row := r.db.QueryRow(
-		`SELECT id, user_id, role, resource_id, duration_minutes, reason, status, approved_by, created_at, updated_at
+func (r *JITRequestRepository) GetByID(ctx context.Context, id string) (*roles.JITRequestDB, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	row := r.db.QueryRowContext(ctx,
+		`SELECT `+jitRequestColumns+`
 		 FROM jit_requests
 		 WHERE id = $1`,
 		id,
 	)
 
-	var req roles.JITRequestDB
-	err := row.Scan(
-		&req.ID,
-		&req.UserID,
-		&req.Role,
-		&req.ResourceID,
-		&req.DurationMinutes,
-		&req.Reason,
-		&req.Status,
-		&req.ApprovedBy,
-		&req.CreatedAt,
-		&req.UpdatedAt,
-	)
-
+	req, err := scanRequest(row)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, fmt.Errorf("JIT request not found")
@@ -86,12 +93,33 @@ func (r *JITRequestRepository) GetByID(id string) (*roles.JITRequestDB, error) {
 		return nil, err
 	}
 
-	return &req, nil
+	return req, nil
+}
+
+// GetByIDForUpdate loads a request and locks its row until the surrounding transaction ends.
+func (r *JITRequestRepository) GetByIDForUpdate(ctx context.Context, id string) (*roles.JITRequestDB, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	req, err := scanRequest(r.db.QueryRowContext(ctx,
+		`SELECT `+jitRequestColumns+`
+		 FROM jit_requests
+		 WHERE id = $1
+		 FOR UPDATE`,
+		id,
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("JIT request not found")
+	}
+	return req, err
 }
 
-func (r *JITRequestRepository) GetPendingRequests() ([]roles.JITRequestDB, error) {
-	rows, err := r.db.Query(
-		`SELECT id, user_id, role, resource_id, duration_minutes, reason, status, approved_by, created_at, updated_at
+func (r *JITRequestRepository) GetPendingRequests(ctx context.Context) ([]roles.JITRequestDB, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+jitRequestColumns+`
 		 FROM jit_requests
 		 WHERE status = 'pending'
 		 ORDER BY created_at DESC`,
@@ -101,12 +129,15 @@ func (r *JITRequestRepository) GetPendingRequests() ([]roles.JITRequestDB, error
 	}
 	defer rows.Close()
 
-	return r.scanRequests(rows)
+	return r.scanRequests(ctx, rows)
 }
 
-func (r *JITRequestRepository) GetUserRequests(userID string) ([]roles.JITRequestDB, error) {
-	rows, err := r.db.Query(
-		`SELECT id, user_id, role, resource_id, duration_minutes, reason, status, approved_by, created_at, updated_at
+func (r *JITRequestRepository) GetUserRequests(ctx context.Context, userID string) ([]roles.JITRequestDB, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+jitRequestColumns+`
 		 FROM jit_requests
 		 WHERE user_id = $1
 		 ORDER BY created_at DESC`,
@@ -117,48 +148,249 @@ func (r *JITRequestRepository) GetUserRequests(userID string) ([]roles.JITReques
 	}
 	defer rows.Close()
 
-	return r.scanRequests(rows)
+	return r.scanRequests(ctx, rows)
+}
+
+// CountByStatus returns the number of requests in each status. Statuses without requests
+// are absent.
+func (r *JITRequestRepository) CountByStatus(ctx context.Context) (map[string]int, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx, `SELECT status, COUNT(*) FROM jit_requests GROUP BY status`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	counts := make(map[string]int)
+	for rows.Next() {
+		var status string
+		var n int
+		if err := rows.Scan(&status, &n); err != nil {
+			return nil, err
+		}
+		counts[status] = n
+	}
+	return counts, rows.Err()
 }
 
-func (r *JITRequestRepository) UpdateStatus(id, status string, approvedBy *string) error {
-	_, err := r.db.Exec(
+// UpdateStatus decides a pending request. It fails if the request is no longer pending, so
+// two concurrent decisions cannot both succeed.
+func (r *JITRequestRepository) UpdateStatus(ctx context.Context, id, status string, approvedBy *string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
 		`UPDATE jit_requests
 		 SET status = $2, approved_by = $3, updated_at = NOW()
-		 WHERE id = $1`,
+		 WHERE id = $1 AND status = 'pending'`,
 		id,
 		status,
 		approvedBy,
 	)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to update JIT request status", "jit_request_id", id, "error", err)
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("request is not pending")
+	}
+
+	return nil
+}
+
+// Transition moves a request from one status to another and records who ended it. It returns
+// false when the request was no longer in the expected status.
+func (r *JITRequestRepository) Transition(ctx context.Context, id, from, to string, endedBy *string) (bool, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE jit_requests
+		 SET status = $3, ended_by = $4, ended_at = NOW(), updated_at = NOW()
+		 WHERE id = $1 AND status = $2`,
+		id,
+		from,
+		to,
+		endedBy,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, err
+	}
+
+	return rowsAffected > 0, nil
+}
+
+// SetGrant links an approved request to the user_roles row backing it.
+func (r *JITRequestRepository) SetGrant(ctx context.Context, id, grantedRoleID string, expiresAt time.Time) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE jit_requests
+		 SET granted_role_id = $2, grant_expires_at = $3, updated_at = NOW()
+		 WHERE id = $1`,
+		id,
+		grantedRoleID,
+		expiresAt,
+	)
+	return err
+}
+
+// EndGrant marks every approved request backed by the given user_roles row as revoked and
+// cancels pending extensions of those requests. It returns the number of requests revoked.
+func (r *JITRequestRepository) EndGrant(ctx context.Context, grantedRoleID string, endedBy *string) (int, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE jit_requests
+		 SET status = 'revoked', ended_by = $2, ended_at = NOW(), updated_at = NOW()
+		 WHERE granted_role_id = $1 AND status = 'approved'`,
+		grantedRoleID,
+		endedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = r.db.ExecContext(ctx,
+		`UPDATE jit_requests
+		 SET status = 'cancelled', ended_by = $2, ended_at = NOW(), updated_at = NOW()
+		 WHERE status = 'pending'
+		 AND parent_request_id IN (SELECT id FROM jit_requests WHERE granted_role_id = $1)`,
+		grantedRoleID,
+		endedBy,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// ExpireStale marks pending requests older than pendingTTL and approved grants past their end
+// as expired. It returns the number of requests changed.
+func (r *JITRequestRepository) ExpireStale(ctx context.Context, pendingTTL time.Duration) (int, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE jit_requests
+		 SET status = 'expired', ended_at = NOW(), updated_at = NOW()
+		 WHERE (status = 'pending' AND created_at <= $1)
+		 OR (status = 'approved' AND grant_expires_at IS NOT NULL AND grant_expires_at <= NOW())`,
+		time.Now().Add(-pendingTTL),
+	)
+	if err != nil {
+		return 0, err
+	}
 
+	rowsAffected, err := result.RowsAffected()
 	if err != nil {
-		log.Printf("failed to update JIT request status: %v", err)
+		return 0, err
 	}
 
+	if rowsAffected > 0 {
+		slog.InfoContext(ctx, "expired JIT requests", "count", rowsAffected)
+	}
+
+	return int(rowsAffected), nil
+}
+
+// Escalate moves a pending request to the given approval tier.
+func (r *JITRequestRepository) Escalate(ctx context.Context, id string, tier int) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE jit_requests
+		 SET approval_tier = $2, escalated_at = NOW(), updated_at = NOW()
+		 WHERE id = $1 AND status = 'pending' AND approval_tier < $2`,
+		id,
+		tier,
+	)
 	return err
 }
 
-func (r *JITRequestRepository) scanRequests(rows *sql.Rows) ([]roles.JITRequestDB, error) {
+// GetEscalationDue returns pending tier-1 requests whose policy escalation timeout has passed.
+func (r *JITRequestRepository) GetEscalationDue(ctx context.Context) ([]roles.JITRequestDB, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT r.id, r.user_id, r.role, r.resource_id, r.duration_minutes, r.reason, r.status, r.approved_by,
+		        r.policy_id, r.approval_tier, r.escalated_at, r.granted_role_id, r.grant_expires_at,
+		        r.parent_request_id, r.ended_by, r.ended_at, r.created_at, r.updated_at
+		 FROM jit_requests r
+		 JOIN jit_approval_policies p ON p.id = r.policy_id
+		 WHERE r.status = 'pending' AND r.approval_tier = 1
+		 AND p.escalation_after_minutes IS NOT NULL
+		 AND r.created_at + make_interval(mins => p.escalation_after_minutes) <= NOW()`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanRequests(ctx, rows)
+}
+
+func (r *JITRequestRepository) scanRequests(ctx context.Context, rows *sql.Rows) ([]roles.JITRequestDB, error) {
 	var requests []roles.JITRequestDB
 	for rows.Next() {
-		var req roles.JITRequestDB
-		err := rows.Scan(
-			&req.ID,
-			&req.UserID,
-			&req.Role,
-			&req.ResourceID,
-			&req.DurationMinutes,
-			&req.Reason,
-			&req.Status,
-			&req.ApprovedBy,
-			&req.CreatedAt,
-			&req.UpdatedAt,
-		)
+		req, err := scanRequest(rows)
 		if err != nil {
-			log.Printf("failed to scan JIT request: %v", err)
+			slog.ErrorContext(ctx, "failed to scan JIT request", "error", err)
 			continue
 		}
-		requests = append(requests, req)
+		requests = append(requests, *req)
 	}
 
 	return requests, nil
 }
+
+func scanRequest(row rowScanner) (*roles.JITRequestDB, error) {
+	var req roles.JITRequestDB
+	err := row.Scan(
+		&req.ID,
+		&req.UserID,
+		&req.Role,
+		&req.ResourceID,
+		&req.DurationMinutes,
+		&req.Reason,
+		&req.Status,
+		&req.ApprovedBy,
+		&req.PolicyID,
+		&req.ApprovalTier,
+		&req.EscalatedAt,
+		&req.GrantedRoleID,
+		&req.GrantExpiresAt,
+		&req.ParentRequestID,
+		&req.EndedBy,
+		&req.EndedAt,
+		&req.CreatedAt,
+		&req.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
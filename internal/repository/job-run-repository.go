@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"context"
+	"log/slog"
+	"time"
+)
+
+type JobRunRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewJobRunRepository(s database.Service) *JobRunRepository {
+	return &JobRunRepository{
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
+	}
+}
+
+// Start records a running job and returns the id of the run.
+func (r *JobRunRepository) Start(ctx context.Context, jobName, instanceID string) (int64, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	var id int64
+	err := r.db.QueryRowContext(ctx,
+		`INSERT INTO job_runs (job_name, instance_id, started_at, status)
+		 VALUES ($1, $2, NOW(), 'running')
+		 RETURNING id`,
+		jobName,
+		instanceID,
+	).Scan(&id)
+	return id, err
+}
+
+// Finish records the outcome of a run; runErr nil means it succeeded.
+func (r *JobRunRepository) Finish(ctx context.Context, id int64, items int, runErr error) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	status := models.JobSucceeded
+	var message *string
+	if runErr != nil {
+		status = models.JobFailed
+		m := runErr.Error()
+		message = &m
+	}
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE job_runs
+		 SET finished_at = NOW(), status = $2, items = $3, error = $4
+		 WHERE id = $1`,
+		id,
+		status,
+		items,
+		message,
+	)
+	return err
+}
+
+// List returns the most recent runs, optionally of one job only.
+func (r *JobRunRepository) List(ctx context.Context, jobName string, limit int) ([]models.JobRun, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, job_name, instance_id, started_at, finished_at, status, items, error
+		 FROM job_runs
+		 WHERE $1 = '' OR job_name = $1
+		 ORDER BY started_at DESC
+		 LIMIT $2`,
+		jobName,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var runs []models.JobRun
+	for rows.Next() {
+		var run models.JobRun
+		if err := rows.Scan(&run.ID, &run.JobName, &run.InstanceID, &run.StartedAt, &run.FinishedAt, &run.Status, &run.Items, &run.Error); err != nil {
+			slog.ErrorContext(ctx, "failed to scan job run", "error", err)
+			continue
+		}
+		runs = append(runs, run)
+	}
+
+	return runs, rows.Err()
+}
+
+// DeleteOlderThan removes finished runs started before the cutoff and returns how many were removed.
+func (r *JobRunRepository) DeleteOlderThan(ctx context.Context, cutoff time.Time) (int, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM job_runs WHERE started_at < $1 AND status <> 'running'", cutoff)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
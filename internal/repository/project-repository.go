@@ -3,32 +3,45 @@ package repository
 import (
 	"AuthServer/internal/database"
 	"AuthServer/internal/domain/models"
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"log/slog"
 )
 
 type IProjectRepository interface {
-	FindById(id string) (*models.Project, error)
-	FindByName(name string) (*models.Project, error)
-	FindAll() ([]models.Project, error)
-	Save(project models.Project) error
-	Update(project models.Project) error
-	Delete(id string) error
+	FindById(ctx context.Context, id string) (*models.Project, error)
+	FindByName(ctx context.Context, name string) (*models.Project, error)
+	FindAll(ctx context.Context) ([]models.Project, error)
+	Save(ctx context.Context, project models.Project) error
+	Import(ctx context.Context, project models.Project) (bool, error)
+	Update(ctx context.Context, project models.Project) error
+	Delete(ctx context.Context, id string) error
+	WithTx(tx *sql.Tx) IProjectRepository
 }
 
 type databaseProjectRepository struct {
-	db *sql.DB
+	db       database.DBTX
+	timeouts database.Timeouts
 }
 
 func NewProjectRepository(s database.Service) IProjectRepository {
 	return &databaseProjectRepository{
-		db: s.DB(),
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
 	}
 }
 
-func (d *databaseProjectRepository) FindById(id string) (*models.Project, error) {
-	row := d.db.QueryRow(
+// WithTx returns a repository that runs its queries in tx.
+func (d *databaseProjectRepository) WithTx(tx *sql.Tx) IProjectRepository {
+	return &databaseProjectRepository{db: tx, timeouts: d.timeouts}
+}
+
+func (d *databaseProjectRepository) FindById(ctx context.Context, id string) (*models.Project, error) {
+	ctx, cancel := d.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	row := d.db.QueryRowContext(ctx,
 		"SELECT id, name, description, created_at FROM project WHERE id = $1",
 		id,
 	)
@@ -51,8 +64,11 @@ func (d *databaseProjectRepository) FindById(id string) (*models.Project, error)
 	return &project, nil
 }
 
-func (d *databaseProjectRepository) FindByName(name string) (*models.Project, error) {
-	row := d.db.QueryRow(
+func (d *databaseProjectRepository) FindByName(ctx context.Context, name string) (*models.Project, error) {
+	ctx, cancel := d.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	row := d.db.QueryRowContext(ctx,
 		"SELECT id, name, description, created_at FROM project WHERE name = $1",
 		name,
 	)
@@ -75,10 +91,13 @@ func (d *databaseProjectRepository) FindByName(name string) (*models.Project, er
 	return &project, nil
 }
 
-func (d *databaseProjectRepository) FindAll() ([]models.Project, error) {
-	rows, err := d.db.Query("SELECT id, name, description, created_at FROM project")
+func (d *databaseProjectRepository) FindAll(ctx context.Context) ([]models.Project, error) {
+	ctx, cancel := d.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := d.db.QueryContext(ctx, "SELECT id, name, description, created_at FROM project")
 	if err != nil {
-		log.Printf("failed to query projects: %v", err)
+		slog.ErrorContext(ctx, "failed to query projects", "error", err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -87,7 +106,7 @@ func (d *databaseProjectRepository) FindAll() ([]models.Project, error) {
 	for rows.Next() {
 		var project models.Project
 		if err := rows.Scan(&project.ID, &project.Name, &project.Description, &project.CreatedAt); err != nil {
-			log.Printf("failed to scan project: %v", err)
+			slog.ErrorContext(ctx, "failed to scan project", "error", err)
 			continue
 		}
 		projects = append(projects, project)
@@ -100,9 +119,11 @@ func (d *databaseProjectRepository) FindAll() ([]models.Project, error) {
 	return projects, nil
 }
 
-func (d *databaseProjectRepository) Save(project models.Project) error {
-	log.Println("Saving project:", project.Name)
-	_, err := d.db.Exec(
+func (d *databaseProjectRepository) Save(ctx context.Context, project models.Project) error {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := d.db.ExecContext(ctx,
 		"INSERT INTO project (id, name, description, created_at) VALUES ($1, $2, $3, $4)",
 		project.ID,
 		project.Name,
@@ -112,8 +133,32 @@ func (d *databaseProjectRepository) Save(project models.Project) error {
 	return err
 }
 
-func (d *databaseProjectRepository) Update(project models.Project) error {
-	_, err := d.db.Exec(
+// Import inserts the project as given. It reports false and changes nothing if a project with
+// the same ID already exists.
+func (d *databaseProjectRepository) Import(ctx context.Context, project models.Project) (bool, error) {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := d.db.ExecContext(ctx,
+		`INSERT INTO project (id, name, description, created_at) VALUES ($1, $2, $3, $4)
+		 ON CONFLICT (id) DO NOTHING`,
+		project.ID,
+		project.Name,
+		project.Description,
+		project.CreatedAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+func (d *databaseProjectRepository) Update(ctx context.Context, project models.Project) error {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := d.db.ExecContext(ctx,
 		`UPDATE project 
 		SET name = $2, description = $3 
 		WHERE id = $1`,
@@ -123,17 +168,20 @@ func (d *databaseProjectRepository) Update(project models.Project) error {
 	)
 
 	if err != nil {
-		log.Printf("failed to update project %s: %v", project.ID, err)
+		slog.ErrorContext(ctx, "failed to update project", "project_id", project.ID, "error", err)
 		return err
 	}
 
 	return nil
 }
 
-func (d *databaseProjectRepository) Delete(id string) error {
-	result, err := d.db.Exec("DELETE FROM project WHERE id = $1", id)
+func (d *databaseProjectRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := d.db.ExecContext(ctx, "DELETE FROM project WHERE id = $1", id)
 	if err != nil {
-		log.Printf("failed to delete project %s: %v", id, err)
+		slog.ErrorContext(ctx, "failed to delete project", "project_id", id, "error", err)
 		return err
 	}
 
@@ -0,0 +1,210 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/rebac"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+)
+
+type RelationTupleRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewRelationTupleRepository(s database.Service) *RelationTupleRepository {
+	return &RelationTupleRepository{
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
+	}
+}
+
+// Write stores a tuple. Writing a tuple the role sync created takes it over, so the sync no
+// longer deletes it.
+func (r *RelationTupleRepository) Write(ctx context.Context, tuple rebac.RelationTuple) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO relation_tuples (namespace, object_id, relation, subject_namespace, subject_id, subject_relation, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		 ON CONFLICT (namespace, object_id, relation, subject_namespace, subject_id, subject_relation)
+		 DO UPDATE SET synced = FALSE`,
+		tuple.Object.Namespace,
+		tuple.Object.ID,
+		tuple.Relation,
+		tuple.Subject.Namespace,
+		tuple.Subject.ID,
+		tuple.Subject.Relation,
+	)
+	return err
+}
+
+// WriteSynced stores a tuple on behalf of the role sync. An existing tuple is left as it is.
+func (r *RelationTupleRepository) WriteSynced(ctx context.Context, tuple rebac.RelationTuple) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO relation_tuples (namespace, object_id, relation, subject_namespace, subject_id, subject_relation, synced, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, TRUE, NOW())
+		 ON CONFLICT DO NOTHING`,
+		tuple.Object.Namespace,
+		tuple.Object.ID,
+		tuple.Relation,
+		tuple.Subject.Namespace,
+		tuple.Subject.ID,
+		tuple.Subject.Relation,
+	)
+	return err
+}
+
+// ListSynced returns the tuples in a namespace written by the role sync.
+func (r *RelationTupleRepository) ListSynced(ctx context.Context, namespace string) ([]rebac.RelationTuple, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT namespace, object_id, relation, subject_namespace, subject_id, subject_relation
+		 FROM relation_tuples
+		 WHERE namespace = $1 AND synced`,
+		namespace,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanTuples(ctx, rows)
+}
+
+func (r *RelationTupleRepository) Delete(ctx context.Context, tuple rebac.RelationTuple) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`DELETE FROM relation_tuples
+		 WHERE namespace = $1 AND object_id = $2 AND relation = $3
+		 AND subject_namespace = $4 AND subject_id = $5 AND subject_relation = $6`,
+		tuple.Object.Namespace,
+		tuple.Object.ID,
+		tuple.Relation,
+		tuple.Subject.Namespace,
+		tuple.Subject.ID,
+		tuple.Subject.Relation,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("tuple not found")
+	}
+
+	return nil
+}
+
+// ReadTuples returns the tuples stored for object#relation.
+func (r *RelationTupleRepository) ReadTuples(ctx context.Context, object rebac.Object, relation string) ([]rebac.RelationTuple, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT namespace, object_id, relation, subject_namespace, subject_id, subject_relation
+		 FROM relation_tuples
+		 WHERE namespace = $1 AND object_id = $2 AND relation = $3`,
+		object.Namespace,
+		object.ID,
+		relation,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanTuples(ctx, rows)
+}
+
+// ListByObject returns every tuple stored for an object, optionally filtered by relation.
+func (r *RelationTupleRepository) ListByObject(ctx context.Context, object rebac.Object, relation string) ([]rebac.RelationTuple, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT namespace, object_id, relation, subject_namespace, subject_id, subject_relation
+		 FROM relation_tuples
+		 WHERE namespace = $1 AND object_id = $2 AND ($3 = '' OR relation = $3)
+		 ORDER BY relation, subject_namespace, subject_id`,
+		object.Namespace,
+		object.ID,
+		relation,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return r.scanTuples(ctx, rows)
+}
+
+// ListObjectIDs returns, in order, up to limit distinct ids after the given one of the objects
+// in a namespace that have at least one tuple.
+func (r *RelationTupleRepository) ListObjectIDs(ctx context.Context, namespace, after string, limit int) ([]string, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT DISTINCT object_id FROM relation_tuples
+		 WHERE namespace = $1 AND object_id > $2
+		 ORDER BY object_id
+		 LIMIT $3`,
+		namespace,
+		after,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			slog.ErrorContext(ctx, "failed to scan object id", "error", err)
+			continue
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, rows.Err()
+}
+
+func (r *RelationTupleRepository) scanTuples(ctx context.Context, rows *sql.Rows) ([]rebac.RelationTuple, error) {
+	var tuples []rebac.RelationTuple
+	for rows.Next() {
+		var t rebac.RelationTuple
+		err := rows.Scan(
+			&t.Object.Namespace,
+			&t.Object.ID,
+			&t.Relation,
+			&t.Subject.Namespace,
+			&t.Subject.ID,
+			&t.Subject.Relation,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan relation tuple", "error", err)
+			continue
+		}
+		tuples = append(tuples, t)
+	}
+
+	return tuples, rows.Err()
+}
@@ -0,0 +1,28 @@
+package repository
+
+// Schema lists, per table, the columns the repositories read and write. The server checks
+// it against the database at startup, so a missing migration fails fast instead of on the
+// first query that needs it. Keep it in step with the queries.
+var Schema = map[string][]string{
+	"users":                   {"id", "full_name", "username", "email", "password", "created_at", "disabled_at", "email_verified_at"},
+	"project":                 {"id", "name", "description", "created_at"},
+	"user_roles":              {"id", "user_id", "project_id", "role", "expires_at", "created_by", "condition", "created_at"},
+	"jit_requests":            {"id", "user_id", "role", "resource_id", "duration_minutes", "reason", "status", "approved_by", "created_at", "updated_at", "policy_id", "approval_tier", "escalated_at", "granted_role_id", "grant_expires_at", "parent_request_id", "ended_by", "ended_at"},
+	"jit_approval_policies":   {"id", "role", "resource_id", "required_approvals", "approver_group_id", "auto_approve_max_minutes", "escalation_after_minutes", "escalation_group_id", "created_by", "created_at"},
+	"jit_approvals":           {"id", "request_id", "approver_id", "tier", "decision", "comment", "created_at"},
+	"relation_tuples":         {"namespace", "object_id", "relation", "subject_namespace", "subject_id", "subject_relation", "synced", "created_at"},
+	"user_groups":             {"id", "name", "description", "created_by", "created_at"},
+	"group_members":           {"group_id", "member_type", "member_id", "added_by", "created_at"},
+	"group_roles":             {"id", "group_id", "role", "project_id", "expires_at", "condition", "created_by", "created_at"},
+	"sod_violations":          {"id", "rule", "message", "actor_id", "target_user_id", "role", "resource_id", "conflicting_role", "request_id", "created_at"},
+	"break_glass_sessions":    {"id", "user_id", "role", "justification", "granted_role_id", "started_at", "expires_at", "ended_at", "review_status", "reviewed_by", "reviewed_at", "review_notes"},
+	"break_glass_access_log":  {"id", "session_id", "method", "path", "status", "ip", "occurred_at"},
+	"access_review_campaigns": {"id", "name", "scope_role", "scope_project_id", "reviewer_id", "due_at", "overdue_action", "escalation_reviewer_id", "escalation_grace_hours", "status", "created_by", "created_at", "completed_at"},
+	"access_review_items":     {"id", "campaign_id", "user_role_id", "user_id", "role", "project_id", "reviewer_id", "decision", "comment", "decided_by", "decided_at", "auto_decided", "escalated_at"},
+	"job_runs":                {"id", "job_name", "instance_id", "started_at", "finished_at", "status", "items", "error"},
+	"audit_events":            {"id", "occurred_at", "actor_id", "action", "target_type", "target_id", "outcome", "reason", "before_state", "after_state", "ip", "user_agent", "request_id", "prev_hash", "hash"},
+	"audit_checkpoints":       {"id", "event_id", "event_hash", "key_id", "signature", "created_at"},
+	"webhook_subscriptions":   {"id", "url", "description", "events", "secret", "active", "consecutive_failures", "disabled_at", "disabled_reason", "created_by", "created_at"},
+	"webhook_deliveries":      {"id", "subscription_id", "event_id", "event_type", "payload", "status", "attempts", "next_attempt_at", "last_attempt_at", "response_status", "response_body", "error", "redelivery_of", "created_at"},
+	"signing_keys":            {"id", "sealed_secret", "created_at", "retired_at"},
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+type SigningKeyRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewSigningKeyRepository(s database.Service) *SigningKeyRepository {
+	return &SigningKeyRepository{db: s.DB(), timeouts: s.Timeouts()}
+}
+
+// WithTx returns a repository that runs its queries in tx.
+func (r *SigningKeyRepository) WithTx(tx *sql.Tx) *SigningKeyRepository {
+	return &SigningKeyRepository{db: tx, timeouts: r.timeouts}
+}
+
+func (r *SigningKeyRepository) Create(ctx context.Context, key *models.SigningKey) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO signing_keys (id, sealed_secret) VALUES ($1, $2) RETURNING created_at`,
+		key.ID,
+		key.Sealed,
+	).Scan(&key.CreatedAt)
+}
+
+// RetireActive marks every key that is not yet retired as retired at the given time and
+// returns how many were retired.
+func (r *SigningKeyRepository) RetireActive(ctx context.Context, at time.Time) (int, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "UPDATE signing_keys SET retired_at = $1 WHERE retired_at IS NULL", at)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+// List returns every key, newest first, with its secret still sealed.
+func (r *SigningKeyRepository) List(ctx context.Context) ([]models.SigningKey, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, sealed_secret, created_at, retired_at FROM signing_keys ORDER BY created_at DESC, id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var keys []models.SigningKey
+	for rows.Next() {
+		var key models.SigningKey
+		if err := rows.Scan(&key.ID, &key.Sealed, &key.CreatedAt, &key.RetiredAt); err != nil {
+			slog.ErrorContext(ctx, "failed to scan signing key", "error", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+
+	return keys, rows.Err()
+}
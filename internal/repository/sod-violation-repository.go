@@ -0,0 +1,87 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/roles"
+	"context"
+	"log/slog"
+
+	"github.com/google/uuid"
+)
+
+type SoDViolationRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewSoDViolationRepository(s database.Service) *SoDViolationRepository {
+	return &SoDViolationRepository{
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
+	}
+}
+
+func (r *SoDViolationRepository) Record(ctx context.Context, v *roles.SoDViolation) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	v.ID = uuid.New().String()
+
+	var conflicting *string
+	if v.ConflictingRole != nil {
+		c := string(*v.ConflictingRole)
+		conflicting = &c
+	}
+
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO sod_violations (id, rule, message, actor_id, target_user_id, role, resource_id, conflicting_role, request_id, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW())
+		 RETURNING created_at`,
+		v.ID,
+		v.Rule,
+		v.Message,
+		v.ActorID,
+		v.TargetUserID,
+		string(v.Role),
+		v.ResourceID,
+		conflicting,
+		v.RequestID,
+	).Scan(&v.CreatedAt)
+}
+
+func (r *SoDViolationRepository) List(ctx context.Context, limit int) ([]roles.SoDViolation, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, rule, message, actor_id, target_user_id, role, resource_id, conflicting_role, request_id, created_at
+		 FROM sod_violations
+		 ORDER BY created_at DESC
+		 LIMIT $1`,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var violations []roles.SoDViolation
+	for rows.Next() {
+		var v roles.SoDViolation
+		var role string
+		var conflicting *string
+		err := rows.Scan(&v.ID, &v.Rule, &v.Message, &v.ActorID, &v.TargetUserID, &role, &v.ResourceID, &conflicting, &v.RequestID, &v.CreatedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan SoD violation", "error", err)
+			continue
+		}
+		v.Role = roles.Role(role)
+		if conflicting != nil {
+			c := roles.Role(*conflicting)
+			v.ConflictingRole = &c
+		}
+		violations = append(violations, v)
+	}
+
+	return violations, rows.Err()
+}
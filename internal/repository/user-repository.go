@@ -3,31 +3,64 @@ package repository
 import (
 	"AuthServer/internal/database"
 	"AuthServer/internal/domain/models"
+	"context"
 	"database/sql"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"time"
 )
 
+// ErrUserNotFound is returned when a user does not exist.
+var ErrUserNotFound = errors.New("user not found")
+
 type IUserRepository interface {
 	//FindAll() []models.User
-	FindById(id string) (*models.User, error)
-	FindByEmail(email string) *models.User
-	FindByEmailOrUsername(emailOrUsername string) (*models.User, error)
-	Save(user models.User) error
-	Update(user models.User) error
-	Delete(id string) error
+	FindById(ctx context.Context, id string) (*models.User, error)
+	FindByEmail(ctx context.Context, email string) *models.User
+	FindByEmailOrUsername(ctx context.Context, emailOrUsername string) (*models.User, error)
+	List(ctx context.Context) ([]models.User, error)
+	Save(ctx context.Context, user models.User) error
+	Import(ctx context.Context, user models.User) (bool, error)
+	Update(ctx context.Context, user models.User) error
+	SetDisabled(ctx context.Context, id string, disabledAt *time.Time) error
+	MarkEmailVerified(ctx context.Context, id string) error
+	Delete(ctx context.Context, id string) error
+	WithTx(tx *sql.Tx) IUserRepository
 }
 
+const userColumns = "id, full_name, username, email, password, created_at, disabled_at, email_verified_at"
+
 type databaseUserRepository struct {
-	db *sql.DB
+	db       database.DBTX
+	timeouts database.Timeouts
 }
 
 func NewUserRepository(s database.Service) IUserRepository {
 	return &databaseUserRepository{
-		db: s.DB(),
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
 	}
 }
 
+// WithTx returns a repository that runs its queries in tx.
+func (d *databaseUserRepository) WithTx(tx *sql.Tx) IUserRepository {
+	return &databaseUserRepository{db: tx, timeouts: d.timeouts}
+}
+
+func scanUser(row rowScanner, user *models.User) error {
+	return row.Scan(
+		&user.ID,
+		&user.FullName,
+		&user.Username,
+		&user.Email,
+		&user.Password,
+		&user.CreatedAt,
+		&user.DisabledAt,
+		&user.EmailVerifiedAt,
+	)
+}
+
 //func (d *databaseUserRepository) FindAll() []models.User {
 //	rows, err := d.db.Query("SELECT id, first_name, last_name, google_email FROM users")
 //	if err != nil {
@@ -48,25 +81,21 @@ func NewUserRepository(s database.Service) IUserRepository {
 //	return users
 //}
 
-func (d *databaseUserRepository) FindById(id string) (*models.User, error) {
-	row := d.db.QueryRow(
-		"SELECT * FROM users WHERE id = $1",
+func (d *databaseUserRepository) FindById(ctx context.Context, id string) (*models.User, error) {
+	ctx, cancel := d.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	row := d.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE id = $1",
 		id,
 	)
 
 	var user models.User
-	err := row.Scan(
-		&user.ID,
-		&user.FullName,
-		&user.Username,
-		&user.Email,
-		&user.Password,
-		&user.CreatedAt,
-	)
+	err := scanUser(row, &user)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			return nil, fmt.Errorf("user not found")
+			return nil, ErrUserNotFound
 		}
 		return nil, fmt.Errorf("failed to scan user: %v", err)
 	}
@@ -74,55 +103,100 @@ func (d *databaseUserRepository) FindById(id string) (*models.User, error) {
 	return &user, nil
 }
 
-func (d *databaseUserRepository) FindByEmail(email string) *models.User {
-	row := d.db.QueryRow(
-		"SELECT * FROM users WHERE email = $1",
+func (d *databaseUserRepository) FindByEmail(ctx context.Context, email string) *models.User {
+	ctx, cancel := d.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	row := d.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE email = $1",
 		email,
 	)
 
 	var user models.User
-	err := row.Scan(
-		&user.ID,
-		&user.FullName,
-		&user.Username,
-		&user.Email,
-		&user.Password,
-		&user.CreatedAt,
-	)
+	err := scanUser(row, &user)
 
 	if err != nil {
 		if err == sql.ErrNoRows {
-			log.Printf("user with email=%s not found", email)
 			return nil
 		}
-		log.Printf("failed to scan user by email: %v", err)
+		slog.ErrorContext(ctx, "failed to find user by email", "error", err)
 		return nil
 	}
 
 	return &user
 }
 
-func (r *databaseUserRepository) FindByEmailOrUsername(identifier string) (*models.User, error) {
+func (r *databaseUserRepository) FindByEmailOrUsername(ctx context.Context, identifier string) (*models.User, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
 	var user models.User
-	err := r.db.QueryRow(
-		"SELECT id, full_name, username, email, password, created_at FROM users WHERE email = $1 OR username = $1",
+	err := scanUser(r.db.QueryRowContext(ctx,
+		"SELECT "+userColumns+" FROM users WHERE email = $1 OR username = $1",
 		identifier,
-	).Scan(&user.ID, &user.FullName, &user.Username, &user.Email, &user.Password, &user.CreatedAt)
+	), &user)
 
 	return &user, err
 }
 
-func (d *databaseUserRepository) Save(user models.User) error {
-	log.Println("Saving user:", user.FullName)
-	_, err := d.db.Exec(
+// List returns every user, oldest first.
+func (d *databaseUserRepository) List(ctx context.Context) ([]models.User, error) {
+	ctx, cancel := d.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := d.db.QueryContext(ctx, "SELECT "+userColumns+" FROM users ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []models.User
+	for rows.Next() {
+		var user models.User
+		if err := scanUser(rows, &user); err != nil {
+			slog.ErrorContext(ctx, "failed to scan user", "error", err)
+			continue
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+func (d *databaseUserRepository) Save(ctx context.Context, user models.User) error {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := d.db.ExecContext(ctx,
 		"INSERT INTO users (id, full_name, username, email, password, created_at) VALUES ($1, $2, $3, $4, $5, $6)",
 		user.ID, user.FullName, user.Username, user.Email, user.Password, user.CreatedAt,
 	)
 	return err
 }
 
-func (d *databaseUserRepository) Update(user models.User) error {
-	_, err := d.db.Exec(
+// Import inserts the user exactly as given, password hash and disabled state included. It
+// reports false and changes nothing if a user with the same ID already exists.
+func (d *databaseUserRepository) Import(ctx context.Context, user models.User) (bool, error) {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := d.db.ExecContext(ctx,
+		`INSERT INTO users (`+userColumns+`) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		 ON CONFLICT (id) DO NOTHING`,
+		user.ID, user.FullName, user.Username, user.Email, user.Password, user.CreatedAt, user.DisabledAt,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+func (d *databaseUserRepository) Update(ctx context.Context, user models.User) error {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := d.db.ExecContext(ctx,
 		`
 		UPDATE users
 		
@@ -138,13 +212,41 @@ func (d *databaseUserRepository) Update(user models.User) error {
 		user.Password,
 	)
 	if err != nil {
-		log.Printf("failed to update user %s: %v", user.ID, err)
+		slog.ErrorContext(ctx, "failed to update user", "user_id", user.ID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// SetDisabled disables the user at disabledAt, or enables them again when it is nil.
+func (d *databaseUserRepository) SetDisabled(ctx context.Context, id string, disabledAt *time.Time) error {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := d.db.ExecContext(ctx, "UPDATE users SET disabled_at = $2 WHERE id = $1", id, disabledAt)
+	if err != nil {
 		return err
 	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrUserNotFound
+	}
 	return nil
 }
 
-func (d *databaseUserRepository) Delete(id string) error {
-	d.db.Exec("DELETE FROM users WHERE id = $1", id)
+// MarkEmailVerified records that the user verified their email, keeping the time of the
+// first verification.
+func (d *databaseUserRepository) MarkEmailVerified(ctx context.Context, id string) error {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := d.db.ExecContext(ctx, "UPDATE users SET email_verified_at = NOW() WHERE id = $1 AND email_verified_at IS NULL", id)
+	return err
+}
+
+func (d *databaseUserRepository) Delete(ctx context.Context, id string) error {
+	ctx, cancel := d.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	d.db.ExecContext(ctx, "DELETE FROM users WHERE id = $1", id)
 	return nil
 }
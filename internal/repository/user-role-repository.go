@@ -2,47 +2,113 @@ package repository
 
 import (
 	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
 	"AuthServer/internal/domain/roles"
+	"context"
 	"database/sql"
-	"fmt"
-	"log"
+	"errors"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
 )
 
+// ErrRoleNotFound is returned when a role assignment does not exist.
+var ErrRoleNotFound = errors.New("role not found")
+
 type UserRoleRepository struct {
-	db *sql.DB
+	db       database.DBTX
+	timeouts database.Timeouts
 }
 
 func NewUserRoleRepository(s database.Service) *UserRoleRepository {
 	return &UserRoleRepository{
-		db: s.DB(),
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
 	}
 }
 
-func (r *UserRoleRepository) AssignRole(userID string, role roles.Role, resourceID *string, expiresAt *time.Time, createdBy string) error {
-	log.Printf("Assigning role %s to user %s", role, userID)
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *UserRoleRepository) WithTx(tx *sql.Tx) *UserRoleRepository {
+	return &UserRoleRepository{db: tx, timeouts: r.timeouts}
+}
+
+// AssignRole inserts a role assignment and returns the id of the new user_roles row.
+func (r *UserRoleRepository) AssignRole(ctx context.Context, userID string, role roles.Role, resourceID *string, expiresAt *time.Time, createdBy string, condition *string) (string, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
 
 	id := uuid.New().String()
 
-	_, err := r.db.Exec(
-		`INSERT INTO user_roles (id, user_id, project_id, role, expires_at, created_by, created_at)
-		 VALUES ($1, $2, $3, $4, $5, $6, NOW())`,
+	_, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_roles (id, user_id, project_id, role, expires_at, created_by, condition, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())`,
 		id,
 		userID,
 		resourceID,
 		string(role),
 		expiresAt,
 		createdBy,
+		condition,
+	)
+	if err != nil {
+		return "", err
+	}
+
+	return id, nil
+}
+
+// GetEnabledUserRoles returns GetUserRoles for a user who exists and is not disabled, and
+// reports whether that is the case, in one query.
+func (r *UserRoleRepository) GetEnabledUserRoles(ctx context.Context, userID string) ([]roles.UserRole, bool, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT u.disabled_at IS NULL, ur.id, ur.role, ur.project_id, ur.expires_at, ur.condition
+		 FROM users u
+		 LEFT JOIN user_roles ur ON ur.user_id = u.id AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+		 WHERE u.id = $1
+		 ORDER BY ur.created_at DESC`,
+		userID,
 	)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var userRoles []roles.UserRole
+	enabled := false
+	for rows.Next() {
+		var id, roleStr sql.NullString
+		var ur roles.UserRole
+		if err := rows.Scan(&enabled, &id, &roleStr, &ur.ResourceID, &ur.ExpiresAt, &ur.Condition); err != nil {
+			return nil, false, err
+		}
+		if !id.Valid {
+			continue
+		}
+		ur.ID = id.String
+		ur.UserID = userID
+		ur.Role = roles.Role(roleStr.String)
+		userRoles = append(userRoles, ur)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	if !enabled {
+		return nil, false, nil
+	}
 
-	return err
+	return userRoles, true, nil
 }
 
-func (r *UserRoleRepository) GetUserRoles(userID string) ([]roles.UserRole, error) {
-	rows, err := r.db.Query(
-		`SELECT user_id, role, project_id, expires_at
+func (r *UserRoleRepository) GetUserRoles(ctx context.Context, userID string) ([]roles.UserRole, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, role, project_id, expires_at, condition
 		 FROM user_roles
 		 WHERE user_id = $1 AND (expires_at IS NULL OR expires_at > NOW())
 		 ORDER BY created_at DESC`,
@@ -57,9 +123,73 @@ func (r *UserRoleRepository) GetUserRoles(userID string) ([]roles.UserRole, erro
 	for rows.Next() {
 		var ur roles.UserRole
 		var roleStr string
-		err := rows.Scan(&ur.UserID, &roleStr, &ur.ResourceID, &ur.ExpiresAt)
+		err := rows.Scan(&ur.ID, &ur.UserID, &roleStr, &ur.ResourceID, &ur.ExpiresAt, &ur.Condition)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan user role", "error", err)
+			continue
+		}
+		ur.Role = roles.Role(roleStr)
+		userRoles = append(userRoles, ur)
+	}
+
+	return userRoles, nil
+}
+
+// GetUserRolesIncludingExpired returns every assignment of the user, expired ones included.
+func (r *UserRoleRepository) GetUserRolesIncludingExpired(ctx context.Context, userID string) ([]roles.UserRole, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, role, project_id, expires_at, condition
+		 FROM user_roles
+		 WHERE user_id = $1
+		 ORDER BY created_at DESC`,
+		userID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userRoles []roles.UserRole
+	for rows.Next() {
+		var ur roles.UserRole
+		var roleStr string
+		err := rows.Scan(&ur.ID, &ur.UserID, &roleStr, &ur.ResourceID, &ur.ExpiresAt, &ur.Condition)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan user role", "error", err)
+			continue
+		}
+		ur.Role = roles.Role(roleStr)
+		userRoles = append(userRoles, ur)
+	}
+
+	return userRoles, nil
+}
+
+// GetAllResourceRoles returns every active resource-specific role assignment.
+func (r *UserRoleRepository) GetAllResourceRoles(ctx context.Context) ([]roles.UserRole, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, role, project_id, expires_at, condition
+		 FROM user_roles
+		 WHERE project_id IS NOT NULL AND (expires_at IS NULL OR expires_at > NOW())`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userRoles []roles.UserRole
+	for rows.Next() {
+		var ur roles.UserRole
+		var roleStr string
+		err := rows.Scan(&ur.ID, &ur.UserID, &roleStr, &ur.ResourceID, &ur.ExpiresAt, &ur.Condition)
 		if err != nil {
-			log.Printf("failed to scan user role: %v", err)
+			slog.ErrorContext(ctx, "failed to scan user role", "error", err)
 			continue
 		}
 		ur.Role = roles.Role(roleStr)
@@ -69,8 +199,121 @@ func (r *UserRoleRepository) GetUserRoles(userID string) ([]roles.UserRole, erro
 	return userRoles, nil
 }
 
-func (r *UserRoleRepository) RevokeRole(roleID string) error {
-	result, err := r.db.Exec("DELETE FROM user_roles WHERE id = $1", roleID)
+// GetResourceMembers returns the active direct role assignments on a project with user details.
+func (r *UserRoleRepository) GetResourceMembers(ctx context.Context, projectID string) ([]models.ProjectMember, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT ur.id, ur.user_id, u.username, u.full_name, ur.role, ur.expires_at, ur.created_by, ur.created_at
+		 FROM user_roles ur
+		 JOIN users u ON u.id = ur.user_id
+		 WHERE ur.project_id = $1 AND (ur.expires_at IS NULL OR ur.expires_at > NOW())
+		 ORDER BY ur.created_at`,
+		projectID,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var members []models.ProjectMember
+	for rows.Next() {
+		var m models.ProjectMember
+		err := rows.Scan(&m.AssignmentID, &m.UserID, &m.Username, &m.FullName, &m.Role, &m.ExpiresAt, &m.CreatedBy, &m.CreatedAt)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan project member", "error", err)
+			continue
+		}
+		members = append(members, m)
+	}
+
+	return members, rows.Err()
+}
+
+// RevokeResourceRoles removes the given roles of a user on a project and returns how many were removed.
+func (r *UserRoleRepository) RevokeResourceRoles(ctx context.Context, userID, projectID string, revoked []roles.Role) (int, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	names := make([]string, len(revoked))
+	for i, role := range revoked {
+		names[i] = string(role)
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		"DELETE FROM user_roles WHERE user_id = $1 AND project_id = $2 AND role = ANY($3)",
+		userID,
+		projectID,
+		names,
+	)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+
+	return int(rowsAffected), nil
+}
+
+// GetByID returns a direct assignment, expired or not.
+func (r *UserRoleRepository) GetByID(ctx context.Context, roleID string) (*roles.UserRole, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	var ur roles.UserRole
+	var roleStr string
+	err := r.db.QueryRowContext(ctx,
+		`SELECT id, user_id, role, project_id, expires_at, condition
+		 FROM user_roles
+		 WHERE id = $1`,
+		roleID,
+	).Scan(&ur.ID, &ur.UserID, &roleStr, &ur.ResourceID, &ur.ExpiresAt, &ur.Condition)
+	if err == sql.ErrNoRows {
+		return nil, ErrRoleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	ur.Role = roles.Role(roleStr)
+	return &ur, nil
+}
+
+func (r *UserRoleRepository) RevokeRole(ctx context.Context, roleID string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM user_roles WHERE id = $1", roleID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return ErrRoleNotFound
+	}
+
+	return nil
+}
+
+// ExtendRole moves the expiry of a time-bound assignment.
+func (r *UserRoleRepository) ExtendRole(ctx context.Context, roleID string, expiresAt time.Time) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		"UPDATE user_roles SET expires_at = $2 WHERE id = $1 AND expires_at IS NOT NULL",
+		roleID,
+		expiresAt,
+	)
 	if err != nil {
 		return err
 	}
@@ -81,13 +324,16 @@ func (r *UserRoleRepository) RevokeRole(roleID string) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("role not found")
+		return ErrRoleNotFound
 	}
 
 	return nil
 }
 
-func (r *UserRoleRepository) HasRole(userID string, role roles.Role, resourceID *string) (bool, error) {
+func (r *UserRoleRepository) HasRole(ctx context.Context, userID string, role roles.Role, resourceID *string) (bool, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
 	var count int
 	var query string
 	var args []interface{}
@@ -104,7 +350,7 @@ func (r *UserRoleRepository) HasRole(userID string, role roles.Role, resourceID
 		args = []interface{}{userID, string(role), *resourceID}
 	}
 
-	err := r.db.QueryRow(query, args...).Scan(&count)
+	err := r.db.QueryRowContext(ctx, query, args...).Scan(&count)
 	if err != nil {
 		return false, err
 	}
@@ -112,8 +358,82 @@ func (r *UserRoleRepository) HasRole(userID string, role roles.Role, resourceID
 	return count > 0, nil
 }
 
-func (r *UserRoleRepository) CleanupExpiredRoles() (int, error) {
-	result, err := r.db.Exec(
+// CountGlobalHolders returns how many users hold role globally and unexpired.
+func (r *UserRoleRepository) CountGlobalHolders(ctx context.Context, role roles.Role) (int, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	var count int
+	err := r.db.QueryRowContext(ctx,
+		`SELECT COUNT(DISTINCT user_id) FROM user_roles
+		 WHERE role = $1 AND project_id IS NULL AND (expires_at IS NULL OR expires_at > NOW())`,
+		string(role),
+	).Scan(&count)
+	return count, err
+}
+
+// ListAll returns every active direct assignment, oldest first.
+func (r *UserRoleRepository) ListAll(ctx context.Context) ([]roles.UserRole, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, user_id, role, project_id, expires_at, condition
+		 FROM user_roles
+		 WHERE expires_at IS NULL OR expires_at > NOW()
+		 ORDER BY created_at, id`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var userRoles []roles.UserRole
+	for rows.Next() {
+		var ur roles.UserRole
+		var roleStr string
+		err := rows.Scan(&ur.ID, &ur.UserID, &roleStr, &ur.ResourceID, &ur.ExpiresAt, &ur.Condition)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan user role", "error", err)
+			continue
+		}
+		ur.Role = roles.Role(roleStr)
+		userRoles = append(userRoles, ur)
+	}
+
+	return userRoles, rows.Err()
+}
+
+// Import inserts an assignment with its original ID. It reports false and changes nothing if
+// an assignment with the same ID already exists.
+func (r *UserRoleRepository) Import(ctx context.Context, ur roles.UserRole, createdBy string) (bool, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
+		`INSERT INTO user_roles (id, user_id, project_id, role, expires_at, created_by, condition, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		 ON CONFLICT (id) DO NOTHING`,
+		ur.ID,
+		ur.UserID,
+		ur.ResourceID,
+		string(ur.Role),
+		ur.ExpiresAt,
+		createdBy,
+		ur.Condition,
+	)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n == 1, err
+}
+
+func (r *UserRoleRepository) CleanupExpiredRoles(ctx context.Context) (int, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx,
 		"DELETE FROM user_roles WHERE expires_at IS NOT NULL AND expires_at <= NOW()",
 	)
 	if err != nil {
@@ -126,7 +446,7 @@ func (r *UserRoleRepository) CleanupExpiredRoles() (int, error) {
 	}
 
 	if rowsAffected > 0 {
-		log.Printf("Cleaned up %d expired roles", rowsAffected)
+		slog.InfoContext(ctx, "cleaned up expired roles", "count", rowsAffected)
 	}
 
 	return int(rowsAffected), nil
@@ -0,0 +1,419 @@
+package repository
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const webhookSubscriptionColumns = `id, url, description, events, secret, active, consecutive_failures,
+	disabled_at, disabled_reason, created_by, created_at`
+
+const webhookDeliveryColumns = `id, subscription_id, event_id, event_type, payload, status, attempts, next_attempt_at,
+	last_attempt_at, response_status, response_body, error, redelivery_of, created_at`
+
+type WebhookRepository struct {
+	db       database.DBTX
+	timeouts database.Timeouts
+}
+
+func NewWebhookRepository(s database.Service) *WebhookRepository {
+	return &WebhookRepository{
+		db:       s.DB(),
+		timeouts: s.Timeouts(),
+	}
+}
+
+// WithTx returns a copy of the repository that runs its queries inside tx.
+func (r *WebhookRepository) WithTx(tx *sql.Tx) *WebhookRepository {
+	return &WebhookRepository{db: tx, timeouts: r.timeouts}
+}
+
+func (r *WebhookRepository) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	events, err := json.Marshal(sub.Events)
+	if err != nil {
+		return err
+	}
+
+	sub.ID = uuid.New().String()
+	sub.Active = true
+
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO webhook_subscriptions (id, url, description, events, secret, active, created_by, created_at)
+		 VALUES ($1, $2, $3, $4, $5, TRUE, $6, NOW())
+		 RETURNING created_at`,
+		sub.ID,
+		sub.URL,
+		sub.Description,
+		string(events),
+		sub.Secret,
+		sub.CreatedBy,
+	).Scan(&sub.CreatedAt)
+}
+
+func (r *WebhookRepository) GetSubscription(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	sub, err := scanWebhookSubscription(r.db.QueryRowContext(ctx,
+		`SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions WHERE id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook subscription not found")
+	}
+	return sub, err
+}
+
+func (r *WebhookRepository) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions ORDER BY created_at`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhookSubscriptions(ctx, rows)
+}
+
+// ListSubscribers returns the active subscriptions that selected eventType.
+func (r *WebhookRepository) ListSubscribers(ctx context.Context, eventType string) ([]models.WebhookSubscription, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+webhookSubscriptionColumns+`
+		 FROM webhook_subscriptions
+		 WHERE active AND events @> jsonb_build_array($1::text)`,
+		eventType,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhookSubscriptions(ctx, rows)
+}
+
+// UpdateSubscription saves the URL, description, events and active flag. Activating a
+// subscription clears its failure count and the reason it was disabled.
+func (r *WebhookRepository) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	events, err := json.Marshal(sub.Events)
+	if err != nil {
+		return err
+	}
+
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_subscriptions
+		 SET url = $2, description = $3, events = $4, active = $5,
+		     consecutive_failures = CASE WHEN $5 AND NOT active THEN 0 ELSE consecutive_failures END,
+		     disabled_at = CASE WHEN $5 THEN NULL ELSE disabled_at END,
+		     disabled_reason = CASE WHEN $5 THEN NULL ELSE disabled_reason END
+		 WHERE id = $1`,
+		sub.ID,
+		sub.URL,
+		sub.Description,
+		string(events),
+		sub.Active,
+	)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook subscription not found")
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) UpdateSecret(ctx context.Context, id, secret string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "UPDATE webhook_subscriptions SET secret = $2 WHERE id = $1", id, secret)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook subscription not found")
+	}
+
+	return nil
+}
+
+func (r *WebhookRepository) DeleteSubscription(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	result, err := r.db.ExecContext(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("webhook subscription not found")
+	}
+
+	return nil
+}
+
+// RecordSuccess resets the subscription's failure count.
+func (r *WebhookRepository) RecordSuccess(ctx context.Context, id string) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = $1 AND consecutive_failures > 0", id)
+	return err
+}
+
+// RecordFailure counts a failed attempt and disables the subscription once disableAfter
+// attempts in a row have failed. It reports whether this call disabled it.
+func (r *WebhookRepository) RecordFailure(ctx context.Context, id string, disableAfter int, reason string) (bool, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	var disabled bool
+	err := r.db.QueryRowContext(ctx,
+		`UPDATE webhook_subscriptions
+		 SET consecutive_failures = consecutive_failures + 1,
+		     active = active AND consecutive_failures + 1 < $2,
+		     disabled_at = CASE WHEN active AND consecutive_failures + 1 >= $2 THEN NOW() ELSE disabled_at END,
+		     disabled_reason = CASE WHEN active AND consecutive_failures + 1 >= $2 THEN $3 ELSE disabled_reason END
+		 WHERE id = $1
+		 RETURNING disabled_at IS NOT NULL AND NOT active AND consecutive_failures = $2`,
+		id,
+		disableAfter,
+		reason,
+	).Scan(&disabled)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return disabled, err
+}
+
+// CreateDelivery queues a delivery. A NextAttemptAt in the future leases the first attempt to
+// the caller; the dispatcher picks the delivery up if that attempt never completes.
+func (r *WebhookRepository) CreateDelivery(ctx context.Context, d *models.WebhookDelivery) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	d.ID = uuid.New().String()
+	d.Status = models.WebhookPending
+
+	return r.db.QueryRowContext(ctx,
+		`INSERT INTO webhook_deliveries (id, subscription_id, event_id, event_type, payload, status, attempts,
+		     next_attempt_at, redelivery_of, created_at)
+		 VALUES ($1, $2, $3, $4, $5, $6, 0, $7, $8, NOW())
+		 RETURNING created_at`,
+		d.ID,
+		d.SubscriptionID,
+		d.EventID,
+		d.EventType,
+		string(d.Payload),
+		d.Status,
+		d.NextAttemptAt,
+		d.RedeliveryOf,
+	).Scan(&d.CreatedAt)
+}
+
+// GetDelivery returns a delivery of the given subscription.
+func (r *WebhookRepository) GetDelivery(ctx context.Context, subscriptionID, id string) (*models.WebhookDelivery, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	d, err := scanWebhookDelivery(r.db.QueryRowContext(ctx,
+		`SELECT `+webhookDeliveryColumns+` FROM webhook_deliveries WHERE id = $1 AND subscription_id = $2`,
+		id, subscriptionID,
+	))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook delivery not found")
+	}
+	return d, err
+}
+
+// ListDeliveries returns the subscription's most recent deliveries, optionally in one status.
+func (r *WebhookRepository) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]models.WebhookDelivery, error) {
+	ctx, cancel := r.timeouts.ReadContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT `+webhookDeliveryColumns+`
+		 FROM webhook_deliveries
+		 WHERE subscription_id = $1 AND ($2 = '' OR status = $2)
+		 ORDER BY created_at DESC
+		 LIMIT $3`,
+		subscriptionID,
+		status,
+		limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhookDeliveries(ctx, rows)
+}
+
+// ClaimDue leases up to limit pending deliveries of active subscriptions whose next attempt
+// is due, pushing their next attempt lease into the future so no other run picks them up.
+func (r *WebhookRepository) ClaimDue(ctx context.Context, limit int, lease time.Duration) ([]models.WebhookDelivery, error) {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	rows, err := r.db.QueryContext(ctx,
+		`UPDATE webhook_deliveries
+		 SET next_attempt_at = NOW() + $2 * INTERVAL '1 second'
+		 WHERE id IN (
+		     SELECT d.id
+		     FROM webhook_deliveries d
+		     JOIN webhook_subscriptions s ON s.id = d.subscription_id
+		     WHERE d.status = 'pending' AND d.next_attempt_at <= NOW() AND s.active
+		     ORDER BY d.next_attempt_at
+		     LIMIT $1
+		     FOR UPDATE OF d SKIP LOCKED
+		 )
+		 RETURNING `+webhookDeliveryColumns,
+		limit,
+		lease.Seconds(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	return scanWebhookDeliveries(ctx, rows)
+}
+
+// RecordAttempt saves the outcome of an attempt: the status, attempt count, next attempt time
+// and the response or error.
+func (r *WebhookRepository) RecordAttempt(ctx context.Context, d *models.WebhookDelivery) error {
+	ctx, cancel := r.timeouts.WriteContext(ctx)
+	defer cancel()
+
+	_, err := r.db.ExecContext(ctx,
+		`UPDATE webhook_deliveries
+		 SET status = $2, attempts = $3, next_attempt_at = $4, last_attempt_at = $5,
+		     response_status = $6, response_body = $7, error = $8
+		 WHERE id = $1`,
+		d.ID,
+		d.Status,
+		d.Attempts,
+		d.NextAttemptAt,
+		d.LastAttemptAt,
+		d.ResponseStatus,
+		d.ResponseBody,
+		d.Error,
+	)
+	return err
+}
+
+func scanWebhookSubscriptions(ctx context.Context, rows *sql.Rows) ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan webhook subscription", "error", err)
+			continue
+		}
+		subs = append(subs, *sub)
+	}
+
+	return subs, rows.Err()
+}
+
+func scanWebhookSubscription(row rowScanner) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	var events []byte
+	err := row.Scan(
+		&sub.ID,
+		&sub.URL,
+		&sub.Description,
+		&events,
+		&sub.Secret,
+		&sub.Active,
+		&sub.ConsecutiveFailures,
+		&sub.DisabledAt,
+		&sub.DisabledReason,
+		&sub.CreatedBy,
+		&sub.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(events, &sub.Events); err != nil {
+		return nil, fmt.Errorf("invalid events of webhook subscription %s: %w", sub.ID, err)
+	}
+	return &sub, nil
+}
+
+func scanWebhookDeliveries(ctx context.Context, rows *sql.Rows) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	for rows.Next() {
+		d, err := scanWebhookDelivery(rows)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to scan webhook delivery", "error", err)
+			continue
+		}
+		deliveries = append(deliveries, *d)
+	}
+
+	return deliveries, rows.Err()
+}
+
+func scanWebhookDelivery(row rowScanner) (*models.WebhookDelivery, error) {
+	var d models.WebhookDelivery
+	var payload []byte
+	err := row.Scan(
+		&d.ID,
+		&d.SubscriptionID,
+		&d.EventID,
+		&d.EventType,
+		&payload,
+		&d.Status,
+		&d.Attempts,
+		&d.NextAttemptAt,
+		&d.LastAttemptAt,
+		&d.ResponseStatus,
+		&d.ResponseBody,
+		&d.Error,
+		&d.RedeliveryOf,
+		&d.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	d.Payload = payload
+	return &d, nil
+}
@@ -0,0 +1,54 @@
+package scheduler
+
+import (
+	"context"
+	"database/sql"
+	"hash/fnv"
+	"log/slog"
+)
+
+// AdvisoryLocker takes Postgres session advisory locks keyed by job name. The lock is held
+// on a dedicated connection, so it is released even if the replica dies mid-run.
+type AdvisoryLocker struct {
+	db *sql.DB
+}
+
+func NewAdvisoryLocker(db *sql.DB) *AdvisoryLocker {
+	return &AdvisoryLocker{db: db}
+}
+
+func (l *AdvisoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	conn, err := l.db.Conn(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+
+	key := lockKey(name)
+
+	var ok bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&ok); err != nil {
+		conn.Close()
+		return nil, false, err
+	}
+	if !ok {
+		conn.Close()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		// The run context may already be cancelled; unlocking must still happen
+		if _, err := conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", key); err != nil {
+			slog.Error("failed to release advisory lock", "job", name, "error", err)
+		}
+		conn.Close()
+	}
+
+	return unlock, true, nil
+}
+
+// lockKey maps a job name to the bigint key space of pg advisory locks.
+func lockKey(name string) int64 {
+	h := fnv.New64a()
+	h.Write([]byte("scheduler:" + name))
+	return int64(h.Sum64())
+}
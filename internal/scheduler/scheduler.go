@@ -0,0 +1,168 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Job is a periodic background task. Run returns how many rows or entries it changed.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	// Local jobs work on in-process state, so every replica runs them and no lock is taken.
+	Local bool
+	Run   func(ctx context.Context) (int, error)
+}
+
+// History records job runs.
+type History interface {
+	Start(ctx context.Context, jobName, instanceID string) (int64, error)
+	Finish(ctx context.Context, id int64, items int, runErr error) error
+}
+
+// Locker makes sure only one replica runs a job at a time. TryLock returns ok false when
+// another replica holds the lock.
+type Locker interface {
+	TryLock(ctx context.Context, name string) (unlock func(), ok bool, err error)
+}
+
+// Scheduler runs registered jobs on their intervals until stopped.
+type Scheduler struct {
+	locker     Locker
+	history    History
+	instanceID string
+
+	mu      sync.Mutex
+	jobs    []Job
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	started bool
+}
+
+func New(locker Locker, history History) *Scheduler {
+	host, _ := os.Hostname()
+	return &Scheduler{
+		locker:     locker,
+		history:    history,
+		instanceID: fmt.Sprintf("%s-%d", host, os.Getpid()),
+	}
+}
+
+// Register adds a job. Jobs registered after Start are not run.
+func (s *Scheduler) Register(job Job) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs = append(s.jobs, job)
+}
+
+// Start runs every registered job once right away and then on its interval.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return
+	}
+	s.started = true
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+
+	for _, job := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(ctx, job)
+	}
+
+	slog.Info("scheduler started", "jobs", len(s.jobs))
+}
+
+// Stop cancels all jobs and waits for running ones to return, or until ctx is done.
+func (s *Scheduler) Stop(ctx context.Context) error {
+	s.mu.Lock()
+	if !s.started {
+		s.mu.Unlock()
+		return nil
+	}
+	s.cancel()
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		slog.Info("scheduler stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("scheduler did not stop in time: %w", ctx.Err())
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		s.runOnce(ctx, job)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (s *Scheduler) runOnce(ctx context.Context, job Job) {
+	if ctx.Err() != nil {
+		return
+	}
+
+	if !job.Local {
+		unlock, ok, err := s.locker.TryLock(ctx, job.Name)
+		if err != nil {
+			slog.ErrorContext(ctx, "job failed to acquire lock", "job", job.Name, "error", err)
+			return
+		}
+		if !ok {
+			return
+		}
+		defer unlock()
+	}
+
+	runID, err := s.history.Start(ctx, job.Name, s.instanceID)
+	if err != nil {
+		slog.ErrorContext(ctx, "job failed to record start", "job", job.Name, "error", err)
+	}
+
+	items, runErr := s.run(ctx, job)
+	if runErr != nil {
+		slog.ErrorContext(ctx, "job failed", "job", job.Name, "error", runErr)
+	}
+
+	if err == nil {
+		// Recorded even when shutdown cancelled the run
+		if err := s.history.Finish(context.WithoutCancel(ctx), runID, items, runErr); err != nil {
+			slog.ErrorContext(ctx, "job failed to record finish", "job", job.Name, "error", err)
+		}
+	}
+}
+
+// run calls the job and turns a panic into an error so one job cannot stop the scheduler.
+func (s *Scheduler) run(ctx context.Context, job Job) (items int, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	return job.Run(ctx)
+}
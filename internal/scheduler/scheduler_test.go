@@ -0,0 +1,98 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+type memoryHistory struct {
+	mu       sync.Mutex
+	started  []string
+	finished map[int64]error
+}
+
+func (h *memoryHistory) Start(_ context.Context, jobName, instanceID string) (int64, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.started = append(h.started, jobName)
+	return int64(len(h.started)), nil
+}
+
+func (h *memoryHistory) Finish(_ context.Context, id int64, items int, runErr error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.finished[id] = runErr
+	return nil
+}
+
+type fakeLocker struct {
+	held map[string]bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	if l.held[name] {
+		return nil, false, nil
+	}
+	return func() {}, true, nil
+}
+
+func TestSchedulerRunsJobsAndStops(t *testing.T) {
+	history := &memoryHistory{finished: map[int64]error{}}
+	locker := &fakeLocker{held: map[string]bool{"locked": true}}
+	s := New(locker, history)
+
+	ran := make(chan string, 10)
+	job := func(name string, err error) Job {
+		return Job{Name: name, Interval: time.Hour, Run: func(ctx context.Context) (int, error) {
+			ran <- name
+			if name == "panics" {
+				panic("boom")
+			}
+			return 1, err
+		}}
+	}
+
+	s.Register(job("ok", nil))
+	s.Register(job("fails", errors.New("failed")))
+	s.Register(job("panics", nil))
+	s.Register(job("locked", nil))
+	s.Start()
+
+	seen := map[string]bool{}
+	for len(seen) < 3 {
+		select {
+		case name := <-ran:
+			seen[name] = true
+		case <-time.After(2 * time.Second):
+			t.Fatalf("jobs did not run, saw %v", seen)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := s.Stop(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if seen["locked"] {
+		t.Fatal("job ran although another replica held its lock")
+	}
+
+	history.mu.Lock()
+	defer history.mu.Unlock()
+	if len(history.finished) != 3 {
+		t.Fatalf("expected 3 finished runs, got %d", len(history.finished))
+	}
+	failures := 0
+	for _, err := range history.finished {
+		if err != nil {
+			failures++
+		}
+	}
+	if failures != 2 {
+		t.Fatalf("expected 2 failed runs, got %d", failures)
+	}
+}
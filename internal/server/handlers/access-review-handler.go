@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"AuthServer/internal/domain/models"
+	domain "AuthServer/internal/service"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) CreateAccessReview(c *gin.Context) {
+	creatorID, _ := c.Get("user_id")
+
+	var input struct {
+		Name                 string    `json:"name" binding:"required,max=200"`
+		ScopeRole            *string   `json:"scope_role"`
+		ScopeProjectID       *string   `json:"scope_project_id"`
+		ReviewerID           *string   `json:"reviewer_id"`
+		DueAt                time.Time `json:"due_at" binding:"required"`
+		OverdueAction        string    `json:"overdue_action" binding:"omitempty,oneof=revoke escalate"`
+		EscalationReviewerID *string   `json:"escalation_reviewer_id"`
+		EscalationGraceHours int       `json:"escalation_grace_hours" binding:"omitempty,min=1"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	campaign := &models.AccessReviewCampaign{
+		Name:                 input.Name,
+		ScopeRole:            input.ScopeRole,
+		ScopeProjectID:       input.ScopeProjectID,
+		ReviewerID:           input.ReviewerID,
+		DueAt:                input.DueAt,
+		OverdueAction:        input.OverdueAction,
+		EscalationReviewerID: input.EscalationReviewerID,
+		EscalationGraceHours: input.EscalationGraceHours,
+		CreatedBy:            creatorID.(string),
+	}
+
+	count, err := s.accessReviewService.Launch(c.Request.Context(), campaign)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidCampaign) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to launch campaign"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "access review launched",
+		"data":    campaign,
+		"items":   count,
+	})
+}
+
+func (s *Server) GetAccessReviews(c *gin.Context) {
+	status := c.Query("status")
+	if status != "" && status != models.CampaignActive && status != models.CampaignCompleted {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be active or completed"})
+		return
+	}
+
+	campaigns, err := s.accessReviewService.ListCampaigns(c.Request.Context(), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve campaigns"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": campaigns})
+}
+
+func (s *Server) GetAccessReview(c *gin.Context) {
+	campaign, items, err := s.accessReviewService.GetCampaign(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    campaign,
+		"items":   items,
+		"summary": domain.SummarizeAccessReview(items),
+	})
+}
+
+// GetAccessReviewReport exports the campaign outcome as JSON (default) or CSV.
+func (s *Server) GetAccessReviewReport(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "format must be json or csv"})
+		return
+	}
+
+	campaign, items, err := s.accessReviewService.GetCampaign(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("access-review-%s.%s", campaign.ID, format)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	if format == "csv" {
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		c.Status(http.StatusOK)
+		if err := domain.WriteAccessReviewCSV(c.Writer, items); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"campaign":     campaign,
+		"summary":      domain.SummarizeAccessReview(items),
+		"items":        items,
+		"generated_at": time.Now().UTC(),
+	})
+}
+
+func (s *Server) GetMyAccessReviewTasks(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	items, err := s.accessReviewService.ReviewerTasks(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve review tasks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": items})
+}
+
+func (s *Server) DecideAccessReviewItem(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	reviewerID := userID.(string)
+
+	var input struct {
+		Decision string  `json:"decision" binding:"required,oneof=keep revoke"`
+		Comment  *string `json:"comment"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := s.accessReviewService.Decide(c.Request.Context(), c.Param("itemId"), reviewerID, input.Decision, input.Comment, s.isAdmin(c, reviewerID))
+	if err != nil {
+		if errors.Is(err, domain.ErrNotReviewer) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "decision recorded"})
+}
+
+// ProcessOverdueAccessReviews applies the overdue action to unanswered items of campaigns past due.
+func (s *Server) ProcessOverdueAccessReviews(c *gin.Context) {
+	changed, err := s.accessReviewService.ProcessOverdue(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to process overdue reviews"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "overdue reviews processed",
+		"items":   changed,
+	})
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"AuthServer/internal/domain/models"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// audit records an action taken in this request. The actor defaults to the authenticated
+// user, and the client IP, user agent and request ID are filled in. A failure to write the
+// event is logged by the audit service and never fails the request.
+func (s *Server) audit(c *gin.Context, e models.AuditEvent) {
+	if e.ActorID == nil {
+		if userID := c.GetString("user_id"); userID != "" {
+			e.ActorID = &userID
+		}
+	}
+	e.IP = c.ClientIP()
+	e.UserAgent = c.Request.UserAgent()
+	e.RequestID = c.GetString("request_id")
+
+	s.auditService.Record(c.Request.Context(), &e)
+}
+
+// auditFailure records an action that was rejected or failed, with err as the reason.
+func (s *Server) auditFailure(c *gin.Context, e models.AuditEvent, err error) {
+	reason := err.Error()
+	e.Outcome = models.AuditFailure
+	e.Reason = &reason
+	s.audit(c, e)
+}
+
+// GetAuditEvents pages through the audit log, newest first. Filters: actor_id, action (exact
+// or a prefix ending in "."), target_type, target_id, outcome, and from/to as RFC 3339 times.
+func (s *Server) GetAuditEvents(c *gin.Context) {
+	filter := models.AuditFilter{
+		ActorID:    c.Query("actor_id"),
+		Action:     c.Query("action"),
+		TargetType: c.Query("target_type"),
+		TargetID:   c.Query("target_id"),
+		Outcome:    c.Query("outcome"),
+	}
+
+	var err error
+	if filter.Limit, err = strconv.Atoi(c.DefaultQuery("limit", "50")); err != nil || filter.Limit < 1 || filter.Limit > 500 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 500"})
+		return
+	}
+	if filter.Offset, err = strconv.Atoi(c.DefaultQuery("offset", "0")); err != nil || filter.Offset < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+		return
+	}
+
+	for param, t := range map[string]*time.Time{"from": &filter.From, "to": &filter.To} {
+		raw := c.Query(param)
+		if raw == "" {
+			continue
+		}
+		if *t, err = time.Parse(time.RFC3339, raw); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": param + " must be an RFC 3339 time"})
+			return
+		}
+	}
+
+	events, total, err := s.auditService.List(c.Request.Context(), filter)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve audit events"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":   events,
+		"total":  total,
+		"limit":  filter.Limit,
+		"offset": filter.Offset,
+	})
+}
+
+// GetAuditCheckpoints exports every signed checkpoint, oldest first, so that they can be kept
+// outside the database and later passed to authctl audit verify.
+func (s *Server) GetAuditCheckpoints(c *gin.Context) {
+	checkpoints, err := s.auditService.Checkpoints(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve audit checkpoints"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": checkpoints})
+}
+
+// CreateAuditCheckpoint signs the current chain head without waiting for the scheduled job.
+func (s *Server) CreateAuditCheckpoint(c *gin.Context) {
+	cp, err := s.auditService.Checkpoint(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create audit checkpoint"})
+		return
+	}
+	if cp == nil {
+		c.JSON(http.StatusOK, gin.H{"message": "no audit events since the last checkpoint"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"data": cp})
+}
+
+// VerifyAuditChain walks the audit chain and reports the first broken link, if any.
+func (s *Server) VerifyAuditChain(c *gin.Context) {
+	result, err := s.auditService.Verify(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to verify audit chain"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": result})
+}
@@ -3,91 +3,86 @@ package handlers
 import (
 	"AuthServer/internal/domain/dto"
 	"AuthServer/internal/domain/models"
+	"AuthServer/internal/events"
+	"AuthServer/internal/metrics"
+	domain "AuthServer/internal/service"
 	"context"
 	"encoding/base64"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/rand"
 	"net/http"
-	"os"
-	"regexp"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
-	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
-	"google.golang.org/api/gmail/v1"
-	"google.golang.org/api/option"
 )
 
-var verificationCodes = make(map[string]VerificationData)
-
 type VerificationData struct {
 	Code      string
 	UserID    string
 	ExpiresAt time.Time
 }
 
-func generateVerificationCode() string {
-	rand.Seed(time.Now().UnixNano())
-	return fmt.Sprintf("%06d", rand.Intn(1000000))
+// verificationStore holds pending verification codes keyed by email. Handlers and the
+// scheduler's purge job access it concurrently.
+type verificationStore struct {
+	mu    sync.Mutex
+	codes map[string]VerificationData
 }
 
-func getGmailService() (*gmail.Service, error) {
-	ctx := context.Background()
-
-	b, err := os.ReadFile("credentials.json")
-	if err != nil {
-		return nil, fmt.Errorf("unable to read credentials.json: %v", err)
-	}
-
-	config, err := google.ConfigFromJSON(b, gmail.GmailSendScope)
-	if err != nil {
-		return nil, fmt.Errorf("unable to parse credentials: %v", err)
-	}
+func (v *verificationStore) Set(email string, data VerificationData) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.codes[email] = data
+}
 
-	client, err := getClient(config)
-	if err != nil {
-		return nil, fmt.Errorf("unable to get client: %v", err)
-	}
+func (v *verificationStore) Get(email string) (VerificationData, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	data, ok := v.codes[email]
+	return data, ok
+}
 
-	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
-	if err != nil {
-		return nil, fmt.Errorf("unable to create Gmail service: %v", err)
+func (v *verificationStore) FindByCode(code string) (string, VerificationData, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for email, data := range v.codes {
+		if data.Code == code {
+			return email, data, true
+		}
 	}
-
-	return srv, nil
+	return "", VerificationData{}, false
 }
 
-func getClient(config *oauth2.Config) (*http.Client, error) {
-	tokFile := "token.json"
-	tok, err := tokenFromFile(tokFile)
-	if err != nil {
-		return nil, fmt.Errorf("token not found - run OAuth setup first: %v", err)
-	}
-	return config.Client(context.Background(), tok), nil
+func (v *verificationStore) Delete(email string) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	delete(v.codes, email)
 }
 
-func tokenFromFile(file string) (*oauth2.Token, error) {
-	f, err := os.Open(file)
-	if err != nil {
-		return nil, err
+// PurgeExpired removes expired codes and returns how many were removed.
+func (v *verificationStore) PurgeExpired() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	now := time.Now()
+	purged := 0
+	for email, data := range v.codes {
+		if now.After(data.ExpiresAt) {
+			delete(v.codes, email)
+			purged++
+		}
 	}
-	defer f.Close()
-	tok := &oauth2.Token{}
-	err = json.NewDecoder(f).Decode(tok)
-	return tok, err
+	return purged
 }
 
-func sendVerificationEmail(toEmail, code, userName string) error {
-	srv, err := getGmailService()
-	if err != nil {
-		return fmt.Errorf("failed to get Gmail service: %v", err)
-	}
+func generateVerificationCode() string {
+	rand.Seed(time.Now().UnixNano())
+	return fmt.Sprintf("%06d", rand.Intn(1000000))
+}
 
-	fromEmail := os.Getenv("SENDER_EMAIL")
+func (s *Server) sendVerificationEmail(ctx context.Context, toEmail, code, userName string) error {
 	subject := "Email Verification Code"
 	body := fmt.Sprintf(`
 
@@ -115,10 +110,10 @@ func sendVerificationEmail(toEmail, code, userName string) error {
 						<div class="code">%s</div>
 						<p style="text-align: center; margin-top: 20px;">Or click the button below to verify:</p>
 						<div style="text-align: center;">
-							<a href="http://localhost:3000/verify?code=%s" class="button">Verify Email</a>
+							<a href="%s?code=%s" class="button">Verify Email</a>
 						</div>
 						<p style="margin-top: 30px; font-size: 14px; color: #666;">
-							This code will expire in 15 minutes. If you didn't register for this account, please ignore this email.
+							This code will expire in %d minutes. If you didn't register for this account, please ignore this email.
 						</p>
 					</div>
 					<div class="footer">
@@ -127,71 +122,92 @@ func sendVerificationEmail(toEmail, code, userName string) error {
 				</div>
 			</body>
 			</html>
-		`, userName, code, code)
-
-	message := fmt.Sprintf("From: %s\r\n"+
-		"To: %s\r\n"+
-		"Subject: %s\r\n"+
-		"MIME-Version: 1.0\r\n"+
-		"Content-Type: text/html; charset=UTF-8\r\n"+
-		"\r\n"+
-		"%s",
-		fromEmail, toEmail, subject, body)
+		`, userName, code, s.verificationURL, code, int(s.verificationCodeTTL.Minutes()))
 
-	encoded := base64.URLEncoding.EncodeToString([]byte(message))
-	gmailMessage := &gmail.Message{Raw: encoded}
+	return s.mailService.Send(ctx, []string{toEmail}, subject, body)
+}
 
-	_, err = srv.Users.Messages.Send("me", gmailMessage).Do()
+// auditAuth records an authentication step for userID, which is empty when the user is
+// unknown. Nobody is signed in yet, so a successful step records the user as the actor and
+// a failed one records no actor.
+func (s *Server) auditAuth(c *gin.Context, action, userID string, after any, err error) {
+	event := models.AuditEvent{
+		Action:     action,
+		TargetType: models.AuditTargetUser,
+		TargetID:   userID,
+		After:      domain.AuditState(after),
+	}
 	if err != nil {
-		return fmt.Errorf("failed to send email: %v", err)
+		s.auditFailure(c, event, err)
+		return
 	}
+	event.ActorID = &userID
+	s.audit(c, event)
+}
 
-	return nil
+// checkNotDisabled refuses to finish a login for a user disabled after the code was sent.
+// It writes the response and reports false when the login must stop.
+func (s *Server) checkNotDisabled(c *gin.Context, userID string) bool {
+	user, err := s.userRepo.FindById(c.Request.Context(), userID)
+	if err != nil {
+		s.auditAuth(c, models.AuditLoginVerify, userID, nil, errors.New("unknown user"))
+		s.metrics.VerificationCodeRedeemed(metrics.CodeRejected)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not found"})
+		return false
+	}
+	if user.DisabledAt != nil {
+		s.auditAuth(c, models.AuditLoginVerify, userID, nil, domain.ErrUserDisabled)
+		s.metrics.VerificationCodeRedeemed(metrics.CodeRejected)
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return false
+	}
+	return true
+}
+
+// markEmailVerified records that the user redeemed a code sent to their email. A failure
+// only costs conditions on user.email_verified, so the login goes on.
+func (s *Server) markEmailVerified(c *gin.Context, userID string) {
+	if err := s.userService.MarkEmailVerified(c.Request.Context(), userID); err != nil {
+		slog.ErrorContext(c.Request.Context(), "failed to mark email verified", "user_id", userID, "error", err)
+	}
 }
 
 func (s *Server) Register(c *gin.Context) {
 	var registerData dto.RegisterDto
 
 	if err := c.ShouldBindJSON(&registerData); err != nil {
-		log.Println("JSON binding error:", err)
+		slog.DebugContext(c.Request.Context(), "invalid register request", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
 		return
 	}
 
-	log.Println("Received encoded data:", registerData)
-
 	// Decode base64 fields
 	decodedFullName, err := base64.StdEncoding.DecodeString(registerData.FullName)
 	if err != nil {
-		log.Println("Full name decoding error:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid full_name encoding"})
 		return
 	}
 
 	decodedUsername, err := base64.StdEncoding.DecodeString(registerData.Username)
 	if err != nil {
-		log.Println("Username decoding error:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid username encoding"})
 		return
 	}
 
 	decodedEmail, err := base64.StdEncoding.DecodeString(registerData.Email)
 	if err != nil {
-		log.Println("Email decoding error:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email encoding"})
 		return
 	}
 
 	decodedPassword, err := base64.StdEncoding.DecodeString(registerData.Password)
 	if err != nil {
-		log.Println("Password decoding error:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid password encoding"})
 		return
 	}
 
 	decodedConfirmedPassword, err := base64.StdEncoding.DecodeString(registerData.ConfirmedPassword)
 	if err != nil {
-		log.Println("Confirmed password decoding error:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid confirmed_password encoding"})
 		return
 	}
@@ -202,54 +218,46 @@ func (s *Server) Register(c *gin.Context) {
 	registerData.Password = string(decodedPassword)
 	registerData.ConfirmedPassword = string(decodedConfirmedPassword)
 
-	emailRegex := regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
-	if !emailRegex.MatchString(registerData.Email) {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email format"})
-		return
-	}
-
-	log.Println("Decoded data - Username:", registerData.Username, "Email:", registerData.Email)
-
 	if registerData.Password != registerData.ConfirmedPassword {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Passwords do not match"})
 		return
 	}
 
-	hashedPassword, err := hashService.HashPassword(registerData.Password)
-	if err != nil {
-		log.Println("Password hashing error:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to process password"})
+	user, err := s.userService.Create(c.Request.Context(), registerData.FullName, registerData.Username, registerData.Email, registerData.Password)
+	if errors.Is(err, domain.ErrInvalidUser) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-
-	var user = models.User{
-		ID:        uuid.New().String(),
-		FullName:  registerData.FullName,
-		Username:  registerData.Username,
-		Email:     registerData.Email,
-		Password:  hashedPassword,
-		CreatedAt: time.Now(),
-	}
-
-	err = userRepo.Save(user)
 	if err != nil {
-		log.Printf("Error saving user: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to create user", "error", err)
+		s.auditAuth(c, models.AuditRegister, "", gin.H{"username": registerData.Username}, errors.New("failed to create user"))
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
+	s.auditAuth(c, models.AuditRegister, user.ID, gin.H{
+		"full_name": user.FullName,
+		"username":  user.Username,
+		"email":     user.Email,
+	}, nil)
+	s.eventBus.Publish(c.Request.Context(), events.UserRegistered, user.ID, gin.H{
+		"user_id":  user.ID,
+		"username": user.Username,
+		"email":    user.Email,
+	})
+
 	verificationCode := generateVerificationCode()
 
-	verificationCodes[user.Email] = VerificationData{
+	s.verificationCodes.Set(user.Email, VerificationData{
 		Code:      verificationCode,
 		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(15 * time.Minute),
-	}
+		ExpiresAt: time.Now().Add(s.verificationCodeTTL),
+	})
+	s.metrics.VerificationCodeIssued("register")
 
-	err = sendVerificationEmail(user.Email, verificationCode, user.FullName)
+	err = s.sendVerificationEmail(c.Request.Context(), user.Email, verificationCode, user.FullName)
 	if err != nil {
-		log.Printf("Error sending verification email: %v", err)
-		log.Println("Warning: User registered but verification email failed to send")
+		slog.WarnContext(c.Request.Context(), "user registered but the verification email failed", "user_id", user.ID, "error", err)
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -277,33 +285,32 @@ func (s *Server) VerificationCode(c *gin.Context) {
 		return
 	}
 
-	var foundEmail string
-	var foundData VerificationData
-	var found bool
-
-	for email, data := range verificationCodes {
-		if data.Code == code {
-			foundEmail = email
-			foundData = data
-			found = true
-			break
-		}
-	}
+	foundEmail, foundData, found := s.verificationCodes.FindByCode(code)
 
 	if !found {
+		s.auditAuth(c, models.AuditLoginVerify, "", nil, errors.New("invalid verification code"))
+		s.metrics.VerificationCodeRedeemed(metrics.CodeInvalid)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
 		return
 	}
 
 	if time.Now().After(foundData.ExpiresAt) {
-		delete(verificationCodes, foundEmail)
+		s.verificationCodes.Delete(foundEmail)
+		s.auditAuth(c, models.AuditLoginVerify, foundData.UserID, nil, errors.New("verification code expired"))
+		s.metrics.VerificationCodeRedeemed(metrics.CodeExpired)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification code has expired"})
 		return
 	}
 
-	delete(verificationCodes, foundEmail)
+	s.verificationCodes.Delete(foundEmail)
+	if !s.checkNotDisabled(c, foundData.UserID) {
+		return
+	}
+	s.markEmailVerified(c, foundData.UserID)
+	s.auditAuth(c, models.AuditLoginVerify, foundData.UserID, nil, nil)
+	s.metrics.VerificationCodeRedeemed(metrics.CodeRedeemed)
 
-	accessToken := tokenService.GenerateAccessToken(foundData.UserID)
+	accessToken := s.tokenService.GenerateAccessToken(foundData.UserID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Email verified successfully",
@@ -322,28 +329,41 @@ func (s *Server) VerifyEmail(c *gin.Context) {
 		return
 	}
 
-	verificationData, exists := verificationCodes[verifyData.Email]
+	verificationData, exists := s.verificationCodes.Get(verifyData.Email)
 	if !exists {
+		s.auditAuth(c, models.AuditLoginVerify, "", nil, errors.New("no verification code for email"))
+		s.metrics.VerificationCodeRedeemed(metrics.CodeInvalid)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "No verification code found for this email"})
 		return
 	}
 
 	if time.Now().After(verificationData.ExpiresAt) {
-		delete(verificationCodes, verifyData.Email)
+		s.verificationCodes.Delete(verifyData.Email)
+		s.auditAuth(c, models.AuditLoginVerify, verificationData.UserID, nil, errors.New("verification code expired"))
+		s.metrics.VerificationCodeRedeemed(metrics.CodeExpired)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Verification code has expired"})
 		return
 	}
 
 	if verificationData.Code != verifyData.Code {
+		s.auditAuth(c, models.AuditLoginVerify, verificationData.UserID, nil, errors.New("invalid verification code"))
+		s.metrics.VerificationCodeRedeemed(metrics.CodeInvalid)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid verification code"})
 		return
 	}
 
-	delete(verificationCodes, verifyData.Email)
+	s.verificationCodes.Delete(verifyData.Email)
+	if !s.checkNotDisabled(c, verificationData.UserID) {
+		return
+	}
+	s.markEmailVerified(c, verificationData.UserID)
+	s.auditAuth(c, models.AuditLoginVerify, verificationData.UserID, nil, nil)
+	s.metrics.VerificationCodeRedeemed(metrics.CodeRedeemed)
+	s.eventBus.Publish(c.Request.Context(), events.UserVerified, verificationData.UserID, gin.H{"user_id": verificationData.UserID, "email": verifyData.Email})
 
 	c.JSON(http.StatusOK, gin.H{
 		"message":      "Email verified successfully",
-		"access_token": tokenService.GenerateAccessToken(verificationData.UserID),
+		"access_token": s.tokenService.GenerateAccessToken(verificationData.UserID),
 	})
 }
 
@@ -357,26 +377,30 @@ func (s *Server) ResendVerificationCode(c *gin.Context) {
 		return
 	}
 
-	user, err := userRepo.FindByEmailOrUsername(resendData.Email)
+	user, err := s.userRepo.FindByEmailOrUsername(c.Request.Context(), resendData.Email)
 	if err != nil {
+		s.auditAuth(c, models.AuditVerificationResend, "", nil, errors.New("unknown user"))
 		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 		return
 	}
 
 	verificationCode := generateVerificationCode()
-	verificationCodes[user.Email] = VerificationData{
+	s.verificationCodes.Set(user.Email, VerificationData{
 		Code:      verificationCode,
 		UserID:    user.ID,
-		ExpiresAt: time.Now().Add(15 * time.Minute),
-	}
+		ExpiresAt: time.Now().Add(s.verificationCodeTTL),
+	})
+	s.metrics.VerificationCodeIssued("resend")
 
-	err = sendVerificationEmail(user.Email, verificationCode, user.FullName)
+	err = s.sendVerificationEmail(c.Request.Context(), user.Email, verificationCode, user.FullName)
 	if err != nil {
-		log.Printf("Error sending verification email: %v", err)
+		slog.ErrorContext(c.Request.Context(), "failed to send verification email", "user_id", user.ID, "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
 		return
 	}
 
+	s.auditAuth(c, models.AuditVerificationResend, user.ID, nil, nil)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Verification code sent successfully",
 	})
@@ -392,14 +416,12 @@ func (s *Server) Login(c *gin.Context) {
 
 	identifier, err := base64.StdEncoding.DecodeString(loginData.Identifier)
 	if err != nil {
-		log.Println("Username or email decoding error:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid username_or_email encoding"})
 		return
 	}
 
 	decodedPassword, err := base64.StdEncoding.DecodeString(loginData.Password)
 	if err != nil {
-		log.Println("Password decoding error:", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid password encoding"})
 		return
 	}
@@ -407,9 +429,10 @@ func (s *Server) Login(c *gin.Context) {
 	loginData.Identifier = string(identifier)
 	loginData.Password = string(decodedPassword)
 
-	existingUser, err := userRepo.FindByEmailOrUsername(loginData.Identifier)
+	existingUser, err := s.userRepo.FindByEmailOrUsername(c.Request.Context(), loginData.Identifier)
 	if err != nil {
-		log.Printf("User not found: %v", err)
+		s.auditAuth(c, models.AuditLogin, "", nil, errors.New("unknown user"))
+		s.metrics.LoginAttempt(metrics.LoginUnknownUser)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Wrong credentials"})
 		return
 	}
@@ -417,18 +440,28 @@ func (s *Server) Login(c *gin.Context) {
 	storedPassword := existingUser.Password
 	loginPassword := loginData.Password
 
-	if hashService.VerifyPassword(loginPassword, storedPassword) {
+	if s.hashService.VerifyPassword(c.Request.Context(), loginPassword, storedPassword) {
+		if existingUser.DisabledAt != nil {
+			s.auditAuth(c, models.AuditLogin, existingUser.ID, nil, domain.ErrUserDisabled)
+			s.metrics.LoginAttempt(metrics.LoginDisabled)
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+			return
+		}
+		s.auditAuth(c, models.AuditLogin, existingUser.ID, nil, nil)
+		s.metrics.LoginAttempt(metrics.LoginSuccess)
+
 		verificationCode := generateVerificationCode()
 
-		verificationCodes[existingUser.Email] = VerificationData{
+		s.verificationCodes.Set(existingUser.Email, VerificationData{
 			Code:      verificationCode,
 			UserID:    existingUser.ID,
-			ExpiresAt: time.Now().Add(15 * time.Minute),
-		}
+			ExpiresAt: time.Now().Add(s.verificationCodeTTL),
+		})
+		s.metrics.VerificationCodeIssued("login")
 
-		err = sendVerificationEmail(existingUser.Email, verificationCode, existingUser.FullName)
+		err = s.sendVerificationEmail(c.Request.Context(), existingUser.Email, verificationCode, existingUser.FullName)
 		if err != nil {
-			log.Printf("Error sending verification email: %v", err)
+			slog.ErrorContext(c.Request.Context(), "failed to send verification email", "user_id", existingUser.ID, "error", err)
 			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
 			return
 		}
@@ -440,7 +473,8 @@ func (s *Server) Login(c *gin.Context) {
 		})
 		return
 	} else {
-		log.Println("Password does not match")
+		s.auditAuth(c, models.AuditLogin, existingUser.ID, nil, errors.New("wrong password"))
+		s.metrics.LoginAttempt(metrics.LoginWrongPassword)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Wrong credentials",
 		})
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/middleware"
+	domain "AuthServer/internal/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type authzCheckInput struct {
+	UserID     string     `json:"user_id"` // defaults to the caller
+	Role       roles.Role `json:"role" binding:"required"`
+	ResourceID *string    `json:"resource_id"`
+	IP         string     `json:"ip"`   // defaults to the caller's IP
+	Time       *time.Time `json:"time"` // defaults to now
+}
+
+func (in authzCheckInput) accessContext(c *gin.Context) roles.AccessContext {
+	ac := middleware.AccessContext(c)
+	if in.IP != "" {
+		ac.IP = in.IP
+	}
+	if in.Time != nil {
+		ac.Time = *in.Time
+	}
+	return ac
+}
+
+// isAdmin reports whether the caller holds the global admin role.
+func (s *Server) isAdmin(c *gin.Context, userID string) bool {
+	ok, err := s.rbacService.HasPermission(c.Request.Context(), userID, roles.RoleAdmin, nil, middleware.AccessContext(c))
+	return err == nil && ok
+}
+
+// isAdminOrManager reports whether the caller holds a global admin or manager role.
+func (s *Server) isAdminOrManager(c *gin.Context, userID string) bool {
+	ac := middleware.AccessContext(c)
+	for _, role := range []roles.Role{roles.RoleAdmin, roles.RoleManager} {
+		if ok, err := s.rbacService.HasPermission(c.Request.Context(), userID, role, nil, ac); err == nil && ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ExplainPermission returns the evaluation trace of a permission check. Users may explain
+// their own checks; explaining another user's requires admin or manager.
+func (s *Server) ExplainPermission(c *gin.Context) {
+	callerID, _ := c.Get("user_id")
+
+	var input authzCheckInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.UserID == "" {
+		input.UserID = callerID.(string)
+	}
+
+	if input.UserID != callerID.(string) && !s.isAdminOrManager(c, callerID.(string)) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "insufficient permissions to explain another user's access"})
+		return
+	}
+
+	decision, err := s.rbacService.Explain(c.Request.Context(), input.UserID, input.Role, input.ResourceID, input.accessContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to evaluate permission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": decision})
+}
+
+// SimulatePermission evaluates a permission check with proposed grants and revokes applied,
+// without committing them.
+func (s *Server) SimulatePermission(c *gin.Context) {
+	var input struct {
+		authzCheckInput
+		Changes []roles.SimulatedChange `json:"changes" binding:"required,min=1,dive"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if input.UserID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "user_id is required"})
+		return
+	}
+
+	simulation, err := s.rbacService.Simulate(c.Request.Context(), input.UserID, input.Role, input.ResourceID, input.accessContext(c), input.Changes)
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidSimulation) || errors.Is(err, domain.ErrInvalidCondition) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to simulate permission"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": simulation})
+}
@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"AuthServer/internal/domain/roles"
+	domain "AuthServer/internal/service"
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func respondBreakGlassError(c *gin.Context, err error) {
+	if respondSoDViolation(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrBreakGlassNotAllowed), errors.Is(err, domain.ErrBreakGlassSelfReview),
+		errors.Is(err, domain.ErrNotRequestOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrBreakGlassDisabled):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrBreakGlassActive):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+func (s *Server) ActivateBreakGlass(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var input struct {
+		Justification string `json:"justification" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := s.breakGlassService.Activate(c.Request.Context(), userID.(string), input.Justification)
+	if err != nil {
+		if errors.Is(err, domain.ErrJustificationRequired) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":                    err.Error(),
+				"min_justification_length": s.breakGlassService.Config().MinJustification,
+			})
+			return
+		}
+		respondBreakGlassError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "break-glass access granted; this session will be reviewed",
+		"data":    session,
+	})
+}
+
+func (s *Server) GetMyBreakGlassSession(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	session, err := s.breakGlassService.ActiveSession(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve session"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": session})
+}
+
+func (s *Server) EndBreakGlassSession(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+	actorID := userID.(string)
+
+	if err := s.breakGlassService.End(c.Request.Context(), c.Param("id"), actorID, s.isAdmin(c, actorID)); err != nil {
+		respondBreakGlassError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "break-glass session ended"})
+}
+
+func (s *Server) GetBreakGlassSessions(c *gin.Context) {
+	status := c.Query("review_status")
+	if status != "" && status != roles.BreakGlassReviewOpen && status != roles.BreakGlassReviewClosed {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "review_status must be open or closed"})
+		return
+	}
+
+	sessions, err := s.breakGlassService.ListSessions(c.Request.Context(), status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve sessions"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sessions})
+}
+
+func (s *Server) GetBreakGlassSession(c *gin.Context) {
+	session, accessLog, err := s.breakGlassService.GetSession(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":       session,
+		"access_log": accessLog,
+	})
+}
+
+func (s *Server) ReviewBreakGlassSession(c *gin.Context) {
+	reviewerID, _ := c.Get("user_id")
+
+	var input struct {
+		Notes string `json:"notes" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.breakGlassService.CloseReview(c.Request.Context(), c.Param("id"), reviewerID.(string), input.Notes); err != nil {
+		respondBreakGlassError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "break-glass review closed"})
+}
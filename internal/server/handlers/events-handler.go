@@ -0,0 +1,132 @@
+package handlers
+
+import (
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/events"
+	"AuthServer/internal/middleware"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sseHeartbeat is how often an idle stream sends a comment, which keeps proxies from closing
+// the connection. The caller's roles are re-checked at the same interval.
+const sseHeartbeat = 15 * time.Second
+
+// StreamEvents streams bus events as server-sent events. Admins see every event, managers
+// see role and JIT events, and everyone sees the events about themselves. A client that
+// reconnects with Last-Event-ID receives the events it missed; if some are no longer in the
+// history it gets a "reset" event and should reload its state. The stream ends when the
+// access token expires, and with no event when the client falls too far behind.
+func (s *Server) StreamEvents(c *gin.Context) {
+	userID := c.GetString("user_id")
+	ctx := c.Request.Context()
+
+	lastID, err := parseLastEventID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Last-Event-ID must be an event id"})
+		return
+	}
+
+	level := s.streamLevel(c, userID)
+	sub, replay, complete := s.eventBus.Subscribe(lastID)
+	defer sub.Close()
+
+	expiry := time.NewTimer(time.Until(s.tokenExpiry(c)))
+	defer expiry.Stop()
+	heartbeat := time.NewTicker(sseHeartbeat)
+	defer heartbeat.Stop()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprint(w, "retry: 5000\n\n")
+	if !complete {
+		fmt.Fprint(w, "event: reset\ndata: {}\n\n")
+	}
+	for _, e := range replay {
+		if e.VisibleTo(userID, level) {
+			writeSSE(w, e)
+		}
+	}
+	w.Flush()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e, ok := <-sub.C:
+			if !ok {
+				return
+			}
+			if e.VisibleTo(userID, level) {
+				writeSSE(w, e)
+				w.Flush()
+			}
+		case <-heartbeat.C:
+			level = s.streamLevel(c, userID)
+			fmt.Fprint(w, ": ping\n\n")
+			w.Flush()
+		case <-expiry.C:
+			fmt.Fprint(w, "event: token-expired\ndata: {}\n\n")
+			w.Flush()
+			return
+		}
+	}
+}
+
+func writeSSE(w io.Writer, e events.Event) {
+	data, err := json.Marshal(e)
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+}
+
+// parseLastEventID reads the Last-Event-ID header the browser sends on reconnect, or the
+// last_event_id query parameter for a client that opens a new EventSource.
+func parseLastEventID(c *gin.Context) (uint64, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseUint(raw, 10, 64)
+}
+
+// streamLevel returns the hierarchy level of the caller's highest global role, which decides
+// the events they may see.
+func (s *Server) streamLevel(c *gin.Context, userID string) int {
+	ac := middleware.AccessContext(c)
+	for _, role := range []roles.Role{roles.RoleAdmin, roles.RoleManager} {
+		if ok, err := s.rbacService.HasPermission(c.Request.Context(), userID, role, nil, ac); err == nil && ok {
+			return roles.RoleHierarchy[role]
+		}
+	}
+	return roles.RoleHierarchy[roles.RoleUser]
+}
+
+// tokenExpiry returns when the caller's access token expires.
+func (s *Server) tokenExpiry(c *gin.Context) time.Time {
+	claims, err := s.tokenService.DecodeAccessToken(strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer "))
+	if err != nil {
+		return time.Now()
+	}
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return time.Now()
+	}
+	return time.Unix(int64(exp), 0)
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/domain/roles"
+	domain "AuthServer/internal/service"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) CreateGroup(c *gin.Context) {
+	creatorID, _ := c.Get("user_id")
+
+	var input struct {
+		Name        string `json:"name" binding:"required,max=100"`
+		Description string `json:"description"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	group, err := s.groupService.Create(c.Request.Context(), input.Name, input.Description, creatorID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create group"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "group created",
+		"data":    group,
+	})
+}
+
+func (s *Server) GetGroups(c *gin.Context) {
+	groups, err := s.groupService.FindAll(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
+
+func (s *Server) GetGroup(c *gin.Context) {
+	groupID := c.Param("id")
+
+	group, err := s.groupService.FindById(c.Request.Context(), groupID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+		return
+	}
+
+	members, err := s.groupService.ListMembers(c.Request.Context(), groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve members"})
+		return
+	}
+
+	groupRoles, err := s.groupService.GetGroupRoles(c.Request.Context(), groupID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"data":    group,
+		"members": members,
+		"roles":   groupRoles,
+	})
+}
+
+func (s *Server) DeleteGroup(c *gin.Context) {
+	if err := s.groupService.Delete(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "group not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "group deleted"})
+}
+
+func (s *Server) AddGroupMember(c *gin.Context) {
+	adderID, _ := c.Get("user_id")
+	groupID := c.Param("id")
+
+	var input struct {
+		UserID  string `json:"user_id"`
+		GroupID string `json:"group_id"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if (input.UserID == "") == (input.GroupID == "") {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "exactly one of user_id or group_id is required"})
+		return
+	}
+
+	var err error
+	if input.UserID != "" {
+		err = s.groupService.AddUser(c.Request.Context(), groupID, input.UserID, adderID.(string))
+	} else {
+		err = s.groupService.AddGroup(c.Request.Context(), groupID, input.GroupID, adderID.(string))
+	}
+
+	if err != nil {
+		if respondSoDViolation(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrGroupCycle) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "member added"})
+}
+
+func (s *Server) RemoveGroupMember(c *gin.Context) {
+	memberType := c.Param("type")
+	if memberType != models.MemberTypeUser && memberType != models.MemberTypeGroup {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "member type must be user or group"})
+		return
+	}
+
+	if err := s.groupService.RemoveMember(c.Request.Context(), c.Param("id"), memberType, c.Param("memberId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "member not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "member removed"})
+}
+
+func (s *Server) AssignGroupRole(c *gin.Context) {
+	assignerID, _ := c.Get("user_id")
+	groupID := c.Param("id")
+
+	var input struct {
+		Role            roles.Role `json:"role" binding:"required"`
+		ResourceID      *string    `json:"resource_id"`
+		DurationMinutes int        `json:"duration_minutes" binding:"omitempty,min=1,max=1440"`
+		Condition       *string    `json:"condition"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var expiresAt *time.Time
+	if input.DurationMinutes > 0 {
+		t := time.Now().Add(time.Duration(input.DurationMinutes) * time.Minute)
+		expiresAt = &t
+	}
+
+	roleID, err := s.groupService.AssignRole(c.Request.Context(), groupID, input.Role, input.ResourceID, expiresAt, assignerID.(string), input.Condition)
+	if err != nil {
+		if respondSoDViolation(c, err) {
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":     "group role assigned",
+		"id":          roleID,
+		"group_id":    groupID,
+		"role":        input.Role,
+		"resource_id": input.ResourceID,
+		"expires_at":  expiresAt,
+	})
+}
+
+func (s *Server) RevokeGroupRole(c *gin.Context) {
+	if err := s.groupService.RevokeRole(c.Request.Context(), c.Param("id"), c.Param("roleId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "group role revoked"})
+}
+
+func (s *Server) GetMyGroups(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	groups, err := s.groupService.FindUserGroups(c.Request.Context(), userID.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve groups"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": groups})
+}
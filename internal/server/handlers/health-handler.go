@@ -1,11 +1,57 @@
 package handlers
 
 import (
+	"AuthServer/internal/health"
+	"context"
 	"net/http"
+	"time"
+
+	db "AuthServer/internal/database"
 
 	"github.com/gin-gonic/gin"
 )
 
-func (s *Server) healthHandler(c *gin.Context) {
-	c.JSON(http.StatusOK, s.Db.Health())
+// readinessTimeout bounds each readiness check, keeping /readyz inside the usual one to
+// five second probe timeouts.
+const readinessTimeout = 2 * time.Second
+
+// livez reports that the process is serving requests. It checks no dependency, so an outage
+// elsewhere never gets the server restarted.
+func (s *Server) livez(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": health.StatusUp})
+}
+
+// readyz reports whether the server should receive traffic: 503 when a critical dependency
+// is down. Error messages are left out, since the endpoint is public.
+func (s *Server) readyz(c *gin.Context) {
+	report := s.readiness(c.Request.Context())
+
+	status := http.StatusOK
+	if !report.Ready() {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report.WithoutErrors())
+}
+
+// GetHealth shows admins the full readiness report and the connection pool statistics.
+func (s *Server) GetHealth(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"readiness": s.readiness(c.Request.Context()),
+		"pool":      s.Db.PoolStats(),
+	})
+}
+
+// readiness checks the server's dependencies. Mail is not critical: without it logins stall
+// at the verification step, but tokens still verify and authorization checks still work.
+func (s *Server) readiness(ctx context.Context) health.Report {
+	return health.Run(ctx, readinessTimeout, []health.Check{
+		{Name: "database", Critical: true, Run: s.Db.Ping},
+		{Name: "migrations", Critical: true, Run: func(ctx context.Context) error {
+			return db.CheckMigrationVersion(ctx, s.Db.DB())
+		}},
+		{Name: "signing_keys", Critical: true, Run: func(context.Context) error {
+			return s.signingKeyService.Check()
+		}},
+		{Name: "mail", Run: s.mailService.Check},
+	})
 }
@@ -1,54 +1,152 @@
 package handlers
 
 import (
+	"AuthServer/internal/app"
 	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/events"
+	"AuthServer/internal/metrics"
 	"AuthServer/internal/middleware"
 	"AuthServer/internal/repository"
 	domain "AuthServer/internal/service"
+	"AuthServer/internal/tracing"
+	"log/slog"
 	"net/http"
+	"time"
 
 	db "AuthServer/internal/database"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel/trace"
 )
 
-var (
-	database db.Service = db.New()
+// Server holds the handlers' dependencies. Each Server is independent of the others, so
+// several can run in one process against different databases.
+type Server struct {
+	Port int
+	Db   db.Service
 
-	userRepo     = repository.NewUserRepository(database)
-	userRoleRepo = repository.NewUserRoleRepository(database)
-	projectRepo  = repository.NewProjectRepository(database)
-	jitRepo      = repository.NewJITRequestRepository(database)
+	userRepo       repository.IUserRepository
+	userRoleRepo   *repository.UserRoleRepository
+	projectRepo    repository.IProjectRepository
+	jitRepo        *repository.JITRequestRepository
+	approvalRepo   *repository.JITApprovalRepository
+	tupleRepo      *repository.RelationTupleRepository
+	groupRepo      *repository.GroupRepository
+	sodRepo        *repository.SoDViolationRepository
+	breakGlassRepo *repository.BreakGlassRepository
+	reviewRepo     *repository.AccessReviewRepository
+	jobRunRepo     *repository.JobRunRepository
+	auditRepo      *repository.AuditRepository
+	webhookRepo    *repository.WebhookRepository
 
-	tokenService   domain.ITokenService   = domain.NewTokenService()
-	hashService    domain.IHashService    = domain.NewHashService()
-	userService    domain.IUserService    = domain.NewUserService(userRepo)
-	projectService domain.IProjectService = domain.NewProjectService(projectRepo)
-	rbacService    *domain.RBACService    = domain.NewRBACService(userRoleRepo)
-	jitService     *domain.JITService     = domain.NewJITService(jitRepo, userRoleRepo)
-)
+	eventBus *events.Bus
+	metrics  *metrics.Metrics
+	tracer   trace.Tracer
 
-type Server struct {
-	Port     int
-	Db       db.Service
-	UserRepo repository.IUserRepository
+	tokenService         domain.ITokenService
+	hashService          domain.IHashService
+	userService          domain.IUserService
+	projectService       domain.IProjectService
+	sodService           *domain.SoDService
+	rbacService          *domain.RBACService
+	jitService           *domain.JITService
+	rebacService         *domain.ReBACService
+	groupService         *domain.GroupService
+	mailService          domain.IMailService
+	auditService         *domain.AuditService
+	webhookService       *domain.WebhookService
+	accessReviewService  *domain.AccessReviewService
+	breakGlassService    *domain.BreakGlassService
+	projectMemberService *domain.ProjectMemberService
+	signingKeyService    *domain.SigningKeyService
+
+	verificationCodes       *verificationStore
+	verificationCodeTTL     time.Duration
+	verificationURL         string
+	corsOrigins             []string
+	trustedProxies          []string
+	metricsToken            string
+	auditCheckpointInterval time.Duration
+}
+
+// NewServer returns a Server using the app's repositories and services.
+func NewServer(a *app.App) *Server {
+	r, svc := a.Repositories, a.Services
+	return &Server{
+		Port: a.Config.Server.Port,
+		Db:   a.DB,
+
+		userRepo:       r.Users,
+		userRoleRepo:   r.UserRoles,
+		projectRepo:    r.Projects,
+		jitRepo:        r.JITRequests,
+		approvalRepo:   r.JITApprovals,
+		tupleRepo:      r.Tuples,
+		groupRepo:      r.Groups,
+		sodRepo:        r.SoDViolations,
+		breakGlassRepo: r.BreakGlass,
+		reviewRepo:     r.AccessReviews,
+		jobRunRepo:     r.JobRuns,
+		auditRepo:      r.Audit,
+		webhookRepo:    r.Webhooks,
+
+		eventBus: a.Events,
+		metrics:  a.Metrics,
+		tracer:   a.Tracing.Tracer(tracing.Scope),
+
+		tokenService:         svc.Token,
+		hashService:          svc.Hash,
+		userService:          svc.User,
+		projectService:       svc.Project,
+		sodService:           svc.SoD,
+		rbacService:          svc.RBAC,
+		jitService:           svc.JIT,
+		rebacService:         svc.ReBAC,
+		groupService:         svc.Group,
+		mailService:          svc.Mail,
+		auditService:         svc.Audit,
+		webhookService:       svc.Webhook,
+		accessReviewService:  svc.AccessReview,
+		breakGlassService:    svc.BreakGlass,
+		projectMemberService: svc.ProjectMember,
+		signingKeyService:    svc.SigningKeys,
+
+		verificationCodes:       &verificationStore{codes: make(map[string]VerificationData)},
+		verificationCodeTTL:     a.Config.Verification.CodeTTL,
+		verificationURL:         a.Config.Verification.URL,
+		corsOrigins:             a.Config.Server.CORSOrigins,
+		trustedProxies:          a.Config.Server.TrustedProxies,
+		metricsToken:            a.Config.Server.MetricsToken,
+		auditCheckpointInterval: a.Config.Audit.CheckpointInterval,
+	}
 }
 
 func (s *Server) RegisterRoutes() http.Handler {
-	r := gin.Default()
+	r := gin.New()
+	// gin trusts X-Forwarded-For from every peer unless told otherwise
+	if err := r.SetTrustedProxies(s.trustedProxies); err != nil {
+		slog.Error("invalid trusted proxies, trusting none", "error", err)
+		r.SetTrustedProxies(nil)
+	}
+	r.Use(middleware.RequestID(), middleware.Tracing(s.tracer), middleware.RequestLog(), middleware.Metrics(s.metrics), gin.Recovery())
 
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:*"},
+		AllowOrigins:     s.corsOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Last-Event-ID", middleware.RequestIDHeader, "traceparent", "tracestate"},
+		ExposeHeaders:    []string{middleware.RequestIDHeader},
 		AllowCredentials: true,
 	}))
 
+	r.Use(middleware.BreakGlassAccessLog(s.breakGlassService))
+
 	// user interface
 	r.LoadHTMLGlob("ui/templates/*")
 
-	r.GET("/health", s.healthHandler)
+	r.GET("/livez", s.livez)
+	r.GET("/readyz", s.readyz)
+	r.GET("/metrics", s.metricsHandler())
 
 	r.GET("/home", func(c *gin.Context) {
 		c.HTML(200, "home.html", nil)
@@ -62,69 +160,335 @@ func (s *Server) RegisterRoutes() http.Handler {
 	// user
 	r.GET("/api/me", s.GetUserData)
 	r.GET("/api/me/roles",
-		middleware.RequireRole(rbacService, tokenService, roles.RoleUser, ""),
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
 		s.GetMyRoles,
 	)
+	r.GET("/api/me/groups",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.GetMyGroups,
+	)
 
 	// project
 	r.GET("/api/projects", s.GetAllProjects)
 	r.GET("/api/projects/:id", s.GetProjectById)
 	r.POST("/api/projects",
-		middleware.RequireRole(rbacService, tokenService, roles.RoleProjectEditor, ""),
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleProjectEditor, ""),
 		s.CreateProject,
 	)
 	r.PUT("/api/projects/:id",
-		middleware.RequireRole(rbacService, tokenService, roles.RoleProjectEditor, "id"),
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleProjectEditor, "id"),
 		s.UpdateProject,
 	)
 	r.DELETE("/api/projects/:id",
-		middleware.RequireRole(rbacService, tokenService, roles.RoleProjectEditor, "id"),
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleProjectEditor, "id"),
 		s.DeleteProject,
 	)
+	r.GET("/api/projects/:id/members",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager, roles.RoleProjectViewer}, "id"),
+		s.GetProjectMembers,
+	)
+	r.POST("/api/projects/:id/members",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager, roles.RoleProjectOwner}, "id"),
+		s.AddProjectMember,
+	)
+	r.DELETE("/api/projects/:id/members/:userId",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager, roles.RoleProjectOwner}, "id"),
+		s.RemoveProjectMember,
+	)
 
 	// role assignment (Admin/Manager only)
 	r.POST("/api/roles/global",
-		middleware.RequireAnyRole(rbacService, tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
 		s.AssignGlobalRole,
 	)
 	r.POST("/api/roles/resource",
-		middleware.RequireAnyRole(rbacService, tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
 		s.AssignResourceRole,
 	)
 	r.POST("/api/roles/temporary",
-		middleware.RequireAnyRole(rbacService, tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
 		s.AssignTemporaryRole,
 	)
 	r.GET("/api/users/:id/roles",
-		middleware.RequireAnyRole(rbacService, tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
 		s.GetUserRoles,
 	)
 	r.DELETE("/api/roles/:id",
-		middleware.RequireAnyRole(rbacService, tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
 		s.RevokeUserRole,
 	)
 
+	// groups (Admin/Manager only)
+	r.POST("/api/groups",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.CreateGroup,
+	)
+	r.GET("/api/groups",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.GetGroups,
+	)
+	r.GET("/api/groups/:id",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.GetGroup,
+	)
+	r.DELETE("/api/groups/:id",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.DeleteGroup,
+	)
+	r.POST("/api/groups/:id/members",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.AddGroupMember,
+	)
+	r.DELETE("/api/groups/:id/members/:type/:memberId",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.RemoveGroupMember,
+	)
+	r.POST("/api/groups/:id/roles",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.AssignGroupRole,
+	)
+	r.DELETE("/api/groups/:id/roles/:roleId",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.RevokeGroupRole,
+	)
+
 	// JIT requests
 	r.POST("/api/jit-requests",
-		middleware.RequireRole(rbacService, tokenService, roles.RoleUser, ""),
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
 		s.CreateJITRequest,
 	)
 	r.GET("/api/jit-requests/me",
-		middleware.RequireRole(rbacService, tokenService, roles.RoleUser, ""),
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
 		s.GetMyJITRequests,
 	)
 	r.GET("/api/jit-requests",
-		middleware.RequireAnyRole(rbacService, tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
 		s.GetJITRequests,
 	)
+	r.GET("/api/jit-requests/awaiting-me",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.GetJITRequestsAwaitingMe,
+	)
+	r.GET("/api/jit-requests/:id/approvals",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.GetJITApprovals,
+	)
+	// approver eligibility is decided per request by its approval policy
 	r.PATCH("/api/jit-requests/:id/approve",
-		middleware.RequireAnyRole(rbacService, tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
 		s.ApproveJITRequest,
 	)
 	r.PATCH("/api/jit-requests/:id/reject",
-		middleware.RequireAnyRole(rbacService, tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
 		s.RejectJITRequest,
 	)
+	r.PATCH("/api/jit-requests/:id/cancel",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.CancelJITRequest,
+	)
+	r.POST("/api/jit-requests/:id/extend",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.ExtendJITRequest,
+	)
+	r.PATCH("/api/jit-requests/:id/revoke",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.RevokeJITRequest,
+	)
+
+	// JIT approval policies
+	r.GET("/api/jit-policies",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.GetJITPolicies,
+	)
+	r.POST("/api/jit-policies",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.CreateJITPolicy,
+	)
+	r.DELETE("/api/jit-policies/:id",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.DeleteJITPolicy,
+	)
+
+	// break-glass emergency access
+	r.POST("/api/break-glass",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.ActivateBreakGlass,
+	)
+	r.GET("/api/break-glass/me",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.GetMyBreakGlassSession,
+	)
+	r.PATCH("/api/break-glass/sessions/:id/end",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.EndBreakGlassSession,
+	)
+	r.GET("/api/break-glass/sessions",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetBreakGlassSessions,
+	)
+	r.GET("/api/break-glass/sessions/:id",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetBreakGlassSession,
+	)
+	r.POST("/api/break-glass/sessions/:id/review",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.ReviewBreakGlassSession,
+	)
+
+	// access review campaigns
+	r.POST("/api/access-reviews",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.CreateAccessReview,
+	)
+	r.GET("/api/access-reviews",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.GetAccessReviews,
+	)
+	r.GET("/api/access-reviews/tasks/me",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.GetMyAccessReviewTasks,
+	)
+	r.POST("/api/access-reviews/process-overdue",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.ProcessOverdueAccessReviews,
+	)
+	r.POST("/api/access-reviews/items/:itemId/decision",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.DecideAccessReviewItem,
+	)
+	r.GET("/api/access-reviews/:id",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.GetAccessReview,
+	)
+	r.GET("/api/access-reviews/:id/report",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.GetAccessReviewReport,
+	)
+
+	// background jobs
+	r.GET("/api/jobs/runs",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetJobRuns,
+	)
+
+	// health details
+	r.GET("/api/health",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetHealth,
+	)
+
+	// audit log
+	r.GET("/api/audit/events",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetAuditEvents,
+	)
+	r.GET("/api/audit/checkpoints",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetAuditCheckpoints,
+	)
+	r.POST("/api/audit/checkpoints",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.CreateAuditCheckpoint,
+	)
+	r.GET("/api/audit/verify",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.VerifyAuditChain,
+	)
+
+	// real-time events
+	r.GET("/api/events/stream",
+		middleware.TokenFromQuery("access_token"),
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.StreamEvents,
+	)
+
+	// webhooks
+	r.GET("/api/webhooks",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetWebhooks,
+	)
+	r.POST("/api/webhooks",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.CreateWebhook,
+	)
+	r.GET("/api/webhooks/:id",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetWebhook,
+	)
+	r.PUT("/api/webhooks/:id",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.UpdateWebhook,
+	)
+	r.DELETE("/api/webhooks/:id",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.DeleteWebhook,
+	)
+	r.POST("/api/webhooks/:id/rotate-secret",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.RotateWebhookSecret,
+	)
+	r.GET("/api/webhooks/:id/deliveries",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetWebhookDeliveries,
+	)
+	r.POST("/api/webhooks/:id/deliveries/:deliveryId/redeliver",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.RedeliverWebhook,
+	)
+
+	// separation of duties
+	r.GET("/api/sod/policy",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.GetSoDPolicy,
+	)
+	r.GET("/api/sod/violations",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.GetSoDViolations,
+	)
+
+	// authorization diagnostics
+	r.POST("/api/authz/explain",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleUser, ""),
+		s.ExplainPermission,
+	)
+	r.POST("/api/authz/simulate",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.SimulatePermission,
+	)
+
+	// relationship-based access control
+	r.GET("/api/rebac/tuples",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.ListRelationTuples,
+	)
+	r.POST("/api/rebac/tuples",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.WriteRelationTuple,
+	)
+	r.DELETE("/api/rebac/tuples",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.DeleteRelationTuple,
+	)
+	r.GET("/api/rebac/check",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.CheckRelation,
+	)
+	r.GET("/api/rebac/expand",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.ExpandRelation,
+	)
+	r.GET("/api/rebac/lookup-resources",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.LookupResources,
+	)
+	r.GET("/api/rebac/namespaces",
+		middleware.RequireAnyRole(s.rbacService, s.tokenService, []roles.Role{roles.RoleAdmin, roles.RoleManager}, ""),
+		s.GetNamespaceConfig,
+	)
+	r.POST("/api/rebac/sync/project-roles",
+		middleware.RequireRole(s.rbacService, s.tokenService, roles.RoleAdmin, ""),
+		s.SyncProjectRoleTuples,
+	)
 
 	return r
 }
@@ -0,0 +1,124 @@
+package handlers
+
+import (
+	"AuthServer/internal/scheduler"
+	domain "AuthServer/internal/service"
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// jobHistoryRetention is how long job_runs rows are kept.
+const jobHistoryRetention = 30 * 24 * time.Hour
+
+// NewScheduler returns a scheduler with the periodic lifecycle jobs registered.
+func (s *Server) NewScheduler() *scheduler.Scheduler {
+	jobs := scheduler.New(scheduler.NewAdvisoryLocker(s.Db.DB()), s.jobRunRepo)
+
+	jobs.Register(scheduler.Job{
+		Name:     "expired-roles",
+		Interval: 5 * time.Minute,
+		Run: func(ctx context.Context) (int, error) {
+			return s.userRoleRepo.CleanupExpiredRoles(ctx)
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "stale-verification-codes",
+		Interval: time.Minute,
+		Local:    true,
+		Run: func(ctx context.Context) (int, error) {
+			return s.verificationCodes.PurgeExpired(), nil
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "expired-jit-requests",
+		Interval: 5 * time.Minute,
+		Run: func(ctx context.Context) (int, error) {
+			return s.jitService.ExpireStaleRequests(ctx)
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "jit-escalation",
+		Interval: 5 * time.Minute,
+		Run: func(ctx context.Context) (int, error) {
+			return s.jitService.EscalateOverdue(ctx)
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "overdue-access-reviews",
+		Interval: time.Hour,
+		Run: func(ctx context.Context) (int, error) {
+			return s.accessReviewService.ProcessOverdue(ctx)
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "rebac-project-roles",
+		Interval: 15 * time.Minute,
+		Run: func(ctx context.Context) (int, error) {
+			written, deleted, err := s.rebacService.SyncProjectRoles(ctx)
+			return written + deleted, err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "webhook-deliveries",
+		Interval: 30 * time.Second,
+		Run: func(ctx context.Context) (int, error) {
+			return s.webhookService.DeliverDue(ctx)
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "audit-checkpoint",
+		Interval: s.auditCheckpointInterval,
+		Run: func(ctx context.Context) (int, error) {
+			cp, err := s.auditService.Checkpoint(ctx)
+			if cp == nil {
+				return 0, err
+			}
+			return 1, err
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "suppressed-audit-failures",
+		Interval: time.Minute,
+		Local:    true,
+		Run: func(ctx context.Context) (int, error) {
+			return s.auditService.RecordSuppressed(ctx)
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "signing-keys",
+		Interval: domain.SigningKeyRefreshInterval,
+		Local:    true,
+		Run: func(ctx context.Context) (int, error) {
+			return 0, s.signingKeyService.Refresh(ctx)
+		},
+	})
+	jobs.Register(scheduler.Job{
+		Name:     "job-history-retention",
+		Interval: 24 * time.Hour,
+		Run: func(ctx context.Context) (int, error) {
+			return s.jobRunRepo.DeleteOlderThan(ctx, time.Now().Add(-jobHistoryRetention))
+		},
+	})
+
+	return jobs
+}
+
+func (s *Server) GetJobRuns(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+		return
+	}
+
+	runs, err := s.jobRunRepo.List(c.Request.Context(), c.Query("job"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve job runs"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": runs})
+}
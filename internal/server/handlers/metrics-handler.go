@@ -0,0 +1,21 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// metricsHandler serves the Prometheus metrics, behind the metrics token when one is set.
+func (s *Server) metricsHandler() gin.HandlerFunc {
+	h := s.metrics.Handler()
+	want := []byte("Bearer " + s.metricsToken)
+	return func(c *gin.Context) {
+		if s.metricsToken != "" && subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), want) != 1 {
+			c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid metrics token"})
+			return
+		}
+		h.ServeHTTP(c.Writer, c.Request)
+	}
+}
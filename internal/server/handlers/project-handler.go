@@ -2,6 +2,10 @@ package handlers
 
 import (
 	"AuthServer/internal/domain/models"
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/middleware"
+	domain "AuthServer/internal/service"
+	"errors"
 	"net/http"
 	"time"
 
@@ -9,12 +13,12 @@ import (
 )
 
 func (s *Server) GetAllProjects(c *gin.Context) {
-	user, _ := getUserFromDatabase(c)
+	user, _ := s.getUserFromDatabase(c)
 	if user == nil {
 		return
 	}
 
-	projects, err := projectService.FindAll()
+	projects, err := s.projectService.FindAll(c.Request.Context())
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve projects"})
 		return
@@ -26,7 +30,7 @@ func (s *Server) GetAllProjects(c *gin.Context) {
 }
 
 func (s *Server) GetProjectById(c *gin.Context) {
-	user, _ := getUserFromDatabase(c)
+	user, _ := s.getUserFromDatabase(c)
 	if user == nil {
 		return
 	}
@@ -37,7 +41,7 @@ func (s *Server) GetProjectById(c *gin.Context) {
 		return
 	}
 
-	project, err := projectService.FindById(projectID)
+	project, err := s.projectService.FindById(c.Request.Context(), projectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
 		return
@@ -49,7 +53,7 @@ func (s *Server) GetProjectById(c *gin.Context) {
 }
 
 func (s *Server) CreateProject(c *gin.Context) {
-	user, _ := getUserFromDatabase(c)
+	user, _ := s.getUserFromDatabase(c)
 	if user == nil {
 		return
 	}
@@ -72,12 +76,19 @@ func (s *Server) CreateProject(c *gin.Context) {
 		CreatedAt:   time.Now(),
 	}
 
-	savedProject, err := projectService.Save(project)
+	savedProject, err := s.projectService.Save(c.Request.Context(), project)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	s.audit(c, models.AuditEvent{
+		Action:     models.AuditProjectCreate,
+		TargetType: models.AuditTargetProject,
+		TargetID:   savedProject.ID,
+		After:      domain.AuditState(savedProject),
+	})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "project created successfully",
 		"data":    savedProject,
@@ -85,7 +96,7 @@ func (s *Server) CreateProject(c *gin.Context) {
 }
 
 func (s *Server) UpdateProject(c *gin.Context) {
-	user, _ := getUserFromDatabase(c)
+	user, _ := s.getUserFromDatabase(c)
 	if user == nil {
 		return
 	}
@@ -97,7 +108,7 @@ func (s *Server) UpdateProject(c *gin.Context) {
 	}
 
 	// Check if project exists
-	existingProject, err := projectService.FindById(projectID)
+	existingProject, err := s.projectService.FindById(c.Request.Context(), projectID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
 		return
@@ -113,6 +124,8 @@ func (s *Server) UpdateProject(c *gin.Context) {
 		return
 	}
 
+	before := domain.AuditState(existingProject)
+
 	// Update only provided fields
 	if input.Name != "" {
 		existingProject.Name = input.Name
@@ -121,11 +134,19 @@ func (s *Server) UpdateProject(c *gin.Context) {
 		existingProject.Description = input.Description
 	}
 
-	if err := projectService.Update(*existingProject); err != nil {
+	if err := s.projectService.Update(c.Request.Context(), *existingProject); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to update project"})
 		return
 	}
 
+	s.audit(c, models.AuditEvent{
+		Action:     models.AuditProjectUpdate,
+		TargetType: models.AuditTargetProject,
+		TargetID:   projectID,
+		Before:     before,
+		After:      domain.AuditState(existingProject),
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "project updated successfully",
 		"data":    existingProject,
@@ -133,7 +154,7 @@ func (s *Server) UpdateProject(c *gin.Context) {
 }
 
 func (s *Server) DeleteProject(c *gin.Context) {
-	user, _ := getUserFromDatabase(c)
+	user, _ := s.getUserFromDatabase(c)
 	if user == nil {
 		return
 	}
@@ -144,12 +165,107 @@ func (s *Server) DeleteProject(c *gin.Context) {
 		return
 	}
 
-	if err := projectService.Delete(projectID); err != nil {
+	existingProject, err := s.projectService.FindById(c.Request.Context(), projectID)
+	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
 		return
 	}
 
+	if err := s.projectService.Delete(c.Request.Context(), projectID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	s.audit(c, models.AuditEvent{
+		Action:     models.AuditProjectDelete,
+		TargetType: models.AuditTargetProject,
+		TargetID:   projectID,
+		Before:     domain.AuditState(existingProject),
+	})
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "project deleted successfully",
 	})
 }
+
+func (s *Server) GetProjectMembers(c *gin.Context) {
+	members, err := s.projectMemberService.ListMembers(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "project not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": members})
+}
+
+func (s *Server) AddProjectMember(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+	projectID := c.Param("id")
+
+	var input struct {
+		UserID string     `json:"user_id" binding:"required"`
+		Role   roles.Role `json:"role" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := s.projectMemberService.AddMember(c.Request.Context(), actorID.(string), projectID, input.UserID, input.Role, middleware.AccessContext(c))
+	event := models.AuditEvent{
+		Action:     models.AuditProjectMemberAdd,
+		TargetType: models.AuditTargetProject,
+		TargetID:   projectID,
+		After:      domain.AuditState(gin.H{"user_id": input.UserID, "role": input.Role}),
+	}
+	if err != nil {
+		s.auditFailure(c, event, err)
+		if respondSoDViolation(c, err) {
+			return
+		}
+		if errors.Is(err, domain.ErrDelegationDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, event)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":    "project member added",
+		"project_id": projectID,
+		"user_id":    input.UserID,
+		"role":       input.Role,
+	})
+}
+
+func (s *Server) RemoveProjectMember(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+	projectID := c.Param("id")
+	userID := c.Param("userId")
+
+	removed, err := s.projectMemberService.RemoveMember(c.Request.Context(), actorID.(string), projectID, userID, middleware.AccessContext(c))
+	event := models.AuditEvent{
+		Action:     models.AuditProjectMemberRemove,
+		TargetType: models.AuditTargetProject,
+		TargetID:   projectID,
+		After:      domain.AuditState(gin.H{"user_id": userID, "removed": removed}),
+	}
+	if err != nil {
+		s.auditFailure(c, event, err)
+		if errors.Is(err, domain.ErrDelegationDenied) {
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	s.audit(c, event)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "project member removed",
+		"removed": removed,
+	})
+}
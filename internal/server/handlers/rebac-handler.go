@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"AuthServer/internal/domain/rebac"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type relationTupleInput struct {
+	Object   string `json:"object" binding:"required"`   // namespace:id
+	Relation string `json:"relation" binding:"required"` // e.g. viewer
+	Subject  string `json:"subject" binding:"required"`  // namespace:id or namespace:id#relation
+}
+
+func (in relationTupleInput) tuple() (rebac.RelationTuple, error) {
+	return rebac.ParseTuple(in.Object + "#" + in.Relation + "@" + in.Subject)
+}
+
+func (s *Server) ListRelationTuples(c *gin.Context) {
+	object, err := rebac.ParseObject(c.Query("object"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tuples, err := s.rebacService.ListTuples(c.Request.Context(), object, c.Query("relation"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve tuples"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tuples})
+}
+
+func (s *Server) WriteRelationTuple(c *gin.Context) {
+	var input relationTupleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tuple, err := input.tuple()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.rebacService.WriteTuple(c.Request.Context(), tuple); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "tuple written",
+		"tuple":   tuple.String(),
+	})
+}
+
+func (s *Server) DeleteRelationTuple(c *gin.Context) {
+	var input relationTupleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tuple, err := input.tuple()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := s.rebacService.DeleteTuple(c.Request.Context(), tuple); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "tuple not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "tuple deleted"})
+}
+
+func (s *Server) CheckRelation(c *gin.Context) {
+	object, err := rebac.ParseObject(c.Query("object"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	subject, err := rebac.ParseSubject(c.Query("subject"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	relation := c.Query("relation")
+	allowed, err := s.rebacService.Check(c.Request.Context(), object, relation, subject)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"object":   object.String(),
+		"relation": relation,
+		"subject":  subject.String(),
+		"allowed":  allowed,
+	})
+}
+
+func (s *Server) ExpandRelation(c *gin.Context) {
+	object, err := rebac.ParseObject(c.Query("object"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tree, err := s.rebacService.Expand(c.Request.Context(), object, c.Query("relation"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": tree})
+}
+
+func (s *Server) LookupResources(c *gin.Context) {
+	namespace := c.Query("namespace")
+	relation := c.Query("relation")
+	if namespace == "" || relation == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "namespace and relation are required"})
+		return
+	}
+
+	subject, err := rebac.ParseSubject(c.Query("subject"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+		return
+	}
+
+	ids, next, err := s.rebacService.LookupResources(c.Request.Context(), namespace, relation, subject, c.Query("cursor"), limit)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"namespace":   namespace,
+		"relation":    relation,
+		"subject":     subject.String(),
+		"data":        ids,
+		"next_cursor": next,
+	})
+}
+
+func (s *Server) GetNamespaceConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": s.rebacService.Config()})
+}
+
+func (s *Server) SyncProjectRoleTuples(c *gin.Context) {
+	written, deleted, err := s.rebacService.SyncProjectRoles(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to sync project roles"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "project roles synced",
+		"written": written,
+		"deleted": deleted,
+	})
+}
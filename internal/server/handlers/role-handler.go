@@ -1,7 +1,11 @@
 package handlers
 
 import (
+	"AuthServer/internal/domain/models"
 	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/middleware"
+	domain "AuthServer/internal/service"
+	"errors"
 	"net/http"
 	"time"
 
@@ -14,8 +18,9 @@ func (s *Server) AssignGlobalRole(c *gin.Context) {
 	assignerID, _ := c.Get("user_id")
 
 	var input struct {
-		UserID string     `json:"user_id" binding:"required"`
-		Role   roles.Role `json:"role" binding:"required"`
+		UserID    string     `json:"user_id" binding:"required"`
+		Role      roles.Role `json:"role" binding:"required"`
+		Condition *string    `json:"condition"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -29,16 +34,22 @@ func (s *Server) AssignGlobalRole(c *gin.Context) {
 		return
 	}
 
-	err := rbacService.AssignRole(input.UserID, input.Role, nil, nil, assignerID.(string))
+	assignment := roles.UserRole{UserID: input.UserID, Role: input.Role, Condition: input.Condition}
+
+	roleID, err := s.rbacService.AssignRole(c.Request.Context(), input.UserID, input.Role, nil, nil, assignerID.(string), input.Condition)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+		s.auditRoleAssign(c, assignment, err)
+		respondAssignRoleError(c, err)
 		return
 	}
+	assignment.ID = roleID
+	s.auditRoleAssign(c, assignment, nil)
 
 	c.JSON(http.StatusCreated, gin.H{
-		"message": "global role assigned",
-		"user_id": input.UserID,
-		"role":    input.Role,
+		"message":   "global role assigned",
+		"user_id":   input.UserID,
+		"role":      input.Role,
+		"condition": input.Condition,
 	})
 }
 
@@ -49,6 +60,7 @@ func (s *Server) AssignResourceRole(c *gin.Context) {
 		UserID     string     `json:"user_id" binding:"required"`
 		Role       roles.Role `json:"role" binding:"required"`
 		ResourceID string     `json:"resource_id" binding:"required"`
+		Condition  *string    `json:"condition"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -56,22 +68,28 @@ func (s *Server) AssignResourceRole(c *gin.Context) {
 		return
 	}
 
-	if input.Role != roles.RoleProjectEditor && input.Role != roles.RoleProjectViewer {
+	if !roles.IsResourceRole(input.Role) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "not a valid resource-specific role"})
 		return
 	}
 
-	err := rbacService.AssignRole(input.UserID, input.Role, &input.ResourceID, nil, assignerID.(string))
+	assignment := roles.UserRole{UserID: input.UserID, Role: input.Role, ResourceID: &input.ResourceID, Condition: input.Condition}
+
+	roleID, err := s.rbacService.AssignRole(c.Request.Context(), input.UserID, input.Role, &input.ResourceID, nil, assignerID.(string), input.Condition)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+		s.auditRoleAssign(c, assignment, err)
+		respondAssignRoleError(c, err)
 		return
 	}
+	assignment.ID = roleID
+	s.auditRoleAssign(c, assignment, nil)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":     "resource role assigned",
 		"user_id":     input.UserID,
 		"role":        input.Role,
 		"resource_id": input.ResourceID,
+		"condition":   input.Condition,
 	})
 }
 
@@ -83,6 +101,7 @@ func (s *Server) AssignTemporaryRole(c *gin.Context) {
 		Role            roles.Role `json:"role" binding:"required"`
 		ResourceID      *string    `json:"resource_id"`
 		DurationMinutes int        `json:"duration_minutes" binding:"required,min=1,max=1440"`
+		Condition       *string    `json:"condition"`
 	}
 
 	if err := c.ShouldBindJSON(&input); err != nil {
@@ -92,11 +111,16 @@ func (s *Server) AssignTemporaryRole(c *gin.Context) {
 
 	expiresAt := time.Now().Add(time.Duration(input.DurationMinutes) * time.Minute)
 
-	err := rbacService.AssignRole(input.UserID, input.Role, input.ResourceID, &expiresAt, assignerID.(string))
+	assignment := roles.UserRole{UserID: input.UserID, Role: input.Role, ResourceID: input.ResourceID, ExpiresAt: &expiresAt, Condition: input.Condition}
+
+	roleID, err := s.rbacService.AssignRole(c.Request.Context(), input.UserID, input.Role, input.ResourceID, &expiresAt, assignerID.(string), input.Condition)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+		s.auditRoleAssign(c, assignment, err)
+		respondAssignRoleError(c, err)
 		return
 	}
+	assignment.ID = roleID
+	s.auditRoleAssign(c, assignment, nil)
 
 	c.JSON(http.StatusCreated, gin.H{
 		"message":     "temporary role assigned",
@@ -104,9 +128,36 @@ func (s *Server) AssignTemporaryRole(c *gin.Context) {
 		"role":        input.Role,
 		"resource_id": input.ResourceID,
 		"expires_at":  expiresAt,
+		"condition":   input.Condition,
 	})
 }
 
+// auditRoleAssign records a direct role assignment, or the attempt if err is set.
+func (s *Server) auditRoleAssign(c *gin.Context, assignment roles.UserRole, err error) {
+	event := models.AuditEvent{
+		Action:     models.AuditRoleAssign,
+		TargetType: models.AuditTargetUserRole,
+		TargetID:   assignment.ID,
+		After:      domain.AuditState(assignment),
+	}
+	if err != nil {
+		s.auditFailure(c, event, err)
+		return
+	}
+	s.audit(c, event)
+}
+
+func respondAssignRoleError(c *gin.Context, err error) {
+	if respondSoDViolation(c, err) {
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidCondition) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to assign role"})
+}
+
 func (s *Server) GetUserRoles(c *gin.Context) {
 	userID := c.Param("id")
 	if userID == "" {
@@ -114,7 +165,7 @@ func (s *Server) GetUserRoles(c *gin.Context) {
 		return
 	}
 
-	userRoles, err := rbacService.GetUserRoles(userID)
+	userRoles, err := s.rbacService.GetUserRoles(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve roles"})
 		return
@@ -129,7 +180,7 @@ func (s *Server) GetUserRoles(c *gin.Context) {
 func (s *Server) GetMyRoles(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	userRoles, err := rbacService.GetUserRoles(userID.(string))
+	userRoles, err := s.rbacService.GetUserRoles(c.Request.Context(), userID.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve roles"})
 		return
@@ -145,12 +196,19 @@ func (s *Server) RevokeUserRole(c *gin.Context) {
 		return
 	}
 
-	err := rbacService.RevokeRole(roleID)
+	revoked, err := s.rbacService.RevokeRole(c.Request.Context(), roleID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "role not found"})
 		return
 	}
 
+	s.audit(c, models.AuditEvent{
+		Action:     models.AuditRoleRevoke,
+		TargetType: models.AuditTargetUserRole,
+		TargetID:   roleID,
+		Before:     domain.AuditState(revoked),
+	})
+
 	c.JSON(http.StatusOK, gin.H{"message": "role revoked"})
 }
 
@@ -171,12 +229,19 @@ func (s *Server) CreateJITRequest(c *gin.Context) {
 		return
 	}
 
-	request, err := jitService.CreateRequest(userID.(string), input.Role, input.ResourceID, input.DurationMinutes, input.Reason)
+	request, err := s.jitService.CreateRequest(c.Request.Context(), userID.(string), input.Role, input.ResourceID, input.DurationMinutes, input.Reason)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
+		respondJITRequestError(c, err)
 		return
 	}
 
+	s.audit(c, models.AuditEvent{
+		Action:     models.AuditJITRequest,
+		TargetType: models.AuditTargetJITRequest,
+		TargetID:   request.ID,
+		After:      domain.AuditState(request),
+	})
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "JIT request created",
 		"data":    request,
@@ -190,9 +255,9 @@ func (s *Server) GetJITRequests(c *gin.Context) {
 	var err error
 
 	if userID != "" {
-		requests, err = jitService.GetUserRequests(userID)
+		requests, err = s.jitService.GetUserRequests(c.Request.Context(), userID)
 	} else {
-		requests, err = jitService.GetPendingRequests()
+		requests, err = s.jitService.GetPendingRequests(c.Request.Context())
 	}
 
 	if err != nil {
@@ -206,7 +271,7 @@ func (s *Server) GetJITRequests(c *gin.Context) {
 func (s *Server) GetMyJITRequests(c *gin.Context) {
 	userID, _ := c.Get("user_id")
 
-	requests, err := jitService.GetUserRequests(userID.(string))
+	requests, err := s.jitService.GetUserRequests(c.Request.Context(), userID.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve requests"})
 		return
@@ -215,28 +280,252 @@ func (s *Server) GetMyJITRequests(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"data": requests})
 }
 
+// auditJIT records an action on a JIT request, or the attempt if err is set.
+func (s *Server) auditJIT(c *gin.Context, action, requestID string, after any, err error) {
+	event := models.AuditEvent{
+		Action:     action,
+		TargetType: models.AuditTargetJITRequest,
+		TargetID:   requestID,
+		After:      domain.AuditState(after),
+	}
+	if err != nil {
+		s.auditFailure(c, event, err)
+		return
+	}
+	s.audit(c, event)
+}
+
+// respondJITRequestError maps errors creating a request to status codes.
+func respondJITRequestError(c *gin.Context, err error) {
+	if respondSoDViolation(c, err) {
+		return
+	}
+	if errors.Is(err, domain.ErrInvalidJITRequest) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create request"})
+}
+
+// respondJITDecisionError maps approval workflow errors to status codes.
+func respondJITDecisionError(c *gin.Context, err error) {
+	if respondSoDViolation(c, err) {
+		return
+	}
+
+	switch {
+	case errors.Is(err, domain.ErrNotEligibleApprover):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrAlreadyDecided), errors.Is(err, domain.ErrInvalidTransition):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, domain.ErrNotRequestOwner):
+		c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	}
+}
+
+type jitDecisionInput struct {
+	Comment *string `json:"comment"`
+}
+
 func (s *Server) ApproveJITRequest(c *gin.Context) {
 	requestID := c.Param("id")
 	approverID, _ := c.Get("user_id")
 
-	err := jitService.ApproveRequest(requestID, approverID.(string))
+	var input jitDecisionInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	progress, err := s.jitService.ApproveRequest(c.Request.Context(), requestID, approverID.(string), input.Comment, middleware.AccessContext(c))
+	s.auditJIT(c, models.AuditJITApprove, requestID, progress, err)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondJITDecisionError(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{"message": "request approved and role assigned"})
+	message := "approval recorded"
+	if progress.Status == roles.JITStatusApproved {
+		message = "request approved and role assigned"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": message,
+		"data":    progress,
+	})
 }
 
 func (s *Server) RejectJITRequest(c *gin.Context) {
 	requestID := c.Param("id")
 	approverID, _ := c.Get("user_id")
 
-	err := jitService.RejectRequest(requestID, approverID.(string))
+	var input jitDecisionInput
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&input); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	err := s.jitService.RejectRequest(c.Request.Context(), requestID, approverID.(string), input.Comment, middleware.AccessContext(c))
+	s.auditJIT(c, models.AuditJITReject, requestID, gin.H{"status": roles.JITStatusRejected, "comment": input.Comment}, err)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		respondJITDecisionError(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "request rejected"})
 }
+
+func (s *Server) CancelJITRequest(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	err := s.jitService.CancelRequest(c.Request.Context(), c.Param("id"), userID.(string))
+	s.auditJIT(c, models.AuditJITCancel, c.Param("id"), gin.H{"status": roles.JITStatusCancelled}, err)
+	if err != nil {
+		respondJITDecisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "request cancelled"})
+}
+
+func (s *Server) ExtendJITRequest(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	var input struct {
+		DurationMinutes int    `json:"duration_minutes" binding:"required,min=1,max=1440"`
+		Reason          string `json:"reason" binding:"required"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	request, err := s.jitService.RequestExtension(c.Request.Context(), c.Param("id"), userID.(string), input.DurationMinutes, input.Reason)
+	s.auditJIT(c, models.AuditJITExtend, c.Param("id"), request, err)
+	if err != nil {
+		respondJITDecisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "JIT extension requested",
+		"data":    request,
+	})
+}
+
+func (s *Server) RevokeJITRequest(c *gin.Context) {
+	actorID, _ := c.Get("user_id")
+
+	err := s.jitService.RevokeGrant(c.Request.Context(), c.Param("id"), actorID.(string))
+	s.auditJIT(c, models.AuditJITRevoke, c.Param("id"), gin.H{"status": roles.JITStatusRevoked}, err)
+	if err != nil {
+		respondJITDecisionError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "grant revoked"})
+}
+
+func (s *Server) GetJITApprovals(c *gin.Context) {
+	approvals, err := s.jitService.GetApprovals(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": approvals})
+}
+
+func (s *Server) GetJITRequestsAwaitingMe(c *gin.Context) {
+	userID, _ := c.Get("user_id")
+
+	requests, err := s.jitService.GetAwaitingApproval(c.Request.Context(), userID.(string), middleware.AccessContext(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve requests"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": requests})
+}
+
+// ============= JIT APPROVAL POLICIES =============
+
+func (s *Server) CreateJITPolicy(c *gin.Context) {
+	creatorID, _ := c.Get("user_id")
+
+	var input struct {
+		Role                   roles.Role `json:"role" binding:"required"`
+		ResourceID             *string    `json:"resource_id"`
+		RequiredApprovals      int        `json:"required_approvals" binding:"omitempty,min=1,max=10"`
+		ApproverGroupID        *string    `json:"approver_group_id"`
+		AutoApproveMaxMinutes  *int       `json:"auto_approve_max_minutes" binding:"omitempty,min=1,max=1440"`
+		EscalationAfterMinutes *int       `json:"escalation_after_minutes" binding:"omitempty,min=1"`
+		EscalationGroupID      *string    `json:"escalation_group_id"`
+	}
+
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	creator := creatorID.(string)
+	policy := &roles.JITApprovalPolicy{
+		Role:                   input.Role,
+		ResourceID:             input.ResourceID,
+		RequiredApprovals:      input.RequiredApprovals,
+		ApproverGroupID:        input.ApproverGroupID,
+		AutoApproveMaxMinutes:  input.AutoApproveMaxMinutes,
+		EscalationAfterMinutes: input.EscalationAfterMinutes,
+		EscalationGroupID:      input.EscalationGroupID,
+		CreatedBy:              &creator,
+	}
+
+	if err := s.jitService.CreatePolicy(c.Request.Context(), policy); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.audit(c, models.AuditEvent{
+		Action:     models.AuditJITPolicyCreate,
+		TargetType: models.AuditTargetJITPolicy,
+		TargetID:   policy.ID,
+		After:      domain.AuditState(policy),
+	})
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "approval policy created",
+		"data":    policy,
+	})
+}
+
+func (s *Server) GetJITPolicies(c *gin.Context) {
+	policies, err := s.jitService.ListPolicies(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve policies"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": policies})
+}
+
+func (s *Server) DeleteJITPolicy(c *gin.Context) {
+	if err := s.jitService.DeletePolicy(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	s.audit(c, models.AuditEvent{
+		Action:     models.AuditJITPolicyDelete,
+		TargetType: models.AuditTargetJITPolicy,
+		TargetID:   c.Param("id"),
+	})
+
+	c.JSON(http.StatusOK, gin.H{"message": "approval policy deleted"})
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"AuthServer/internal/domain/roles"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// respondSoDViolation writes a structured 403 if err is a separation-of-duties violation.
+func respondSoDViolation(c *gin.Context, err error) bool {
+	var violation *roles.SoDViolation
+	if !errors.As(err, &violation) {
+		return false
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{
+		"error":     violation.Error(),
+		"rule":      violation.Rule,
+		"violation": violation,
+	})
+	return true
+}
+
+func (s *Server) GetSoDPolicy(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"data": s.sodService.Policy()})
+}
+
+func (s *Server) GetSoDViolations(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+		return
+	}
+
+	violations, err := s.sodService.Violations(c.Request.Context(), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve violations"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": violations})
+}
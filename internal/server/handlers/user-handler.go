@@ -8,7 +8,7 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
-func getUserFromDatabase(c *gin.Context) (*models.User, string) {
+func (s *Server) getUserFromDatabase(c *gin.Context) (*models.User, string) {
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "need to include a Authorization header"})
@@ -16,24 +16,28 @@ func getUserFromDatabase(c *gin.Context) (*models.User, string) {
 	}
 
 	tokenString := strings.TrimPrefix(authHeader, "Bearer ")
-	claims, _ := tokenService.DecodeAccessToken(tokenString)
+	claims, _ := s.tokenService.DecodeAccessToken(tokenString)
 	userID, ok := claims["user-id"].(string)
 	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token", "debug": claims["user-id"].(string)})
 		return nil, ""
 	}
 
-	user, err := userService.FindById(userID)
+	user, err := s.userService.FindById(c.Request.Context(), userID)
 	if err != nil {
 		c.JSON(http.StatusNotFound, gin.H{"error": "user not found"})
 		return nil, ""
 	}
+	if user.DisabledAt != nil {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account is disabled"})
+		return nil, ""
+	}
 
 	return user, userID
 }
 
 func (s *Server) GetUserData(c *gin.Context) {
-	user, _ := getUserFromDatabase(c)
+	user, _ := s.getUserFromDatabase(c)
 
 	if user == nil {
 		return
@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/events"
+	domain "AuthServer/internal/service"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type webhookInput struct {
+	URL         string   `json:"url" binding:"required"`
+	Description *string  `json:"description"`
+	Events      []string `json:"events" binding:"required"`
+	Active      *bool    `json:"active"`
+}
+
+func respondWebhookError(c *gin.Context, err error, message string) {
+	if errors.Is(err, domain.ErrInvalidWebhook) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": message})
+}
+
+func (s *Server) CreateWebhook(c *gin.Context) {
+	var input webhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	createdBy := c.GetString("user_id")
+	sub := models.WebhookSubscription{
+		URL:         input.URL,
+		Description: input.Description,
+		Events:      input.Events,
+		CreatedBy:   &createdBy,
+	}
+
+	secret, err := s.webhookService.CreateSubscription(c.Request.Context(), &sub)
+	if err != nil {
+		respondWebhookError(c, err, "failed to create webhook")
+		return
+	}
+
+	// The secret is shown only here and when rotated
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "webhook created",
+		"data":    sub,
+		"secret":  secret,
+	})
+}
+
+func (s *Server) GetWebhooks(c *gin.Context) {
+	subs, err := s.webhookService.ListSubscriptions(c.Request.Context())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve webhooks"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": subs, "events": events.Types})
+}
+
+func (s *Server) GetWebhook(c *gin.Context) {
+	sub, err := s.webhookService.GetSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": sub})
+}
+
+// UpdateWebhook replaces the URL, description and events. Setting active to true re-enables a
+// subscription that was disabled after repeated failures.
+func (s *Server) UpdateWebhook(c *gin.Context) {
+	sub, err := s.webhookService.GetSubscription(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+
+	var input webhookInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	sub.URL = input.URL
+	sub.Description = input.Description
+	sub.Events = input.Events
+	if input.Active != nil {
+		sub.Active = *input.Active
+	}
+
+	if err := s.webhookService.UpdateSubscription(c.Request.Context(), sub); err != nil {
+		respondWebhookError(c, err, "failed to update webhook")
+		return
+	}
+
+	updated, err := s.webhookService.GetSubscription(c.Request.Context(), sub.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve webhook"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook updated", "data": updated})
+}
+
+func (s *Server) DeleteWebhook(c *gin.Context) {
+	if err := s.webhookService.DeleteSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook deleted"})
+}
+
+func (s *Server) RotateWebhookSecret(c *gin.Context) {
+	secret, err := s.webhookService.RotateSecret(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "webhook secret rotated", "secret": secret})
+}
+
+// GetWebhookDeliveries lists the subscription's recent deliveries, optionally filtered by
+// status (pending, succeeded or failed).
+func (s *Server) GetWebhookDeliveries(c *gin.Context) {
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "100"))
+	if err != nil || limit < 1 || limit > 1000 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "limit must be between 1 and 1000"})
+		return
+	}
+
+	if _, err := s.webhookService.GetSubscription(c.Request.Context(), c.Param("id")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "webhook not found"})
+		return
+	}
+
+	deliveries, err := s.webhookService.ListDeliveries(c.Request.Context(), c.Param("id"), c.Query("status"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to retrieve webhook deliveries"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"data": deliveries})
+}
+
+// RedeliverWebhook sends an earlier delivery again, with the same event ID, and returns the
+// outcome of the attempt. Failed attempts are retried like any other delivery.
+func (s *Server) RedeliverWebhook(c *gin.Context) {
+	delivery, err := s.webhookService.Redeliver(c.Request.Context(), c.Param("id"), c.Param("deliveryId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "webhook redelivered", "data": delivery})
+}
@@ -1,36 +1,44 @@
 package server
 
 import (
+	"AuthServer/internal/app"
+	"AuthServer/internal/database"
 	"AuthServer/internal/repository"
+	"AuthServer/internal/scheduler"
 	"AuthServer/internal/server/handlers"
+	"context"
 	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
-	"strconv"
-
-	_ "github.com/joho/godotenv/autoload"
-
-	"AuthServer/internal/database"
 )
 
-func NewServer() *http.Server {
-	port, _ := strconv.Atoi(os.Getenv("PORT"))
-
-	dbService := database.New() // This is of type database.Service
-	database.RunMigrations(dbService.DB())
-	//
-	userRepo := repository.NewUserRepository(dbService) // Pass the service
+// NewServer migrates the app's database, checks that its schema matches what the
+// repositories expect, builds the HTTP server and starts the background job scheduler unless
+// it is disabled. The caller stops the scheduler on shutdown.
+func NewServer(a *app.App) (*http.Server, *scheduler.Scheduler, error) {
+	if err := database.RunMigrations(a.DB.DB()); err != nil {
+		return nil, nil, err
+	}
+	if err := database.CheckSchema(context.Background(), a.DB.DB(), repository.Schema); err != nil {
+		return nil, nil, err
+	}
 
-	serverHandler := &handlers.Server{
-		Port:     port,
-		Db:       dbService,
-		UserRepo: userRepo,
+	// Until the keys load tokens are signed with the configured secret
+	if err := a.Services.SigningKeys.Refresh(context.Background()); err != nil {
+		slog.Error("failed to load signing keys, signing with the configured secret", "error", err)
 	}
 
+	serverHandler := handlers.NewServer(a)
+
 	srv := &http.Server{
-		Addr:    fmt.Sprintf(":%d", port),
+		Addr:    fmt.Sprintf(":%d", serverHandler.Port),
 		Handler: serverHandler.RegisterRoutes(),
 	}
 
-	return srv
+	jobs := serverHandler.NewScheduler()
+	if !a.Config.Scheduler.Disabled {
+		jobs.Start()
+	}
+
+	return srv, jobs, nil
 }
@@ -0,0 +1,431 @@
+package service
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/repository"
+	"AuthServer/internal/tracing"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrInvalidCampaign is returned when a campaign definition is rejected.
+	ErrInvalidCampaign = errors.New("invalid access review campaign")
+	// ErrNotReviewer is returned when the caller is not the item's reviewer or reviews their own access.
+	ErrNotReviewer = errors.New("not the reviewer of this item")
+)
+
+// AccessReviewService runs recertification campaigns over permanent role assignments.
+// Revocations are applied through the RBAC service in the same transaction as the decision,
+// and are announced and audited like any other revocation.
+type AccessReviewService struct {
+	tx           database.Transactor
+	repo         *repository.AccessReviewRepository
+	userRoleRepo *repository.UserRoleRepository
+	userRepo     repository.IUserRepository
+	projectRepo  repository.IProjectRepository
+	rbacService  *RBACService
+	audit        *AuditService
+	tracer       trace.Tracer
+}
+
+func NewAccessReviewService(tx database.Transactor, repo *repository.AccessReviewRepository, userRoleRepo *repository.UserRoleRepository, userRepo repository.IUserRepository, projectRepo repository.IProjectRepository, rbacService *RBACService, audit *AuditService, tracer trace.Tracer) *AccessReviewService {
+	return &AccessReviewService{
+		tx:           tx,
+		repo:         repo,
+		userRoleRepo: userRoleRepo,
+		userRepo:     userRepo,
+		projectRepo:  projectRepo,
+		rbacService:  rbacService,
+		audit:        audit,
+		tracer:       tracer,
+	}
+}
+
+// Launch validates the campaign, snapshots the assignments in scope and creates one review
+// item per assignment.
+func (s *AccessReviewService) Launch(ctx context.Context, c *models.AccessReviewCampaign) (n int, err error) {
+	ctx, span := s.tracer.Start(ctx, "AccessReviewService.Launch")
+	defer func() { tracing.End(span, err) }()
+
+	if err := s.validate(ctx, c); err != nil {
+		return 0, err
+	}
+
+	items, err := s.repo.SnapshotAssignments(ctx, c.ScopeRole, c.ScopeProjectID)
+	if err != nil {
+		return 0, err
+	}
+	if len(items) == 0 {
+		return 0, fmt.Errorf("%w: no permanent assignments in scope", ErrInvalidCampaign)
+	}
+
+	owners := map[string][]string{}
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		repo := s.repo.WithTx(tx)
+
+		if err := repo.CreateCampaign(ctx, c); err != nil {
+			return err
+		}
+
+		for i := range items {
+			item := &items[i]
+			item.CampaignID = c.ID
+			item.ReviewerID = s.reviewerFor(ctx, c, item, owners)
+			if err := repo.AddItem(ctx, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	slog.InfoContext(ctx, "access review launched", "campaign_id", c.ID, "items", len(items))
+	return len(items), nil
+}
+
+func (s *AccessReviewService) validate(ctx context.Context, c *models.AccessReviewCampaign) error {
+	if c.OverdueAction == "" {
+		c.OverdueAction = models.OverdueRevoke
+	}
+	if c.OverdueAction != models.OverdueRevoke && c.OverdueAction != models.OverdueEscalate {
+		return fmt.Errorf("%w: overdue_action must be revoke or escalate", ErrInvalidCampaign)
+	}
+	if c.EscalationGraceHours <= 0 {
+		c.EscalationGraceHours = 72
+	}
+	if !c.DueAt.After(time.Now()) {
+		return fmt.Errorf("%w: due_at must be in the future", ErrInvalidCampaign)
+	}
+
+	if c.ScopeRole != nil {
+		role := roles.Role(*c.ScopeRole)
+		if !roles.IsGlobalRole(role) && !roles.IsResourceRole(role) {
+			return fmt.Errorf("%w: unknown role %s", ErrInvalidCampaign, role)
+		}
+	}
+	if c.ScopeProjectID != nil {
+		if _, err := s.projectRepo.FindById(ctx, *c.ScopeProjectID); err != nil {
+			return fmt.Errorf("%w: project not found", ErrInvalidCampaign)
+		}
+	}
+
+	for _, userID := range []*string{c.ReviewerID, c.EscalationReviewerID} {
+		if userID == nil {
+			continue
+		}
+		if _, err := s.userRepo.FindById(ctx, *userID); err != nil {
+			return fmt.Errorf("%w: reviewer %s not found", ErrInvalidCampaign, *userID)
+		}
+	}
+
+	return nil
+}
+
+// reviewerFor picks the campaign reviewer, else an owner of the item's project, else the
+// campaign creator. Nobody is picked to review their own assignment if avoidable.
+func (s *AccessReviewService) reviewerFor(ctx context.Context, c *models.AccessReviewCampaign, item *models.AccessReviewItem, owners map[string][]string) string {
+	if c.ReviewerID != nil && *c.ReviewerID != item.UserID {
+		return *c.ReviewerID
+	}
+
+	if item.ProjectID != nil {
+		projectOwners, ok := owners[*item.ProjectID]
+		if !ok {
+			members, err := s.userRoleRepo.GetResourceMembers(ctx, *item.ProjectID)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to load project owners", "project_id", *item.ProjectID, "error", err)
+			}
+			for _, m := range members {
+				if roles.Role(m.Role) == roles.RoleProjectOwner {
+					projectOwners = append(projectOwners, m.UserID)
+				}
+			}
+			owners[*item.ProjectID] = projectOwners
+		}
+		for _, owner := range projectOwners {
+			if owner != item.UserID {
+				return owner
+			}
+		}
+	}
+
+	return c.CreatedBy
+}
+
+func (s *AccessReviewService) ListCampaigns(ctx context.Context, status string) ([]models.AccessReviewCampaign, error) {
+	return s.repo.ListCampaigns(ctx, status)
+}
+
+func (s *AccessReviewService) GetCampaign(ctx context.Context, id string) (*models.AccessReviewCampaign, []models.AccessReviewItem, error) {
+	c, err := s.repo.GetCampaign(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	items, err := s.repo.ListItems(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return c, items, nil
+}
+
+// ReviewerTasks returns the pending items assigned to the reviewer.
+func (s *AccessReviewService) ReviewerTasks(ctx context.Context, reviewerID string) ([]models.AccessReviewItem, error) {
+	return s.repo.ListReviewerTasks(ctx, reviewerID)
+}
+
+// Decide records a reviewer's keep or revoke decision. Admins may decide any item except
+// reviews of their own access.
+func (s *AccessReviewService) Decide(ctx context.Context, itemID, reviewerID, decision string, comment *string, isAdmin bool) (err error) {
+	ctx, span := s.tracer.Start(ctx, "AccessReviewService.Decide")
+	defer func() { tracing.End(span, err) }()
+
+	if decision != models.ReviewKeep && decision != models.ReviewRevoke {
+		return fmt.Errorf("decision must be keep or revoke")
+	}
+
+	item, err := s.repo.GetItem(ctx, itemID)
+	if err != nil {
+		return err
+	}
+
+	if item.UserID == reviewerID || (item.ReviewerID != reviewerID && !isAdmin) {
+		return ErrNotReviewer
+	}
+
+	c, err := s.repo.GetCampaign(ctx, item.CampaignID)
+	if err != nil {
+		return err
+	}
+	if c.Status != models.CampaignActive {
+		return fmt.Errorf("campaign is already completed")
+	}
+
+	var revoked *roles.UserRole
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		repo := s.repo.WithTx(tx)
+
+		if err := repo.Decide(ctx, item.ID, decision, &reviewerID, comment, false); err != nil {
+			return err
+		}
+
+		if decision == models.ReviewRevoke {
+			var err error
+			if revoked, err = s.revoke(ctx, tx, item); err != nil {
+				return err
+			}
+		}
+
+		_, err := repo.CompleteCampaign(ctx, c.ID)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.revoked(ctx, item, revoked, &reviewerID)
+	return nil
+}
+
+// ProcessOverdue handles unanswered items of campaigns past their due date. Items are
+// auto-revoked, or for escalating campaigns first handed to the escalation reviewer and
+// auto-revoked if still unanswered after the grace period. It returns the number of items
+// changed.
+func (s *AccessReviewService) ProcessOverdue(ctx context.Context) (changed int, err error) {
+	ctx, span := s.tracer.Start(ctx, "AccessReviewService.ProcessOverdue")
+	defer func() { tracing.End(span, err) }()
+
+	campaigns, err := s.repo.ListOverdueCampaigns(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := range campaigns {
+		c := &campaigns[i]
+
+		pending, err := s.repo.ListPendingItems(ctx, c.ID)
+		if err != nil {
+			return changed, err
+		}
+
+		for j := range pending {
+			item := &pending[j]
+
+			if c.OverdueAction == models.OverdueEscalate {
+				if item.EscalatedAt == nil {
+					if err := s.repo.Escalate(ctx, item.ID, s.escalationReviewer(c, item)); err != nil {
+						return changed, err
+					}
+					changed++
+					continue
+				}
+				if time.Since(*item.EscalatedAt) < time.Duration(c.EscalationGraceHours)*time.Hour {
+					continue
+				}
+			}
+
+			comment := "auto-revoked: no decision before the campaign deadline"
+			var revoked *roles.UserRole
+			err := s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+				if err := s.repo.WithTx(tx).Decide(ctx, item.ID, models.ReviewRevoke, nil, &comment, true); err != nil {
+					return err
+				}
+				var err error
+				revoked, err = s.revoke(ctx, tx, item)
+				return err
+			})
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to auto-revoke review item", "item_id", item.ID, "error", err)
+				continue
+			}
+			s.revoked(ctx, item, revoked, nil)
+			changed++
+		}
+
+		if done, err := s.repo.CompleteCampaign(ctx, c.ID); err != nil {
+			return changed, err
+		} else if done {
+			slog.InfoContext(ctx, "access review completed", "campaign_id", c.ID)
+		}
+	}
+
+	return changed, nil
+}
+
+func (s *AccessReviewService) escalationReviewer(c *models.AccessReviewCampaign, item *models.AccessReviewItem) string {
+	if c.EscalationReviewerID != nil && *c.EscalationReviewerID != item.UserID {
+		return *c.EscalationReviewerID
+	}
+	return c.CreatedBy
+}
+
+// revoke removes the reviewed assignment inside tx and returns it. It may already be gone,
+// which is not an error; the result is then nil.
+func (s *AccessReviewService) revoke(ctx context.Context, tx *sql.Tx, item *models.AccessReviewItem) (*roles.UserRole, error) {
+	if item.UserRoleID == nil {
+		return nil, nil
+	}
+	revoked, err := s.rbacService.revokeRoleTx(ctx, tx, *item.UserRoleID)
+	if errors.Is(err, repository.ErrRoleNotFound) {
+		slog.InfoContext(ctx, "review item assignment already revoked", "item_id", item.ID, "user_role_id", *item.UserRoleID)
+		return nil, nil
+	}
+	return revoked, err
+}
+
+// revoked announces and audits a revocation once its decision has committed. actorID is
+// nil for automatic revocations.
+func (s *AccessReviewService) revoked(ctx context.Context, item *models.AccessReviewItem, revoked *roles.UserRole, actorID *string) {
+	if revoked == nil {
+		return
+	}
+	s.rbacService.roleRevoked(ctx, revoked)
+	s.audit.Record(ctx, &models.AuditEvent{
+		ActorID:    actorID,
+		Action:     models.AuditRoleRevoke,
+		TargetType: models.AuditTargetUserRole,
+		TargetID:   revoked.ID,
+		Before:     AuditState(revoked),
+	})
+	slog.InfoContext(ctx, "review item revoked", "item_id", item.ID, "role", item.Role, "user_id", item.UserID)
+}
+
+// SummarizeAccessReview counts the items by outcome.
+func SummarizeAccessReview(items []models.AccessReviewItem) models.AccessReviewSummary {
+	summary := models.AccessReviewSummary{Total: len(items)}
+	for _, item := range items {
+		if item.EscalatedAt != nil {
+			summary.Escalated++
+		}
+		switch {
+		case item.Decision == nil:
+			summary.Pending++
+		case *item.Decision == models.ReviewKeep:
+			summary.Kept++
+		case item.AutoDecided:
+			summary.AutoRevoked++
+		default:
+			summary.Revoked++
+		}
+	}
+	return summary
+}
+
+// WriteAccessReviewCSV writes one row per item, in the order given.
+func WriteAccessReviewCSV(w io.Writer, items []models.AccessReviewItem) error {
+	cw := csv.NewWriter(w)
+
+	header := []string{"item_id", "user_id", "username", "role", "project_id", "reviewer_id",
+		"decision", "decided_by", "decided_at", "auto_decided", "escalated_at", "comment"}
+	if err := cw.Write(header); err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		decision := "pending"
+		if item.Decision != nil {
+			decision = *item.Decision
+		}
+		record := []string{
+			item.ID,
+			item.UserID,
+			item.Username,
+			item.Role,
+			deref(item.ProjectID),
+			item.ReviewerID,
+			decision,
+			deref(item.DecidedBy),
+			formatTime(item.DecidedAt),
+			fmt.Sprintf("%t", item.AutoDecided),
+			formatTime(item.EscalatedAt),
+			deref(item.Comment),
+		}
+		for i := range record {
+			record[i] = csvCell(record[i])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvCell keeps spreadsheets from evaluating a cell as a formula by prefixing values that
+// start with a formula character with a quote.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsRune("=+-@", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
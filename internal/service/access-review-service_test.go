@@ -0,0 +1,57 @@
+package service
+
+import (
+	"AuthServer/internal/domain/models"
+	"bytes"
+	"encoding/csv"
+	"testing"
+	"time"
+)
+
+func reviewItems() []models.AccessReviewItem {
+	keep, revoke := models.ReviewKeep, models.ReviewRevoke
+	formula := "=HYPERLINK(\"http://example.com\")"
+	escalated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	project := "p1"
+	return []models.AccessReviewItem{
+		{ID: "i1", UserID: "u1", Username: "alice", Role: "manager", ReviewerID: "r1", Decision: &keep},
+		{ID: "i2", UserID: "u2", Username: "bob", Role: "project_editor", ProjectID: &project, ReviewerID: "r1", Decision: &revoke},
+		{ID: "i3", UserID: "u3", Username: "carol", Role: "user", ReviewerID: "r2", Decision: &revoke, AutoDecided: true, EscalatedAt: &escalated},
+		{ID: "i4", UserID: "u4", Username: "dave", Role: "user", ReviewerID: "r2", Comment: &formula},
+	}
+}
+
+func TestSummarizeAccessReview(t *testing.T) {
+	got := SummarizeAccessReview(reviewItems())
+	want := models.AccessReviewSummary{Total: 4, Kept: 1, Revoked: 1, AutoRevoked: 1, Pending: 1, Escalated: 1}
+	if got != want {
+		t.Fatalf("expected %+v, got %+v", want, got)
+	}
+}
+
+func TestWriteAccessReviewCSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := WriteAccessReviewCSV(&buf, reviewItems()); err != nil {
+		t.Fatal(err)
+	}
+
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(records) != 5 {
+		t.Fatalf("expected header and 4 rows, got %d", len(records))
+	}
+	if records[2][4] != "p1" || records[2][6] != "revoke" {
+		t.Fatalf("unexpected row %v", records[2])
+	}
+	if records[3][9] != "true" || records[3][10] != "2026-01-02T03:04:05Z" {
+		t.Fatalf("unexpected row %v", records[3])
+	}
+	if records[4][6] != "pending" {
+		t.Fatalf("expected pending decision, got %v", records[4])
+	}
+	if records[4][11] != `'=HYPERLINK("http://example.com")` {
+		t.Fatalf("expected the formula to be quoted, got %q", records[4][11])
+	}
+}
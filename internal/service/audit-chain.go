@@ -0,0 +1,280 @@
+package service
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/tracing"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// auditChainLock serialises appends so that every event links to the one before it.
+const auditChainLock = "audit-chain"
+
+// auditVerifyBatch is the number of events read at a time while verifying the chain.
+const auditVerifyBatch = 1000
+
+// ErrNoAuditSigningKey is returned when a checkpoint is needed but no signing key is set.
+var ErrNoAuditSigningKey = errors.New("audit signing key is not configured")
+
+// auditHashInput lists the hashed fields in a fixed order. Times are UTC at microsecond
+// precision, matching what the database stores.
+type auditHashInput struct {
+	ID         int64   `json:"id"`
+	OccurredAt string  `json:"occurred_at"`
+	ActorID    *string `json:"actor_id"`
+	Action     string  `json:"action"`
+	TargetType string  `json:"target_type"`
+	TargetID   string  `json:"target_id"`
+	Outcome    string  `json:"outcome"`
+	Reason     *string `json:"reason"`
+	Before     any     `json:"before"`
+	After      any     `json:"after"`
+	IP         string  `json:"ip"`
+	UserAgent  string  `json:"user_agent"`
+	RequestID  string  `json:"request_id"`
+}
+
+// HashAuditEvent returns the hex SHA-256 of prevHash followed by the event's contents. The
+// stored PrevHash and Hash of e are not part of the input.
+func HashAuditEvent(prevHash string, e *models.AuditEvent) (string, error) {
+	before, err := canonicalJSON(e.Before)
+	if err != nil {
+		return "", fmt.Errorf("before state: %w", err)
+	}
+	after, err := canonicalJSON(e.After)
+	if err != nil {
+		return "", fmt.Errorf("after state: %w", err)
+	}
+
+	body, err := json.Marshal(auditHashInput{
+		ID:         e.ID,
+		OccurredAt: e.OccurredAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano),
+		ActorID:    e.ActorID,
+		Action:     e.Action,
+		TargetType: e.TargetType,
+		TargetID:   e.TargetID,
+		Outcome:    e.Outcome,
+		Reason:     e.Reason,
+		Before:     before,
+		After:      after,
+		IP:         e.IP,
+		UserAgent:  e.UserAgent,
+		RequestID:  e.RequestID,
+	})
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	h.Write([]byte(prevHash))
+	h.Write([]byte{'\n'})
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// canonicalJSON decodes raw so that it re-encodes with sorted keys and no insignificant
+// whitespace. JSONB does not keep the original formatting, so the raw bytes cannot be hashed.
+func canonicalJSON(raw json.RawMessage) (any, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+
+	var v any
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// AuditKeyID identifies a checkpoint key in checkpoints without revealing it.
+func AuditKeyID(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SignAuditCheckpoint returns the hex HMAC-SHA256 of the checkpoint's event ID, event hash and
+// creation time under key.
+func SignAuditCheckpoint(key []byte, cp *models.AuditCheckpoint) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(strconv.FormatInt(cp.EventID, 10)))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(cp.EventHash))
+	mac.Write([]byte{'\n'})
+	mac.Write([]byte(cp.CreatedAt.UTC().Truncate(time.Microsecond).Format(time.RFC3339Nano)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyAuditCheckpoint reports whether the checkpoint was signed with key.
+func VerifyAuditCheckpoint(key []byte, cp *models.AuditCheckpoint) bool {
+	expected, err := hex.DecodeString(SignAuditCheckpoint(key, cp))
+	if err != nil {
+		return false
+	}
+	got, err := hex.DecodeString(cp.Signature)
+	if err != nil {
+		return false
+	}
+	return hmac.Equal(expected, got)
+}
+
+// Checkpoint signs the hash of the newest chained event. It returns nil if no event has been
+// written since the last checkpoint.
+func (s *AuditService) Checkpoint(ctx context.Context) (created *models.AuditCheckpoint, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuditService.Checkpoint")
+	defer func() { tracing.End(span, err) }()
+
+	if len(s.key) == 0 {
+		return nil, ErrNoAuditSigningKey
+	}
+
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		repo := s.repo.WithTx(tx)
+		if err := database.LockKey(ctx, tx, auditChainLock); err != nil {
+			return err
+		}
+
+		last, err := repo.Last(ctx)
+		if err != nil || last == nil {
+			return err
+		}
+		previous, err := repo.LastCheckpoint(ctx)
+		if err != nil {
+			return err
+		}
+		if previous != nil && previous.EventID == last.ID {
+			return nil
+		}
+
+		cp := &models.AuditCheckpoint{
+			EventID:   last.ID,
+			EventHash: last.Hash,
+			KeyID:     AuditKeyID(s.key),
+			CreatedAt: time.Now().UTC().Truncate(time.Microsecond),
+		}
+		cp.Signature = SignAuditCheckpoint(s.key, cp)
+		if err := repo.CreateCheckpoint(ctx, cp); err != nil {
+			return err
+		}
+		created = cp
+		return nil
+	})
+	return created, err
+}
+
+// Checkpoints returns every checkpoint, oldest first, for export.
+func (s *AuditService) Checkpoints(ctx context.Context) ([]models.AuditCheckpoint, error) {
+	return s.repo.ListCheckpoints(ctx)
+}
+
+// Verify walks the chain from the oldest event and stops at the first broken link: an event
+// whose contents no longer match its hash, whose prev_hash does not match the event before it,
+// or that follows a deletion. Checkpoints must carry a valid signature and match the event
+// they name, which catches events removed from the end of the log.
+func (s *AuditService) Verify(ctx context.Context) (result *models.AuditVerification, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuditService.Verify")
+	defer func() { tracing.End(span, err) }()
+
+	checkpoints, err := s.repo.ListCheckpoints(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return s.verify(ctx, checkpoints)
+}
+
+// VerifyAgainst verifies the chain against checkpoints exported earlier, rather than those
+// currently in the database.
+func (s *AuditService) VerifyAgainst(ctx context.Context, checkpoints []models.AuditCheckpoint) (result *models.AuditVerification, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuditService.VerifyAgainst")
+	defer func() { tracing.End(span, err) }()
+
+	return s.verify(ctx, checkpoints)
+}
+
+func (s *AuditService) verify(ctx context.Context, checkpoints []models.AuditCheckpoint) (*models.AuditVerification, error) {
+	result := &models.AuditVerification{OK: true}
+	broken := func(eventID, checkpointID int64, problem string) (*models.AuditVerification, error) {
+		result.OK = false
+		result.BrokenAt = eventID
+		result.CheckpointID = checkpointID
+		result.Problem = problem
+		return result, nil
+	}
+
+	pending := make(map[int64][]models.AuditCheckpoint)
+	for _, cp := range checkpoints {
+		key, ok := s.verifyKeys[cp.KeyID]
+		if !ok {
+			return broken(cp.EventID, cp.ID, "checkpoint was signed with a key that is not configured")
+		}
+		if !VerifyAuditCheckpoint(key, &cp) {
+			return broken(cp.EventID, cp.ID, "checkpoint signature is invalid")
+		}
+		pending[cp.EventID] = append(pending[cp.EventID], cp)
+	}
+
+	var afterID int64
+	prevHash := ""
+	chained := false
+	for {
+		events, err := s.repo.Scan(ctx, afterID, auditVerifyBatch)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			break
+		}
+
+		for i := range events {
+			e := &events[i]
+			afterID = e.ID
+
+			if e.Hash == "" {
+				if chained {
+					return broken(e.ID, 0, "event has no hash but follows chained events")
+				}
+				result.UnchainedEvents++
+				continue
+			}
+			chained = true
+			result.EventsChecked++
+
+			if e.PrevHash != prevHash {
+				return broken(e.ID, 0, "prev_hash does not match the preceding event; an event was removed or altered")
+			}
+			hash, err := HashAuditEvent(prevHash, e)
+			if err != nil {
+				return broken(e.ID, 0, "event state cannot be decoded: "+err.Error())
+			}
+			if hash != e.Hash {
+				return broken(e.ID, 0, "event contents do not match its hash")
+			}
+
+			for _, cp := range pending[e.ID] {
+				if cp.EventHash != e.Hash {
+					return broken(e.ID, cp.ID, "event hash does not match the signed checkpoint")
+				}
+				result.CheckpointsChecked++
+			}
+			delete(pending, e.ID)
+
+			prevHash = e.Hash
+		}
+	}
+
+	for _, cp := range checkpoints {
+		if _, missing := pending[cp.EventID]; missing {
+			return broken(cp.EventID, cp.ID, "checkpointed event is missing; the log was truncated")
+		}
+	}
+
+	return result, nil
+}
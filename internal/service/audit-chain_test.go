@@ -0,0 +1,85 @@
+package service
+
+import (
+	"AuthServer/internal/domain/models"
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestHashAuditEvent(t *testing.T) {
+	actor := "u1"
+	e := models.AuditEvent{
+		ID:         7,
+		OccurredAt: time.Date(2024, 5, 1, 12, 0, 0, 123456789, time.UTC),
+		ActorID:    &actor,
+		Action:     models.AuditRoleAssign,
+		TargetType: models.AuditTargetUserRole,
+		TargetID:   "r1",
+		Outcome:    models.AuditSuccess,
+		After:      json.RawMessage(`{"role": "admin", "user_id": "u2"}`),
+	}
+
+	hash, err := HashAuditEvent("prev", &e)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// JSONB reorders keys and drops whitespace, and timestamps lose their nanoseconds.
+	stored := e
+	stored.After = json.RawMessage(`{"user_id":"u2","role":"admin"}`)
+	stored.OccurredAt = time.Date(2024, 5, 1, 14, 0, 0, 123456000, time.FixedZone("", 2*3600))
+	if got, _ := HashAuditEvent("prev", &stored); got != hash {
+		t.Fatalf("hash changed after a database round trip: %s != %s", got, hash)
+	}
+
+	if got, _ := HashAuditEvent("other", &e); got == hash {
+		t.Fatal("hash does not depend on the previous hash")
+	}
+	tampered := e
+	tampered.TargetID = "r2"
+	if got, _ := HashAuditEvent("prev", &tampered); got == hash {
+		t.Fatal("hash does not depend on the event contents")
+	}
+}
+
+func TestAuditCheckpointSignature(t *testing.T) {
+	key := []byte("secret")
+	cp := models.AuditCheckpoint{
+		EventID:   42,
+		EventHash: "abc",
+		KeyID:     AuditKeyID(key),
+		CreatedAt: time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC),
+	}
+	cp.Signature = SignAuditCheckpoint(key, &cp)
+
+	if !VerifyAuditCheckpoint(key, &cp) {
+		t.Fatal("valid checkpoint rejected")
+	}
+	if VerifyAuditCheckpoint([]byte("other"), &cp) {
+		t.Fatal("checkpoint accepted under a different key")
+	}
+
+	moved := cp
+	moved.EventID = 41
+	if VerifyAuditCheckpoint(key, &moved) {
+		t.Fatal("checkpoint accepted after its event ID changed")
+	}
+}
+
+func TestVerifyRejectsUnconfiguredCheckpointKey(t *testing.T) {
+	s := NewAuditService(nil, nil, []byte("configured"), [][]byte{[]byte("previous")}, nil)
+
+	forged := []byte("inserted-into-the-database")
+	cp := models.AuditCheckpoint{ID: 1, EventID: 42, EventHash: "abc", KeyID: AuditKeyID(forged), CreatedAt: time.Now()}
+	cp.Signature = SignAuditCheckpoint(forged, &cp)
+
+	result, err := s.verify(context.Background(), []models.AuditCheckpoint{cp})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.OK || result.CheckpointID != 1 {
+		t.Fatalf("checkpoint signed with an unconfigured key accepted: %+v", result)
+	}
+}
@@ -0,0 +1,138 @@
+package service
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/repository"
+	"AuthServer/internal/tracing"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log/slog"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Audit page sizes.
+const (
+	DefaultAuditPageSize = 50
+	MaxAuditPageSize     = 500
+)
+
+// AuditService writes and queries the append-only audit log. Each event is hash-chained to
+// the one before it, and checkpoints of the chain head are signed with a key from
+// configuration that is never stored, so database access alone cannot re-sign an edited log.
+// Each checkpoint names its key; checkpoints verify only against configured keys.
+type AuditService struct {
+	tx         database.Transactor
+	repo       *repository.AuditRepository
+	key        []byte
+	verifyKeys map[string][]byte
+	throttle   failureThrottle
+	tracer     trace.Tracer
+}
+
+// NewAuditService returns a service that signs checkpoints with key and verifies them with key
+// or any of previous.
+func NewAuditService(tx database.Transactor, repo *repository.AuditRepository, key []byte, previous [][]byte, tracer trace.Tracer) *AuditService {
+	verifyKeys := make(map[string][]byte, len(previous)+1)
+	for _, k := range previous {
+		verifyKeys[AuditKeyID(k)] = k
+	}
+	if len(key) > 0 {
+		verifyKeys[AuditKeyID(key)] = key
+	}
+	return &AuditService{
+		tx:         tx,
+		repo:       repo,
+		key:        key,
+		verifyKeys: verifyKeys,
+		tracer:     tracer,
+	}
+}
+
+// Record appends the event to the chain. It is written even if ctx was cancelled after the
+// audited action completed. Errors are logged as well as returned, so callers may ignore them.
+// Failures without an actor are limited per client IP and in total; those over the limits
+// are only counted, see RecordSuppressed.
+func (s *AuditService) Record(ctx context.Context, e *models.AuditEvent) (err error) {
+	ctx, span := s.tracer.Start(ctx, "AuditService.Record")
+	defer func() { tracing.End(span, err) }()
+
+	if e.Outcome == "" {
+		e.Outcome = models.AuditSuccess
+	}
+
+	if e.ActorID == nil && e.Outcome == models.AuditFailure && !s.throttle.allow(e.IP, time.Now()) {
+		slog.DebugContext(ctx, "anonymous audit failure suppressed", "action", e.Action, "ip", e.IP)
+		return nil
+	}
+	return s.record(ctx, e)
+}
+
+func (s *AuditService) record(ctx context.Context, e *models.AuditEvent) error {
+	ctx = context.WithoutCancel(ctx)
+	err := s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		repo := s.repo.WithTx(tx)
+		if err := database.LockKey(ctx, tx, auditChainLock); err != nil {
+			return err
+		}
+
+		last, err := repo.Last(ctx)
+		if err != nil {
+			return err
+		}
+		if last != nil {
+			e.PrevHash = last.Hash
+		}
+
+		if e.ID, err = repo.NextID(ctx); err != nil {
+			return err
+		}
+		e.OccurredAt = time.Now().UTC().Truncate(time.Microsecond)
+		if e.Hash, err = HashAuditEvent(e.PrevHash, e); err != nil {
+			return err
+		}
+
+		return repo.Append(ctx, e)
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record audit event", "action", e.Action, "target_type", e.TargetType, "target_id", e.TargetID, "error", err)
+		return err
+	}
+	return nil
+}
+
+// List returns one page of events, newest first, and the total number of matches.
+func (s *AuditService) List(ctx context.Context, f models.AuditFilter) ([]models.AuditEvent, int, error) {
+	if f.Limit <= 0 {
+		f.Limit = DefaultAuditPageSize
+	}
+	if f.Limit > MaxAuditPageSize {
+		f.Limit = MaxAuditPageSize
+	}
+	if f.Offset < 0 {
+		f.Offset = 0
+	}
+
+	return s.repo.List(ctx, f)
+}
+
+// AuditState encodes a before or after state. A nil value, or one that cannot be encoded,
+// is recorded as no state.
+func AuditState(v any) json.RawMessage {
+	if v == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("failed to encode audit state", "error", err)
+		return nil
+	}
+	if string(raw) == "null" {
+		return nil
+	}
+	return raw
+}
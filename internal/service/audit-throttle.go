@@ -0,0 +1,82 @@
+package service
+
+import (
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/tracing"
+	"context"
+	"sync"
+	"time"
+)
+
+// Limits on the anonymous failure events each server chains in a window, per client IP and in
+// total. Failed logins and registrations need no account, so without them anyone could grow
+// the log and hold the chain lock at will.
+const (
+	anonymousFailureWindow = time.Minute
+	anonymousFailuresPerIP = 10
+	anonymousFailuresTotal = 300
+)
+
+// failureThrottle decides which anonymous failure events are chained. Events over the limits
+// are only counted, and the count is chained as one summary event by RecordSuppressed.
+type failureThrottle struct {
+	mu          sync.Mutex
+	windowStart time.Time
+	total       int
+	perIP       map[string]int // bounded by anonymousFailuresTotal
+	suppressed  int
+	since       time.Time
+}
+
+// allow reports whether an anonymous failure from ip may be chained at now.
+func (t *failureThrottle) allow(ip string, now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if now.Sub(t.windowStart) >= anonymousFailureWindow {
+		t.windowStart = now
+		t.total = 0
+		t.perIP = make(map[string]int)
+	}
+
+	if t.total >= anonymousFailuresTotal || t.perIP[ip] >= anonymousFailuresPerIP {
+		if t.suppressed == 0 {
+			t.since = now
+		}
+		t.suppressed++
+		return false
+	}
+	t.total++
+	t.perIP[ip]++
+	return true
+}
+
+// takeSuppressed returns the number of events suppressed since the last call, and when the
+// first of them was, and resets the count.
+func (t *failureThrottle) takeSuppressed() (int, time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	n, since := t.suppressed, t.since
+	t.suppressed = 0
+	return n, since
+}
+
+// RecordSuppressed chains one event counting the anonymous failures left out of the log since
+// the last call. It returns that count.
+func (s *AuditService) RecordSuppressed(ctx context.Context) (n int, err error) {
+	ctx, span := s.tracer.Start(ctx, "AuditService.RecordSuppressed")
+	defer func() { tracing.End(span, err) }()
+
+	n, since := s.throttle.takeSuppressed()
+	if n == 0 {
+		return 0, nil
+	}
+
+	err = s.record(ctx, &models.AuditEvent{
+		Action:  models.AuditFailuresSuppressed,
+		Outcome: models.AuditFailure,
+		After:   AuditState(map[string]any{"count": n, "since": since.UTC()}),
+	})
+	return n, err
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestFailureThrottle(t *testing.T) {
+	var throttle failureThrottle
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	for i := 0; i < anonymousFailuresPerIP; i++ {
+		if !throttle.allow("10.0.0.1", now) {
+			t.Fatalf("failure %d refused within the per-IP limit", i)
+		}
+	}
+	if throttle.allow("10.0.0.1", now) {
+		t.Fatal("failure over the per-IP limit allowed")
+	}
+
+	for i := anonymousFailuresPerIP; i < anonymousFailuresTotal; i++ {
+		throttle.allow("10.1."+strconv.Itoa(i/256)+"."+strconv.Itoa(i%256), now)
+	}
+	if throttle.allow("10.2.0.1", now) {
+		t.Fatal("failure over the total limit allowed")
+	}
+
+	if n, since := throttle.takeSuppressed(); n != 2 || !since.Equal(now) {
+		t.Fatalf("takeSuppressed = %d, %s; want 2 since %s", n, since, now)
+	}
+	if n, _ := throttle.takeSuppressed(); n != 0 {
+		t.Fatalf("suppressed count not reset, got %d", n)
+	}
+
+	if !throttle.allow("10.0.0.1", now.Add(anonymousFailureWindow)) {
+		t.Fatal("failure refused in a new window")
+	}
+}
@@ -0,0 +1,297 @@
+package service
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/events"
+	"AuthServer/internal/repository"
+	"AuthServer/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"html"
+	"log/slog"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrBreakGlassDisabled is returned when break-glass access is not enabled.
+	ErrBreakGlassDisabled = errors.New("break-glass access is disabled")
+	// ErrBreakGlassNotAllowed is returned when the user is outside the configured break-glass group.
+	ErrBreakGlassNotAllowed = errors.New("not allowed to use break-glass access")
+	// ErrBreakGlassActive is returned when the user already has an active session.
+	ErrBreakGlassActive = errors.New("break-glass session already active")
+	// ErrBreakGlassSelfReview is returned when a user tries to review their own session.
+	ErrBreakGlassSelfReview = errors.New("break-glass sessions must be reviewed by another user")
+	// ErrJustificationRequired is returned when the justification is missing or too short.
+	ErrJustificationRequired = errors.New("justification is too short")
+)
+
+// breakGlassActor is recorded as the grantor of emergency roles, so no-self-grant does not apply.
+const breakGlassActor = "system:break-glass"
+
+// BreakGlassService grants a preconfigured emergency role without approval, alerts the
+// configured recipients and keeps every session open for review until an admin closes it.
+type BreakGlassService struct {
+	tx           database.Transactor
+	config       roles.BreakGlassConfig
+	repo         *repository.BreakGlassRepository
+	userRoleRepo *repository.UserRoleRepository
+	groupRepo    *repository.GroupRepository
+	userRepo     repository.IUserRepository
+	rbacService  *RBACService
+	sod          *SoDService
+	mail         IMailService
+	bus          *events.Bus
+	tracer       trace.Tracer
+}
+
+func NewBreakGlassService(tx database.Transactor, config roles.BreakGlassConfig, repo *repository.BreakGlassRepository, userRoleRepo *repository.UserRoleRepository, groupRepo *repository.GroupRepository, userRepo repository.IUserRepository, rbacService *RBACService, sod *SoDService, mail IMailService, bus *events.Bus, tracer trace.Tracer) *BreakGlassService {
+	return &BreakGlassService{
+		tx:           tx,
+		config:       config,
+		repo:         repo,
+		userRoleRepo: userRoleRepo,
+		groupRepo:    groupRepo,
+		userRepo:     userRepo,
+		rbacService:  rbacService,
+		sod:          sod,
+		mail:         mail,
+		bus:          bus,
+		tracer:       tracer,
+	}
+}
+
+func (s *BreakGlassService) Config() roles.BreakGlassConfig {
+	return s.config
+}
+
+// Activate grants the emergency role to the user right away and fires the alerts.
+func (s *BreakGlassService) Activate(ctx context.Context, userID, justification string) (session *roles.BreakGlassSession, err error) {
+	ctx, span := s.tracer.Start(ctx, "BreakGlassService.Activate")
+	defer func() { tracing.End(span, err) }()
+
+	justification = strings.TrimSpace(justification)
+	if len(justification) < s.config.MinJustification {
+		return nil, ErrJustificationRequired
+	}
+
+	if err := s.checkAllowed(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		// The user lock also keeps two concurrent activations from opening two sessions
+		if err := lockUserRoles(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		repo := s.repo.WithTx(tx)
+
+		active, err := repo.FindActive(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if active != nil {
+			return ErrBreakGlassActive
+		}
+
+		existing, err := s.rbacService.userRolesTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := s.sod.CheckAssignment(ctx, breakGlassActor, userID, s.config.Role, nil, existing); err != nil {
+			return err
+		}
+
+		now := time.Now()
+		expiresAt := now.Add(s.config.Duration)
+
+		roleID, err := s.userRoleRepo.WithTx(tx).AssignRole(ctx, userID, s.config.Role, nil, &expiresAt, breakGlassActor, nil)
+		if err != nil {
+			return err
+		}
+
+		session = &roles.BreakGlassSession{
+			UserID:        userID,
+			Role:          s.config.Role,
+			Justification: justification,
+			GrantedRoleID: &roleID,
+			StartedAt:     now,
+			ExpiresAt:     expiresAt,
+		}
+		return repo.Create(ctx, session)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slog.WarnContext(ctx, "break-glass activated", "user_id", userID, "role", session.Role, "session_id", session.ID, "expires_at", session.ExpiresAt)
+	// The alerts outlive the request that activated the session
+	go s.alert(context.WithoutCancel(ctx), session)
+
+	return session, nil
+}
+
+func (s *BreakGlassService) checkAllowed(ctx context.Context, userID string) error {
+	if !s.config.Enabled {
+		return ErrBreakGlassDisabled
+	}
+	if s.config.AllowedGroup == nil {
+		return ErrBreakGlassNotAllowed
+	}
+
+	groups, err := s.groupRepo.FindUserGroups(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, g := range groups {
+		if g.ID == *s.config.AllowedGroup {
+			return nil
+		}
+	}
+
+	return ErrBreakGlassNotAllowed
+}
+
+// End stops an active session early and removes the emergency role. The session still
+// needs a review.
+func (s *BreakGlassService) End(ctx context.Context, sessionID, actorID string, isAdmin bool) (err error) {
+	ctx, span := s.tracer.Start(ctx, "BreakGlassService.End")
+	defer func() { tracing.End(span, err) }()
+
+	return s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		return s.end(ctx, tx, sessionID, actorID, isAdmin)
+	})
+}
+
+func (s *BreakGlassService) end(ctx context.Context, tx *sql.Tx, sessionID, actorID string, isAdmin bool) error {
+	repo := s.repo.WithTx(tx)
+
+	session, err := repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.UserID != actorID && !isAdmin {
+		return ErrNotRequestOwner
+	}
+
+	if err := repo.End(ctx, session.ID); err != nil {
+		return err
+	}
+
+	if session.GrantedRoleID != nil {
+		if err := s.userRoleRepo.WithTx(tx).RevokeRole(ctx, *session.GrantedRoleID); err != nil {
+			slog.WarnContext(ctx, "break-glass role already gone", "user_role_id", *session.GrantedRoleID, "session_id", session.ID, "error", err)
+		}
+	}
+
+	slog.WarnContext(ctx, "break-glass ended", "session_id", session.ID, "user_id", session.UserID, "ended_by", actorID)
+	return nil
+}
+
+// ActiveSession returns the user's session that is still in effect, or nil.
+// HoldsGrant reports whether userRoles include an assignment break-glass access could have
+// granted: the configured role, held directly, globally and with an expiry. Callers use it to
+// skip the session lookup for everybody else.
+func (s *BreakGlassService) HoldsGrant(userRoles []roles.UserRole) bool {
+	for _, ur := range userRoles {
+		if ur.Role == s.config.Role && ur.ResourceID == nil && ur.GroupID == nil && ur.ExpiresAt != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *BreakGlassService) ActiveSession(ctx context.Context, userID string) (*roles.BreakGlassSession, error) {
+	return s.repo.FindActive(ctx, userID)
+}
+
+func (s *BreakGlassService) RecordAccess(ctx context.Context, entry *roles.BreakGlassAccess) error {
+	return s.repo.LogAccess(ctx, entry)
+}
+
+func (s *BreakGlassService) ListSessions(ctx context.Context, reviewStatus string) ([]roles.BreakGlassSession, error) {
+	return s.repo.List(ctx, reviewStatus)
+}
+
+// GetSession returns a session together with the requests made while it was active.
+func (s *BreakGlassService) GetSession(ctx context.Context, id string) (*roles.BreakGlassSession, []roles.BreakGlassAccess, error) {
+	session, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	accessLog, err := s.repo.GetAccessLog(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return session, accessLog, nil
+}
+
+// CloseReview completes the mandatory review of a session. Active sessions are ended first
+// so a closed review always covers the complete access log.
+func (s *BreakGlassService) CloseReview(ctx context.Context, sessionID, reviewerID, notes string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "BreakGlassService.CloseReview")
+	defer func() { tracing.End(span, err) }()
+
+	session, err := s.repo.GetByID(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	if session.UserID == reviewerID {
+		return ErrBreakGlassSelfReview
+	}
+
+	return s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		if session.Active(time.Now()) {
+			if err := s.end(ctx, tx, session.ID, reviewerID, true); err != nil {
+				return err
+			}
+		}
+
+		return s.repo.WithTx(tx).CloseReview(ctx, session.ID, reviewerID, notes)
+	})
+}
+
+// alert notifies the configured email recipients and publishes the activation, which the
+// webhook service delivers to its subscribers. Failures are logged only; they must never
+// block emergency access.
+func (s *BreakGlassService) alert(ctx context.Context, session *roles.BreakGlassSession) {
+	user, err := s.userRepo.FindById(ctx, session.UserID)
+	if err != nil {
+		slog.ErrorContext(ctx, "break-glass alert: failed to load user", "user_id", session.UserID, "error", err)
+		user = &models.User{ID: session.UserID}
+	}
+
+	if len(s.config.AlertEmails) > 0 {
+		subject := fmt.Sprintf("[BREAK-GLASS] %s activated emergency %s access", headerText(user.Username), session.Role)
+		body := fmt.Sprintf(`<h2>Break-glass access activated</h2>
+<p><b>User:</b> %s (%s, %s)</p>
+<p><b>Role:</b> %s</p>
+<p><b>Active until:</b> %s</p>
+<p><b>Justification:</b> %s</p>
+<p>Session %s must be reviewed by an admin.</p>`,
+			html.EscapeString(user.Username), html.EscapeString(user.Email), session.UserID,
+			session.Role, session.ExpiresAt.Format(time.RFC1123),
+			html.EscapeString(session.Justification), session.ID)
+
+		if err := s.mail.Send(ctx, s.config.AlertEmails, subject, body); err != nil {
+			slog.ErrorContext(ctx, "break-glass alert: email failed", "session_id", session.ID, "error", err)
+		}
+	}
+
+	s.bus.Publish(ctx, events.BreakGlassActivated, session.UserID, map[string]any{
+		"session":  session,
+		"username": user.Username,
+		"email":    user.Email,
+	})
+}
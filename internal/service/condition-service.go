@@ -0,0 +1,124 @@
+package service
+
+import (
+	"AuthServer/internal/domain/roles"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+// ErrInvalidCondition is returned when a condition expression does not compile.
+var ErrInvalidCondition = errors.New("invalid condition expression")
+
+// maxConditionNodes keeps condition expressions small enough to evaluate on every request.
+const maxConditionNodes = 200
+
+// conditionEnv is the only data a condition expression can see. Expressions cannot
+// perform IO or call anything but the functions registered in conditionOptions.
+type conditionEnv struct {
+	Now      time.Time      `expr:"now"`
+	IP       string         `expr:"ip"`
+	User     map[string]any `expr:"user"`
+	Resource map[string]any `expr:"resource"`
+}
+
+var conditionOptions = []expr.Option{
+	expr.Env(conditionEnv{}),
+	expr.AsBool(),
+	expr.MaxNodes(maxConditionNodes),
+	expr.Function(
+		"inCIDR",
+		func(params ...any) (any, error) {
+			ip := net.ParseIP(params[0].(string))
+			if ip == nil {
+				return false, nil
+			}
+			_, network, err := net.ParseCIDR(params[1].(string))
+			if err != nil {
+				return false, err
+			}
+			return network.Contains(ip), nil
+		},
+		new(func(string, string) bool),
+	),
+	expr.Function(
+		"inTimezone",
+		func(params ...any) (any, error) {
+			loc, err := time.LoadLocation(params[1].(string))
+			if err != nil {
+				return nil, err
+			}
+			return params[0].(time.Time).In(loc), nil
+		},
+		new(func(time.Time, string) time.Time),
+	),
+}
+
+// ConditionEvaluator compiles and evaluates role assignment conditions such as
+//
+//	now.Hour() >= 9 && now.Hour() < 17
+//	inCIDR(ip, "10.20.0.0/16")
+//	user.email endsWith "@example.com"
+//	user.email_verified
+type ConditionEvaluator struct {
+	programs sync.Map // expression -> *vm.Program
+}
+
+func NewConditionEvaluator() *ConditionEvaluator {
+	return &ConditionEvaluator{}
+}
+
+// Validate compiles the expression and reports ErrInvalidCondition if it is malformed.
+func (e *ConditionEvaluator) Validate(expression string) error {
+	_, err := e.compile(expression)
+	return err
+}
+
+// Evaluate runs the expression against the access context.
+func (e *ConditionEvaluator) Evaluate(expression string, ac roles.AccessContext) (bool, error) {
+	program, err := e.compile(expression)
+	if err != nil {
+		return false, err
+	}
+
+	now := ac.Time
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	out, err := expr.Run(program, conditionEnv{
+		Now:      now,
+		IP:       ac.IP,
+		User:     ac.User,
+		Resource: ac.Resource,
+	})
+	if err != nil {
+		return false, fmt.Errorf("condition evaluation failed: %v", err)
+	}
+
+	result, ok := out.(bool)
+	if !ok {
+		return false, fmt.Errorf("condition did not evaluate to a boolean")
+	}
+
+	return result, nil
+}
+
+func (e *ConditionEvaluator) compile(expression string) (*vm.Program, error) {
+	if cached, ok := e.programs.Load(expression); ok {
+		return cached.(*vm.Program), nil
+	}
+
+	program, err := expr.Compile(expression, conditionOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCondition, err)
+	}
+
+	e.programs.Store(expression, program)
+	return program, nil
+}
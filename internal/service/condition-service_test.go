@@ -0,0 +1,50 @@
+package service
+
+import (
+	"AuthServer/internal/domain/roles"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestConditionEvaluator(t *testing.T) {
+	e := NewConditionEvaluator()
+	tuesdayNoon := time.Date(2025, 3, 4, 12, 0, 0, 0, time.UTC)
+	tuesdayNight := time.Date(2025, 3, 4, 22, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		expression string
+		ac         roles.AccessContext
+		want       bool
+	}{
+		{"business hours inside", "now.Hour() >= 9 && now.Hour() < 17", roles.AccessContext{Time: tuesdayNoon}, true},
+		{"business hours outside", "now.Hour() >= 9 && now.Hour() < 17", roles.AccessContext{Time: tuesdayNight}, false},
+		{"office cidr inside", `inCIDR(ip, "10.20.0.0/16")`, roles.AccessContext{IP: "10.20.3.4"}, true},
+		{"office cidr outside", `inCIDR(ip, "10.20.0.0/16")`, roles.AccessContext{IP: "192.168.1.1"}, false},
+		{"user attribute", `user.email endsWith "@example.com"`, roles.AccessContext{User: map[string]any{"email": "a@example.com"}}, true},
+		{"resource attribute", `resource.id == "p1"`, roles.AccessContext{Resource: map[string]any{"id": "p2"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := e.Evaluate(tt.expression, tt.ac)
+			if err != nil {
+				t.Fatalf("Evaluate returned error: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("Evaluate(%q) = %v, want %v", tt.expression, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConditionEvaluatorRejectsMalformed(t *testing.T) {
+	e := NewConditionEvaluator()
+
+	for _, expression := range []string{"now.Hour() >=", `"not a bool"`, "os.Exit(1)"} {
+		if err := e.Validate(expression); !errors.Is(err, ErrInvalidCondition) {
+			t.Errorf("Validate(%q) = %v, want ErrInvalidCondition", expression, err)
+		}
+	}
+}
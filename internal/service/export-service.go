@@ -0,0 +1,126 @@
+package service
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/repository"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// importActor is recorded as the creator of imported role assignments.
+const importActor = "system:import"
+
+// ExportService dumps and restores users, projects and direct role assignments.
+type ExportService struct {
+	tx           database.Transactor
+	userRepo     repository.IUserRepository
+	projectRepo  repository.IProjectRepository
+	userRoleRepo *repository.UserRoleRepository
+}
+
+func NewExportService(tx database.Transactor, userRepo repository.IUserRepository, projectRepo repository.IProjectRepository, userRoleRepo *repository.UserRoleRepository) *ExportService {
+	return &ExportService{
+		tx:           tx,
+		userRepo:     userRepo,
+		projectRepo:  projectRepo,
+		userRoleRepo: userRoleRepo,
+	}
+}
+
+// Export reads every user, project and active direct role assignment.
+func (s *ExportService) Export(ctx context.Context) (*models.Export, error) {
+	users, err := s.userRepo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+	projects, err := s.projectRepo.FindAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userRoles, err := s.userRoleRepo.ListAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	export := &models.Export{
+		Version:    models.ExportVersion,
+		ExportedAt: time.Now().UTC(),
+		Users:      make([]models.ExportedUser, 0, len(users)),
+		Projects:   projects,
+		Roles:      userRoles,
+	}
+	for _, u := range users {
+		export.Users = append(export.Users, models.ExportedUser{
+			ID:           u.ID,
+			FullName:     u.FullName,
+			Username:     u.Username,
+			Email:        u.Email,
+			PasswordHash: u.Password,
+			CreatedAt:    u.CreatedAt,
+			DisabledAt:   u.DisabledAt,
+		})
+	}
+	return export, nil
+}
+
+// Import inserts the exported records in one transaction, so a failure leaves the database
+// unchanged. Records whose ID already exists are skipped rather than overwritten.
+func (s *ExportService) Import(ctx context.Context, export *models.Export) (*models.ImportResult, error) {
+	if export.Version != models.ExportVersion {
+		return nil, fmt.Errorf("unsupported export version %d, expected %d", export.Version, models.ExportVersion)
+	}
+
+	result := &models.ImportResult{}
+	err := s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		userRepo := s.userRepo.WithTx(tx)
+		for _, u := range export.Users {
+			inserted, err := userRepo.Import(ctx, models.User{
+				ID:         u.ID,
+				FullName:   u.FullName,
+				Username:   u.Username,
+				Email:      u.Email,
+				Password:   u.PasswordHash,
+				CreatedAt:  u.CreatedAt,
+				DisabledAt: u.DisabledAt,
+			})
+			if err != nil {
+				return fmt.Errorf("user %s: %v", u.ID, err)
+			}
+			countImport(inserted, &result.UsersImported, &result.UsersSkipped)
+		}
+
+		projectRepo := s.projectRepo.WithTx(tx)
+		for _, p := range export.Projects {
+			inserted, err := projectRepo.Import(ctx, p)
+			if err != nil {
+				return fmt.Errorf("project %s: %v", p.ID, err)
+			}
+			countImport(inserted, &result.ProjectsImported, &result.ProjectsSkipped)
+		}
+
+		userRoleRepo := s.userRoleRepo.WithTx(tx)
+		for _, ur := range export.Roles {
+			inserted, err := userRoleRepo.Import(ctx, ur, importActor)
+			if err != nil {
+				return fmt.Errorf("role assignment %s: %v", ur.ID, err)
+			}
+			countImport(inserted, &result.RolesImported, &result.RolesSkipped)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func countImport(inserted bool, imported, skipped *int) {
+	if inserted {
+		*imported++
+	} else {
+		*skipped++
+	}
+}
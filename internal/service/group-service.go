@@ -0,0 +1,263 @@
+package service
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/repository"
+	"AuthServer/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// ErrGroupCycle is returned when adding a nested group would make a group contain itself.
+var ErrGroupCycle = errors.New("group membership would create a cycle")
+
+// groupsLock serializes the group changes that grant roles: new members, new nestings and
+// new group roles. Each would otherwise read memberships or roles another is changing, and
+// two could together form a cycle or break a separation-of-duties rule.
+const groupsLock = "groups"
+
+type GroupService struct {
+	tx          database.Transactor
+	groupRepo   *repository.GroupRepository
+	userRepo    repository.IUserRepository
+	rbacService *RBACService
+	sod         *SoDService
+	tracer      trace.Tracer
+}
+
+func NewGroupService(tx database.Transactor, groupRepo *repository.GroupRepository, userRepo repository.IUserRepository, rbacService *RBACService, sod *SoDService, tracer trace.Tracer) *GroupService {
+	return &GroupService{
+		tx:          tx,
+		groupRepo:   groupRepo,
+		userRepo:    userRepo,
+		rbacService: rbacService,
+		sod:         sod,
+		tracer:      tracer,
+	}
+}
+
+func (s *GroupService) Create(ctx context.Context, name, description, createdBy string) (created *models.Group, err error) {
+	ctx, span := s.tracer.Start(ctx, "GroupService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	group := models.Group{
+		ID:          uuid.New().String(),
+		Name:        name,
+		Description: description,
+		CreatedBy:   &createdBy,
+		CreatedAt:   time.Now(),
+	}
+
+	if err := s.groupRepo.Create(ctx, group); err != nil {
+		return nil, err
+	}
+
+	return &group, nil
+}
+
+func (s *GroupService) FindAll(ctx context.Context) ([]models.Group, error) {
+	return s.groupRepo.FindAll(ctx)
+}
+
+func (s *GroupService) FindById(ctx context.Context, id string) (*models.Group, error) {
+	return s.groupRepo.FindById(ctx, id)
+}
+
+func (s *GroupService) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "GroupService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return s.groupRepo.Delete(ctx, id)
+}
+
+func (s *GroupService) ListMembers(ctx context.Context, groupID string) ([]models.GroupMember, error) {
+	return s.groupRepo.ListMembers(ctx, groupID)
+}
+
+func (s *GroupService) GetGroupRoles(ctx context.Context, groupID string) ([]roles.UserRole, error) {
+	return s.groupRepo.GetGroupRoles(ctx, groupID)
+}
+
+// FindUserGroups returns the groups a user belongs to directly or through nesting.
+func (s *GroupService) FindUserGroups(ctx context.Context, userID string) ([]models.Group, error) {
+	return s.groupRepo.FindUserGroups(ctx, userID)
+}
+
+// AddUser adds a user to a group. Memberships that would give the user roles breaking a
+// separation-of-duties rule are rejected with a *roles.SoDViolation.
+func (s *GroupService) AddUser(ctx context.Context, groupID, userID, addedBy string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "GroupService.AddUser")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.groupRepo.FindById(ctx, groupID); err != nil {
+		return err
+	}
+	if _, err := s.userRepo.FindById(ctx, userID); err != nil {
+		return err
+	}
+
+	return s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := database.LockKey(ctx, tx, groupsLock); err != nil {
+			return err
+		}
+
+		groupRepo := s.groupRepo.WithTx(tx)
+
+		grants, err := groupRepo.GetMemberRoles(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkGrants(ctx, tx, addedBy, []string{userID}, grants); err != nil {
+			return err
+		}
+
+		return groupRepo.AddMember(ctx, groupID, models.MemberTypeUser, userID, addedBy)
+	})
+}
+
+// AddGroup nests childID inside groupID, refusing memberships that would form a cycle or
+// give a member of childID roles breaking a separation-of-duties rule.
+func (s *GroupService) AddGroup(ctx context.Context, groupID, childID, addedBy string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "GroupService.AddGroup")
+	defer func() { tracing.End(span, err) }()
+
+	if _, err := s.groupRepo.FindById(ctx, groupID); err != nil {
+		return err
+	}
+	if _, err := s.groupRepo.FindById(ctx, childID); err != nil {
+		return err
+	}
+
+	return s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := database.LockKey(ctx, tx, groupsLock); err != nil {
+			return err
+		}
+
+		groupRepo := s.groupRepo.WithTx(tx)
+
+		cycle, err := groupRepo.ContainsGroup(ctx, childID, groupID)
+		if err != nil {
+			return err
+		}
+		if cycle {
+			return ErrGroupCycle
+		}
+
+		members, err := groupRepo.UserMemberIDs(ctx, childID)
+		if err != nil {
+			return err
+		}
+		grants, err := groupRepo.GetMemberRoles(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		if err := s.checkGrants(ctx, tx, addedBy, members, grants); err != nil {
+			return err
+		}
+
+		return groupRepo.AddMember(ctx, groupID, models.MemberTypeGroup, childID, addedBy)
+	})
+}
+
+func (s *GroupService) RemoveMember(ctx context.Context, groupID, memberType, memberID string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "GroupService.RemoveMember")
+	defer func() { tracing.End(span, err) }()
+
+	return s.groupRepo.RemoveMember(ctx, groupID, memberType, memberID)
+}
+
+// AssignRole grants a role to every member of the group, globally when resourceID is nil.
+// It is rejected with a *roles.SoDViolation if the role would break a separation-of-duties
+// rule for any member.
+func (s *GroupService) AssignRole(ctx context.Context, groupID string, role roles.Role, resourceID *string, expiresAt *time.Time, assignedBy string, condition *string) (roleID string, err error) {
+	ctx, span := s.tracer.Start(ctx, "GroupService.AssignRole")
+	defer func() { tracing.End(span, err) }()
+
+	if resourceID == nil && !roles.IsGlobalRole(role) {
+		return "", fmt.Errorf("%s is not a valid global role", role)
+	}
+	if resourceID != nil && !roles.IsResourceRole(role) {
+		return "", fmt.Errorf("%s is not a valid resource-specific role", role)
+	}
+
+	if condition != nil && *condition == "" {
+		condition = nil
+	}
+	if condition != nil {
+		if err := s.rbacService.ValidateCondition(*condition); err != nil {
+			return "", err
+		}
+	}
+
+	if _, err := s.groupRepo.FindById(ctx, groupID); err != nil {
+		return "", err
+	}
+
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := database.LockKey(ctx, tx, groupsLock); err != nil {
+			return err
+		}
+
+		groupRepo := s.groupRepo.WithTx(tx)
+
+		members, err := groupRepo.UserMemberIDs(ctx, groupID)
+		if err != nil {
+			return err
+		}
+		grant := roles.UserRole{Role: role, ResourceID: resourceID, ExpiresAt: expiresAt, Condition: condition}
+		if err := s.checkGrants(ctx, tx, assignedBy, members, []roles.UserRole{grant}); err != nil {
+			return err
+		}
+
+		roleID, err = groupRepo.AssignRole(ctx, groupID, role, resourceID, expiresAt, assignedBy, condition)
+		return err
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return roleID, nil
+}
+
+// checkGrants runs the separation-of-duties check for each user about to receive grants
+// through a group. Each user's roles stay locked until tx ends, as for a direct assignment;
+// userIDs must be sorted so that concurrent callers take the locks in the same order.
+func (s *GroupService) checkGrants(ctx context.Context, tx *sql.Tx, actorID string, userIDs []string, grants []roles.UserRole) error {
+	if len(grants) == 0 {
+		return nil
+	}
+
+	for _, userID := range userIDs {
+		if err := lockUserRoles(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		existing, err := s.rbacService.userRolesTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		for _, grant := range grants {
+			if err := s.sod.CheckAssignment(ctx, actorID, userID, grant.Role, grant.ResourceID, existing); err != nil {
+				return err
+			}
+			existing = append(existing, grant)
+		}
+	}
+
+	return nil
+}
+
+func (s *GroupService) RevokeRole(ctx context.Context, groupID, roleID string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "GroupService.RevokeRole")
+	defer func() { tracing.End(span, err) }()
+
+	return s.groupRepo.RevokeRole(ctx, groupID, roleID)
+}
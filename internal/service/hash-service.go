@@ -1,29 +1,35 @@
 package service
 
 import (
+	"context"
 	"crypto/rand"
 	"crypto/sha256"
 	"crypto/subtle"
 	"encoding/hex"
 	"fmt"
-	"log"
+	"log/slog"
 	"math/big"
 	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 type IHashService interface {
-	HashPassword(password string) (string, error)
-	VerifyPassword(password, stored string) bool
+	HashPassword(ctx context.Context, password string) (string, error)
+	VerifyPassword(ctx context.Context, password, stored string) bool
 }
 
-type HashService struct{}
+type HashService struct {
+	tracer trace.Tracer
+}
 
 const saltCharset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 const saltLen = 16
 const iterations = 10000
 
-func NewHashService() IHashService {
-	return &HashService{}
+func NewHashService(tracer trace.Tracer) IHashService {
+	return &HashService{tracer: tracer}
 }
 
 func (h *HashService) generateSalt() (string, error) {
@@ -50,7 +56,10 @@ func (h *HashService) hash(password, salt string, iter int) string {
 	return hex.EncodeToString(hash[:])
 }
 
-func (h *HashService) HashPassword(password string) (string, error) {
+func (h *HashService) HashPassword(ctx context.Context, password string) (string, error) {
+	_, span := h.tracer.Start(ctx, "HashService.HashPassword", trace.WithAttributes(attribute.Int("hash.iterations", iterations)))
+	defer span.End()
+
 	salt, err := h.generateSalt()
 	if err != nil {
 		return "", err
@@ -61,7 +70,10 @@ func (h *HashService) HashPassword(password string) (string, error) {
 	return fmt.Sprintf("%s$%d$%s", salt, iterations, hash), nil
 }
 
-func (h *HashService) VerifyPassword(password, stored string) bool {
+func (h *HashService) VerifyPassword(ctx context.Context, password, stored string) bool {
+	ctx, span := h.tracer.Start(ctx, "HashService.VerifyPassword")
+	defer span.End()
+
 	parts := strings.Split(stored, "$")
 
 	var salt, expectedHash string
@@ -79,9 +91,10 @@ func (h *HashService) VerifyPassword(password, stored string) bool {
 		}
 		expectedHash = parts[2]
 	} else {
-		log.Printf("Invalid stored password format. Parts: %d", len(parts))
+		slog.ErrorContext(ctx, "invalid stored password format", "parts", len(parts))
 		return false
 	}
+	span.SetAttributes(attribute.Int("hash.iterations", iter))
 
 	computed := h.hash(password, salt, iter)
 
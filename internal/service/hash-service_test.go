@@ -0,0 +1,33 @@
+package service
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestHashServiceSpans(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	hash := NewHashService(sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter)).Tracer("test"))
+	ctx := context.Background()
+
+	stored, err := hash.HashPassword(ctx, "correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hash.VerifyPassword(ctx, "correct horse", stored) || hash.VerifyPassword(ctx, "wrong", stored) {
+		t.Fatal("password verification is wrong")
+	}
+
+	var names []string
+	for _, s := range exporter.GetSpans() {
+		names = append(names, s.Name)
+	}
+	want := []string{"HashService.HashPassword", "HashService.VerifyPassword", "HashService.VerifyPassword"}
+	if !slices.Equal(names, want) {
+		t.Fatalf("spans %v, want %v", names, want)
+	}
+}
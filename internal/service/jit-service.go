@@ -1,73 +1,660 @@
 package service
 
 import (
+	"AuthServer/internal/database"
 	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/events"
 	"AuthServer/internal/repository"
+	"AuthServer/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
 	"fmt"
+	"log/slog"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	// ErrNotEligibleApprover is returned when the caller may not decide at the request's current tier.
+	ErrNotEligibleApprover = errors.New("not an eligible approver for this request")
+	// ErrAlreadyDecided is returned when an approver decides twice on the same request.
+	ErrAlreadyDecided = errors.New("approver already decided on this request")
+	// ErrNotRequestOwner is returned when a user acts on another user's request.
+	ErrNotRequestOwner = errors.New("request belongs to another user")
+	// ErrInvalidTransition is returned when the request is not in a state that allows the action.
+	ErrInvalidTransition = errors.New("request is not in a state that allows this action")
+	// ErrInvalidJITRequest is returned for an unknown role or a role requested at the wrong scope.
+	ErrInvalidJITRequest = errors.New("invalid JIT request")
 )
 
+// jitSystemActor is recorded as the grantor of automatically approved requests.
+const jitSystemActor = "system:jit-auto-approval"
+
 type JITService struct {
+	tx           database.Transactor
 	jitRepo      *repository.JITRequestRepository
+	approvalRepo *repository.JITApprovalRepository
 	userRoleRepo *repository.UserRoleRepository
+	groupRepo    *repository.GroupRepository
+	rbacService  *RBACService
+	sod          *SoDService
+	pendingTTL   time.Duration
+	bus          *events.Bus
+	tracer       trace.Tracer
 }
 
-func NewJITService(jitRepo *repository.JITRequestRepository, userRoleRepo *repository.UserRoleRepository) *JITService {
+func NewJITService(tx database.Transactor, jitRepo *repository.JITRequestRepository, approvalRepo *repository.JITApprovalRepository, userRoleRepo *repository.UserRoleRepository, groupRepo *repository.GroupRepository, rbacService *RBACService, sod *SoDService, pendingTTL time.Duration, bus *events.Bus, tracer trace.Tracer) *JITService {
 	return &JITService{
+		tx:           tx,
 		jitRepo:      jitRepo,
+		approvalRepo: approvalRepo,
 		userRoleRepo: userRoleRepo,
+		groupRepo:    groupRepo,
+		rbacService:  rbacService,
+		sod:          sod,
+		pendingTTL:   pendingTTL,
+		bus:          bus,
+		tracer:       tracer,
 	}
 }
 
-func (s *JITService) CreateRequest(userID string, role roles.Role, resourceID *string, durationMinutes int, reason string) (*roles.JITRequestDB, error) {
-	return s.jitRepo.Create(userID, role, resourceID, durationMinutes, reason)
+// CreateRequest stores a new request. If the matching policy auto-approves requests of this
+// duration, the role is granted immediately.
+func (s *JITService) CreateRequest(ctx context.Context, userID string, role roles.Role, resourceID *string, durationMinutes int, reason string) (request *roles.JITRequestDB, err error) {
+	ctx, span := s.tracer.Start(ctx, "JITService.CreateRequest")
+	defer func() { tracing.End(span, err) }()
+
+	return s.create(ctx, userID, role, resourceID, durationMinutes, reason, nil)
 }
 
-func (s *JITService) GetPendingRequests() ([]roles.JITRequestDB, error) {
-	return s.jitRepo.GetPendingRequests()
+// RequestExtension asks for more time on an active grant. The new request goes through the
+// same approval policy and, once approved, pushes back the expiry of the original grant.
+func (s *JITService) RequestExtension(ctx context.Context, requestID, userID string, durationMinutes int, reason string) (request *roles.JITRequestDB, err error) {
+	ctx, span := s.tracer.Start(ctx, "JITService.RequestExtension")
+	defer func() { tracing.End(span, err) }()
+
+	original, err := s.jitRepo.GetByID(ctx, requestID)
+	if err != nil {
+		return nil, err
+	}
+
+	if original.UserID != userID {
+		return nil, ErrNotRequestOwner
+	}
+	if original.Status != roles.JITStatusApproved || original.GrantedRoleID == nil ||
+		original.GrantExpiresAt == nil || !original.GrantExpiresAt.After(time.Now()) {
+		return nil, ErrInvalidTransition
+	}
+
+	return s.create(ctx, userID, roles.Role(original.Role), original.ResourceID, durationMinutes, reason, &original.ID)
 }
 
-func (s *JITService) GetUserRequests(userID string) ([]roles.JITRequestDB, error) {
-	return s.jitRepo.GetUserRequests(userID)
+func (s *JITService) create(ctx context.Context, userID string, role roles.Role, resourceID *string, durationMinutes int, reason string, parentRequestID *string) (*roles.JITRequestDB, error) {
+	if resourceID == nil && !roles.IsGlobalRole(role) {
+		return nil, fmt.Errorf("%w: %s is not a valid global role", ErrInvalidJITRequest, role)
+	}
+	if resourceID != nil && !roles.IsResourceRole(role) {
+		return nil, fmt.Errorf("%w: %s is not a valid resource-specific role", ErrInvalidJITRequest, role)
+	}
+
+	policy, err := s.approvalRepo.FindPolicy(ctx, role, resourceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var policyID *string
+	if policy != nil {
+		policyID = &policy.ID
+	}
+
+	request, err := s.jitRepo.Create(ctx, userID, role, resourceID, durationMinutes, reason, policyID, parentRequestID)
+	if err != nil {
+		return nil, err
+	}
+
+	s.bus.Publish(ctx, events.JITRequested, userID, request)
+
+	if policy != nil && policy.AutoApproveMaxMinutes != nil && durationMinutes <= *policy.AutoApproveMaxMinutes {
+		if err := s.autoApprove(ctx, request); err != nil {
+			slog.WarnContext(ctx, "JIT auto-approval failed, request left pending", "jit_request_id", request.ID, "error", err)
+			return request, nil
+		}
+		request.Status = roles.JITStatusApproved
+		s.bus.Publish(ctx, events.JITApproved, userID, roles.JITApprovalProgress{
+			RequestID: request.ID,
+			Status:    request.Status,
+			Tier:      request.ApprovalTier,
+		})
+	}
+
+	return request, nil
 }
 
-func (s *JITService) ApproveRequest(requestID, approverID string) error {
-	request, err := s.jitRepo.GetByID(requestID)
+func (s *JITService) autoApprove(ctx context.Context, request *roles.JITRequestDB) error {
+	var assigned *roles.UserRole
+	err := s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := s.checkGrantTx(ctx, tx, request, "", false); err != nil {
+			return err
+		}
+		if err := s.approvalRepo.WithTx(tx).RecordApproval(ctx, request.ID, nil, request.ApprovalTier, roles.ApprovalAutoApproved, nil); err != nil {
+			return err
+		}
+		var err error
+		assigned, err = s.grant(ctx, tx, request, jitSystemActor, nil)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
-	if request.Status != "pending" {
-		return fmt.Errorf("request is not pending")
+	if assigned != nil {
+		s.rbacService.roleAssigned(ctx, assigned)
 	}
+	return nil
+}
 
-	// Update request status
-	err = s.jitRepo.UpdateStatus(requestID, "approved", &approverID)
+// GetPendingRequests lists the requests awaiting a decision. Requests past the pending TTL
+// stay listed until the expiry job marks them expired; they can no longer be approved.
+func (s *JITService) GetPendingRequests(ctx context.Context) ([]roles.JITRequestDB, error) {
+	return s.jitRepo.GetPendingRequests(ctx)
+}
+
+func (s *JITService) GetUserRequests(ctx context.Context, userID string) ([]roles.JITRequestDB, error) {
+	return s.jitRepo.GetUserRequests(ctx, userID)
+}
+
+// ExpireStaleRequests marks pending requests older than the pending TTL and approved grants
+// past their end as expired.
+func (s *JITService) ExpireStaleRequests(ctx context.Context) (int, error) {
+	return s.jitRepo.ExpireStale(ctx, s.pendingTTL)
+}
+
+// expireStale expires a request a decision found stale without waiting for the expiry job;
+// a failure only leaves the stale status behind until the job runs.
+func (s *JITService) expireStale(ctx context.Context) {
+	if _, err := s.ExpireStaleRequests(ctx); err != nil {
+		slog.ErrorContext(ctx, "failed to expire stale JIT requests", "error", err)
+	}
+}
+
+// CancelRequest lets the requester withdraw a request that is still pending.
+func (s *JITService) CancelRequest(ctx context.Context, requestID, userID string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "JITService.CancelRequest")
+	defer func() { tracing.End(span, err) }()
+
+	request, err := s.jitRepo.GetByID(ctx, requestID)
 	if err != nil {
 		return err
 	}
 
-	// Assign the role with expiration
-	expiresAt := time.Now().Add(time.Duration(request.DurationMinutes) * time.Minute)
+	if request.UserID != userID {
+		return ErrNotRequestOwner
+	}
 
-	return s.userRoleRepo.AssignRole(
-		request.UserID,
-		roles.Role(request.Role),
-		request.ResourceID,
-		&expiresAt,
-		approverID,
-	)
+	ok, err := s.jitRepo.Transition(ctx, requestID, roles.JITStatusPending, roles.JITStatusCancelled, &userID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInvalidTransition
+	}
+
+	s.bus.Publish(ctx, events.JITCancelled, userID, map[string]any{"request_id": requestID, "status": roles.JITStatusCancelled})
+	return nil
 }
 
-func (s *JITService) RejectRequest(requestID, approverID string) error {
-	request, err := s.jitRepo.GetByID(requestID)
+// RevokeGrant ends an approved grant early by deleting the user_roles row it created. Every
+// request sharing the grant (the original and its approved extensions) is marked revoked.
+func (s *JITService) RevokeGrant(ctx context.Context, requestID, actorID string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "JITService.RevokeGrant")
+	defer func() { tracing.End(span, err) }()
+
+	var userID string
+	var revoked *roles.UserRole
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		jitRepo := s.jitRepo.WithTx(tx)
+
+		request, err := jitRepo.GetByIDForUpdate(ctx, requestID)
+		if err != nil {
+			return err
+		}
+		userID = request.UserID
+
+		if request.Status != roles.JITStatusApproved {
+			return ErrInvalidTransition
+		}
+
+		if request.GrantedRoleID == nil {
+			// Approved before grants were linked to their requests
+			_, err := jitRepo.Transition(ctx, requestID, roles.JITStatusApproved, roles.JITStatusRevoked, &actorID)
+			return err
+		}
+
+		revoked, err = s.rbacService.revokeRoleTx(ctx, tx, *request.GrantedRoleID)
+		if errors.Is(err, repository.ErrRoleNotFound) {
+			slog.WarnContext(ctx, "JIT role already gone", "user_role_id", *request.GrantedRoleID, "jit_request_id", requestID)
+		} else if err != nil {
+			return err
+		}
+
+		_, err = jitRepo.EndGrant(ctx, *request.GrantedRoleID, &actorID)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
-	if request.Status != "pending" {
-		return fmt.Errorf("request is not pending")
+	if revoked != nil {
+		s.rbacService.roleRevoked(ctx, revoked)
+	}
+
+	s.bus.Publish(ctx, events.JITRevoked, userID, map[string]any{
+		"request_id": requestID,
+		"status":     roles.JITStatusRevoked,
+		"revoked_by": actorID,
+	})
+	return nil
+}
+
+func (s *JITService) GetApprovals(ctx context.Context, requestID string) ([]roles.JITApproval, error) {
+	if _, err := s.jitRepo.GetByID(ctx, requestID); err != nil {
+		return nil, err
+	}
+	return s.approvalRepo.ListApprovals(ctx, requestID)
+}
+
+// GetAwaitingApproval returns the pending requests the user may currently decide on.
+func (s *JITService) GetAwaitingApproval(ctx context.Context, userID string, ac roles.AccessContext) ([]roles.JITRequestDB, error) {
+	pending, err := s.GetPendingRequests(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	awaiting := []roles.JITRequestDB{}
+	for i := range pending {
+		request := &pending[i]
+		if request.UserID == userID {
+			continue
+		}
+		policy, err := s.policyFor(ctx, request)
+		if err != nil {
+			return nil, err
+		}
+		eligible, err := s.isEligible(ctx, userID, request, policy, ac)
+		if err != nil {
+			return nil, err
+		}
+		decided, err := s.approvalRepo.HasDecided(ctx, request.ID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if eligible && !decided {
+			awaiting = append(awaiting, *request)
+		}
+	}
+
+	return awaiting, nil
+}
+
+// ApproveRequest records an approval. The role is granted once the policy's required number
+// of approvals is reached at the request's current tier.
+func (s *JITService) ApproveRequest(ctx context.Context, requestID, approverID string, comment *string, ac roles.AccessContext) (progress *roles.JITApprovalProgress, err error) {
+	ctx, span := s.tracer.Start(ctx, "JITService.ApproveRequest")
+	defer func() { tracing.End(span, err) }()
+
+	request, policy, err := s.prepareDecision(ctx, requestID, approverID, ac)
+	if err != nil {
+		return nil, err
+	}
+
+	var assigned *roles.UserRole
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		approvalRepo := s.approvalRepo.WithTx(tx)
+
+		// Separation of duties: approver and granted role are checked before anything is written
+		if err := s.checkGrantTx(ctx, tx, request, approverID, approverGroup(request, policy) != nil); err != nil {
+			return err
+		}
+
+		// Lock the request so concurrent decisions are applied one after another
+		locked, err := s.lockPending(ctx, tx, request, approverID)
+		if err != nil {
+			return err
+		}
+
+		if err := approvalRepo.RecordApproval(ctx, locked.ID, &approverID, locked.ApprovalTier, roles.ApprovalApproved, comment); err != nil {
+			return err
+		}
+
+		approvals, err := approvalRepo.CountApprovals(ctx, locked.ID, locked.ApprovalTier)
+		if err != nil {
+			return err
+		}
+
+		progress = &roles.JITApprovalProgress{
+			RequestID: locked.ID,
+			Status:    roles.JITStatusPending,
+			Tier:      locked.ApprovalTier,
+			Approvals: approvals,
+			Required:  policy.RequiredApprovals,
+		}
+
+		if approvals < policy.RequiredApprovals {
+			return nil
+		}
+
+		if assigned, err = s.grant(ctx, tx, locked, approverID, &approverID); err != nil {
+			return err
+		}
+		progress.Status = roles.JITStatusApproved
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if assigned != nil {
+		s.rbacService.roleAssigned(ctx, assigned)
 	}
 
-	return s.jitRepo.UpdateStatus(requestID, "rejected", &approverID)
+	if progress.Status == roles.JITStatusApproved {
+		s.bus.Publish(ctx, events.JITApproved, request.UserID, progress)
+	} else {
+		s.bus.Publish(ctx, events.JITApprovalRecorded, request.UserID, progress)
+	}
+	return progress, nil
+}
+
+// RejectRequest records a rejection; a single eligible rejection rejects the request.
+func (s *JITService) RejectRequest(ctx context.Context, requestID, approverID string, comment *string, ac roles.AccessContext) (err error) {
+	ctx, span := s.tracer.Start(ctx, "JITService.RejectRequest")
+	defer func() { tracing.End(span, err) }()
+
+	request, _, err := s.prepareDecision(ctx, requestID, approverID, ac)
+	if err != nil {
+		return err
+	}
+
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		locked, err := s.lockPending(ctx, tx, request, approverID)
+		if err != nil {
+			return err
+		}
+
+		if err := s.approvalRepo.WithTx(tx).RecordApproval(ctx, locked.ID, &approverID, locked.ApprovalTier, roles.ApprovalRejected, comment); err != nil {
+			return err
+		}
+
+		return s.jitRepo.WithTx(tx).UpdateStatus(ctx, locked.ID, roles.JITStatusRejected, &approverID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.bus.Publish(ctx, events.JITRejected, request.UserID, map[string]any{
+		"request_id":  request.ID,
+		"status":      roles.JITStatusRejected,
+		"rejected_by": approverID,
+		"comment":     comment,
+	})
+	return nil
+}
+
+// checkGrantTx runs the separation-of-duties checks for granting request inside tx. The
+// requester's roles stay locked until tx ends, as for a direct assignment, so a concurrent
+// approval or assignment cannot pass the same checks. approverID is empty for automatic
+// approvals; groupApproved reports that the approver is eligible through an approver group.
+func (s *JITService) checkGrantTx(ctx context.Context, tx *sql.Tx, request *roles.JITRequestDB, approverID string, groupApproved bool) error {
+	if err := lockUserRoles(ctx, tx, request.UserID); err != nil {
+		return err
+	}
+
+	requesterRoles, err := s.rbacService.userRolesTx(ctx, tx, request.UserID)
+	if err != nil {
+		return err
+	}
+
+	actor := jitSystemActor
+	if approverID != "" {
+		actor = approverID
+		approverRoles, err := s.rbacService.userRolesTx(ctx, tx, approverID)
+		if err != nil {
+			return err
+		}
+		if err := s.sod.CheckApproval(ctx, approverID, approverRoles, request, requesterRoles, groupApproved); err != nil {
+			return err
+		}
+	}
+
+	return s.sod.CheckAssignment(ctx, actor, request.UserID, roles.Role(request.Role), request.ResourceID, requesterRoles)
+}
+
+// lockPending re-reads the request under a row lock and checks that it is still pending at
+// the tier the decision was prepared for and that the approver has not decided meanwhile.
+func (s *JITService) lockPending(ctx context.Context, tx *sql.Tx, prepared *roles.JITRequestDB, approverID string) (*roles.JITRequestDB, error) {
+	locked, err := s.jitRepo.WithTx(tx).GetByIDForUpdate(ctx, prepared.ID)
+	if err != nil {
+		return nil, err
+	}
+	if locked.Status != roles.JITStatusPending {
+		return nil, fmt.Errorf("request is not pending")
+	}
+	if locked.ApprovalTier != prepared.ApprovalTier {
+		return nil, fmt.Errorf("request was escalated, please retry")
+	}
+
+	decided, err := s.approvalRepo.WithTx(tx).HasDecided(ctx, locked.ID, approverID)
+	if err != nil {
+		return nil, err
+	}
+	if decided {
+		return nil, ErrAlreadyDecided
+	}
+
+	return locked, nil
+}
+
+// EscalateOverdue moves pending requests past their policy's escalation timeout to tier 2.
+func (s *JITService) EscalateOverdue(ctx context.Context) (escalated int, err error) {
+	ctx, span := s.tracer.Start(ctx, "JITService.EscalateOverdue")
+	defer func() { tracing.End(span, err) }()
+
+	due, err := s.jitRepo.GetEscalationDue(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, request := range due {
+		if err := s.jitRepo.Escalate(ctx, request.ID, 2); err != nil {
+			return 0, err
+		}
+		slog.InfoContext(ctx, "JIT request escalated to tier 2", "jit_request_id", request.ID)
+	}
+
+	return len(due), nil
+}
+
+// prepareDecision loads a pending request, applies a due escalation and checks that the
+// approver is eligible at the current tier and has not decided yet.
+func (s *JITService) prepareDecision(ctx context.Context, requestID, approverID string, ac roles.AccessContext) (*roles.JITRequestDB, *roles.JITApprovalPolicy, error) {
+	request, err := s.jitRepo.GetByID(ctx, requestID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if request.Status != roles.JITStatusPending {
+		return nil, nil, fmt.Errorf("request is not pending")
+	}
+
+	if time.Since(request.CreatedAt) >= s.pendingTTL {
+		s.expireStale(ctx)
+		return nil, nil, fmt.Errorf("request has expired")
+	}
+
+	policy, err := s.policyFor(ctx, request)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if request.ApprovalTier == 1 && policy.EscalationAfterMinutes != nil &&
+		time.Since(request.CreatedAt) >= time.Duration(*policy.EscalationAfterMinutes)*time.Minute {
+		if err := s.jitRepo.Escalate(ctx, request.ID, 2); err != nil {
+			return nil, nil, err
+		}
+		request.ApprovalTier = 2
+	}
+
+	eligible, err := s.isEligible(ctx, approverID, request, policy, ac)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !eligible {
+		return nil, nil, ErrNotEligibleApprover
+	}
+
+	decided, err := s.approvalRepo.HasDecided(ctx, request.ID, approverID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if decided {
+		return nil, nil, ErrAlreadyDecided
+	}
+
+	return request, policy, nil
+}
+
+func (s *JITService) policyFor(ctx context.Context, request *roles.JITRequestDB) (*roles.JITApprovalPolicy, error) {
+	if request.PolicyID != nil {
+		policy, err := s.approvalRepo.GetPolicy(ctx, *request.PolicyID)
+		if err == nil {
+			return policy, nil
+		}
+		slog.WarnContext(ctx, "JIT policy not found, using default", "policy_id", *request.PolicyID, "jit_request_id", request.ID)
+	}
+	return roles.DefaultJITApprovalPolicy(roles.Role(request.Role), request.ResourceID), nil
+}
+
+// approverGroup returns the group that decides at the request's current tier, or nil when
+// eligibility falls back to roles.
+func approverGroup(request *roles.JITRequestDB, policy *roles.JITApprovalPolicy) *string {
+	if request.ApprovalTier >= 2 {
+		return policy.EscalationGroupID
+	}
+	return policy.ApproverGroupID
+}
+
+// isEligible reports whether userID may decide at the request's current tier. Tier 1 uses the
+// policy's approver group, tier 2 its escalation group. Without a group, tier 1 falls back to
+// admins and managers and tier 2 to admins only.
+func (s *JITService) isEligible(ctx context.Context, userID string, request *roles.JITRequestDB, policy *roles.JITApprovalPolicy, ac roles.AccessContext) (bool, error) {
+	group := approverGroup(request, policy)
+	fallback := []roles.Role{roles.RoleAdmin, roles.RoleManager}
+	if request.ApprovalTier >= 2 {
+		fallback = []roles.Role{roles.RoleAdmin}
+	}
+
+	if group != nil {
+		groups, err := s.groupRepo.FindUserGroups(ctx, userID)
+		if err != nil {
+			return false, err
+		}
+		for _, g := range groups {
+			if g.ID == *group {
+				return true, nil
+			}
+		}
+		return false, nil
+	}
+
+	for _, role := range fallback {
+		ok, err := s.rbacService.HasPermission(ctx, userID, role, nil, ac)
+		if err != nil {
+			return false, err
+		}
+		if ok {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// grant marks the request approved and assigns the role for the requested duration. An
+// approved extension moves the expiry of its parent's grant instead of assigning again.
+// It runs inside the caller's transaction and returns the new assignment, nil for an
+// extension, for the caller to announce once tx has committed.
+func (s *JITService) grant(ctx context.Context, tx *sql.Tx, request *roles.JITRequestDB, grantedBy string, approvedBy *string) (*roles.UserRole, error) {
+	jitRepo := s.jitRepo.WithTx(tx)
+
+	// Update request status
+	err := jitRepo.UpdateStatus(ctx, request.ID, roles.JITStatusApproved, approvedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	duration := time.Duration(request.DurationMinutes) * time.Minute
+
+	if request.ParentRequestID != nil {
+		// Locked so a concurrent revocation cannot end the grant being extended
+		parent, err := jitRepo.GetByIDForUpdate(ctx, *request.ParentRequestID)
+		if err != nil {
+			return nil, err
+		}
+		if parent.Status == roles.JITStatusApproved && parent.GrantedRoleID != nil && parent.GrantExpiresAt != nil &&
+			parent.GrantExpiresAt.After(time.Now()) {
+			expiresAt := parent.GrantExpiresAt.Add(duration)
+			if err := s.userRoleRepo.WithTx(tx).ExtendRole(ctx, *parent.GrantedRoleID, expiresAt); err != nil {
+				return nil, err
+			}
+			if err := jitRepo.SetGrant(ctx, parent.ID, *parent.GrantedRoleID, expiresAt); err != nil {
+				return nil, err
+			}
+			return nil, jitRepo.SetGrant(ctx, request.ID, *parent.GrantedRoleID, expiresAt)
+		}
+		// The original grant lapsed while the extension was pending: grant afresh
+	}
+
+	// Assign the role with expiration
+	expiresAt := time.Now().Add(duration)
+
+	assigned := &roles.UserRole{
+		UserID:     request.UserID,
+		Role:       roles.Role(request.Role),
+		ResourceID: request.ResourceID,
+		ExpiresAt:  &expiresAt,
+	}
+	if err := s.rbacService.assignRoleTx(ctx, tx, assigned, grantedBy); err != nil {
+		return nil, err
+	}
+
+	return assigned, jitRepo.SetGrant(ctx, request.ID, assigned.ID, expiresAt)
+}
+
+// CreatePolicy validates and stores an approval policy.
+func (s *JITService) CreatePolicy(ctx context.Context, policy *roles.JITApprovalPolicy) error {
+	if policy.RequiredApprovals < 1 {
+		policy.RequiredApprovals = 1
+	}
+	if policy.EscalationGroupID != nil && policy.EscalationAfterMinutes == nil {
+		return fmt.Errorf("escalation_group_id requires escalation_after_minutes")
+	}
+	for _, groupID := range []*string{policy.ApproverGroupID, policy.EscalationGroupID} {
+		if groupID == nil {
+			continue
+		}
+		if _, err := s.groupRepo.FindById(ctx, *groupID); err != nil {
+			return err
+		}
+	}
+	return s.approvalRepo.CreatePolicy(ctx, policy)
+}
+
+func (s *JITService) ListPolicies(ctx context.Context) ([]roles.JITApprovalPolicy, error) {
+	return s.approvalRepo.ListPolicies(ctx)
+}
+
+func (s *JITService) DeletePolicy(ctx context.Context, id string) error {
+	return s.approvalRepo.DeletePolicy(ctx, id)
 }
@@ -2,7 +2,6 @@ package service
 
 import (
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/dgrijalva/jwt-go"
@@ -20,14 +19,32 @@ type JWTCustomClaims struct {
 }
 
 type JwtService struct {
-	secretKey string
-	issuer    string
+	keys   Keyring
+	issuer string
+	ttl    time.Duration
 }
 
-func NewJWTService() IJWTService {
+func NewJWTService(keys Keyring, issuer string, ttl time.Duration) IJWTService {
 	return &JwtService{
-		secretKey: os.Getenv("ACCESS_TOKEN_SECRET_KEY"),
-		issuer:    "Artan Ebibi",
+		keys:   keys,
+		issuer: issuer,
+		ttl:    ttl,
+	}
+}
+
+// keyFunc finds the key named by the token's kid header. Tokens without one were signed
+// with the configured secret.
+func keyFunc(keys Keyring) jwt.Keyfunc {
+	return func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		secret, ok := keys.VerificationKey(kid)
+		if !ok {
+			return nil, fmt.Errorf("unknown signing key %q", kid)
+		}
+		return secret, nil
 	}
 }
 
@@ -35,14 +52,18 @@ func (jwtSrv *JwtService) GenerateAccessToken(userId string) string {
 	claims := &JWTCustomClaims{
 		UserID: userId,
 		StandardClaims: jwt.StandardClaims{
-			ExpiresAt: time.Now().Add(time.Minute * 30).Unix(),
+			ExpiresAt: time.Now().Add(jwtSrv.ttl).Unix(),
 			Issuer:    jwtSrv.issuer,
 			IssuedAt:  time.Now().Unix(),
 		},
 	}
 
+	kid, secret := jwtSrv.keys.SigningKey()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	t, err := token.SignedString([]byte(jwtSrv.secretKey))
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	t, err := token.SignedString(secret)
 	if err != nil {
 		panic(err)
 	}
@@ -50,12 +71,7 @@ func (jwtSrv *JwtService) GenerateAccessToken(userId string) string {
 }
 
 func (jwtSrv *JwtService) ValidateAccessToken(tokenString string) (*jwt.Token, error) {
-	return jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(jwtSrv.secretKey), nil
-	})
+	return jwt.Parse(tokenString, keyFunc(jwtSrv.keys))
 }
 
 func (jwtSrv *JwtService) ExtractClaims(tokenString string) (string, error) {
@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"unicode"
+
+	"AuthServer/internal/metrics"
+	"AuthServer/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+	"google.golang.org/api/gmail/v1"
+	"google.golang.org/api/option"
+)
+
+type IMailService interface {
+	Send(ctx context.Context, to []string, subject, htmlBody string) error
+	// Check reports whether mail can currently be sent. Nothing is sent.
+	Check(ctx context.Context) error
+}
+
+// gmailAddr is the Gmail API endpoint messages are sent through.
+const gmailAddr = "gmail.googleapis.com:443"
+
+// MailService sends HTML mail through the Gmail API using credentials.json and token.json.
+type MailService struct {
+	from    string
+	metrics *metrics.Metrics
+	tracer  trace.Tracer
+}
+
+func NewMailService(from string, m *metrics.Metrics, tracer trace.Tracer) *MailService {
+	return &MailService{from: from, metrics: m, tracer: tracer}
+}
+
+func (m *MailService) Send(ctx context.Context, to []string, subject, htmlBody string) (err error) {
+	ctx, span := m.tracer.Start(ctx, "MailService.Send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.Int("mail.recipients", len(to))))
+	defer func() { tracing.End(span, err) }()
+
+	err = m.send(ctx, to, subject, htmlBody)
+	m.metrics.MailSent(err)
+	return err
+}
+
+// headerText makes s safe to place in a mail header: line breaks would let it add headers
+// of its own, so control characters become spaces.
+func headerText(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return ' '
+		}
+		return r
+	}, s)
+}
+
+func (m *MailService) send(ctx context.Context, to []string, subject, htmlBody string) error {
+	srv, err := getGmailService()
+	if err != nil {
+		return fmt.Errorf("failed to get Gmail service: %v", err)
+	}
+
+	message := fmt.Sprintf("From: %s\r\n"+
+		"To: %s\r\n"+
+		"Subject: %s\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/html; charset=UTF-8\r\n"+
+		"\r\n"+
+		"%s",
+		m.from, headerText(strings.Join(to, ", ")), headerText(subject), htmlBody)
+
+	encoded := base64.URLEncoding.EncodeToString([]byte(message))
+	gmailMessage := &gmail.Message{Raw: encoded}
+
+	_, err = srv.Users.Messages.Send("me", gmailMessage).Context(ctx).Do()
+	if err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// Check loads the OAuth credentials and token and opens a connection to the Gmail API.
+func (m *MailService) Check(ctx context.Context) error {
+	config, err := getOAuthConfig()
+	if err != nil {
+		return err
+	}
+	if _, err := getClient(config); err != nil {
+		return err
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", gmailAddr)
+	if err != nil {
+		return fmt.Errorf("Gmail API unreachable: %v", err)
+	}
+	return conn.Close()
+}
+
+func getOAuthConfig() (*oauth2.Config, error) {
+	b, err := os.ReadFile("credentials.json")
+	if err != nil {
+		return nil, fmt.Errorf("unable to read credentials.json: %v", err)
+	}
+
+	config, err := google.ConfigFromJSON(b, gmail.GmailSendScope)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse credentials: %v", err)
+	}
+	return config, nil
+}
+
+func getGmailService() (*gmail.Service, error) {
+	ctx := context.Background()
+
+	config, err := getOAuthConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := getClient(config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get client: %v", err)
+	}
+
+	srv, err := gmail.NewService(ctx, option.WithHTTPClient(client))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create Gmail service: %v", err)
+	}
+
+	return srv, nil
+}
+
+func getClient(config *oauth2.Config) (*http.Client, error) {
+	tokFile := "token.json"
+	tok, err := tokenFromFile(tokFile)
+	if err != nil {
+		return nil, fmt.Errorf("token not found - run OAuth setup first: %v", err)
+	}
+	return config.Client(context.Background(), tok), nil
+}
+
+func tokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	tok := &oauth2.Token{}
+	err = json.NewDecoder(f).Decode(tok)
+	return tok, err
+}
@@ -0,0 +1,153 @@
+package service
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/repository"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// ErrDelegationDenied is returned when the actor may not manage the requested project role.
+var ErrDelegationDenied = errors.New("not allowed to manage this project role")
+
+// ProjectMemberService lets platform admins and managers manage any project's members,
+// and lets project owners grant and revoke roles below their own level on their project.
+type ProjectMemberService struct {
+	tx           database.Transactor
+	userRoleRepo *repository.UserRoleRepository
+	userRepo     repository.IUserRepository
+	projectRepo  repository.IProjectRepository
+	rbacService  *RBACService
+}
+
+func NewProjectMemberService(tx database.Transactor, userRoleRepo *repository.UserRoleRepository, userRepo repository.IUserRepository, projectRepo repository.IProjectRepository, rbacService *RBACService) *ProjectMemberService {
+	return &ProjectMemberService{
+		tx:           tx,
+		userRoleRepo: userRoleRepo,
+		userRepo:     userRepo,
+		projectRepo:  projectRepo,
+		rbacService:  rbacService,
+	}
+}
+
+func (s *ProjectMemberService) ListMembers(ctx context.Context, projectID string) ([]models.ProjectMember, error) {
+	if _, err := s.projectRepo.FindById(ctx, projectID); err != nil {
+		return nil, err
+	}
+	return s.userRoleRepo.GetResourceMembers(ctx, projectID)
+}
+
+// grantableRoles returns the project roles the actor may grant or revoke on the project.
+func (s *ProjectMemberService) grantableRoles(ctx context.Context, actorID, projectID string, ac roles.AccessContext) ([]roles.Role, error) {
+	for _, role := range []roles.Role{roles.RoleAdmin, roles.RoleManager} {
+		ok, err := s.rbacService.HasPermission(ctx, actorID, role, nil, ac)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return []roles.Role{roles.RoleProjectOwner, roles.RoleProjectEditor, roles.RoleProjectViewer}, nil
+		}
+	}
+
+	isOwner, err := s.rbacService.HasPermission(ctx, actorID, roles.RoleProjectOwner, &projectID, ac)
+	if err != nil {
+		return nil, err
+	}
+	if !isOwner {
+		return nil, nil
+	}
+
+	// owners may only delegate roles strictly below their own level
+	var grantable []roles.Role
+	ownerLevel := roles.ResourceRoleHierarchy[roles.RoleProjectOwner]
+	for _, role := range []roles.Role{roles.RoleProjectEditor, roles.RoleProjectViewer} {
+		if roles.ResourceRoleHierarchy[role] < ownerLevel {
+			grantable = append(grantable, role)
+		}
+	}
+	return grantable, nil
+}
+
+func containsRole(list []roles.Role, role roles.Role) bool {
+	for _, r := range list {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// AddMember grants a project role to a user on behalf of actorID.
+func (s *ProjectMemberService) AddMember(ctx context.Context, actorID, projectID, userID string, role roles.Role, ac roles.AccessContext) error {
+	if !roles.IsResourceRole(role) {
+		return fmt.Errorf("%s is not a valid project role", role)
+	}
+
+	if _, err := s.projectRepo.FindById(ctx, projectID); err != nil {
+		return err
+	}
+	if _, err := s.userRepo.FindById(ctx, userID); err != nil {
+		return err
+	}
+
+	grantable, err := s.grantableRoles(ctx, actorID, projectID, ac)
+	if err != nil {
+		return err
+	}
+	if !containsRole(grantable, role) {
+		return fmt.Errorf("%w: cannot grant %s", ErrDelegationDenied, role)
+	}
+
+	_, err = s.rbacService.AssignRole(ctx, userID, role, &projectID, nil, actorID, nil)
+	return err
+}
+
+// RemoveMember revokes the user's direct roles on the project. An owner cannot remove
+// a member who holds a role at or above the owner's level.
+func (s *ProjectMemberService) RemoveMember(ctx context.Context, actorID, projectID, userID string, ac roles.AccessContext) (int, error) {
+	grantable, err := s.grantableRoles(ctx, actorID, projectID, ac)
+	if err != nil {
+		return 0, err
+	}
+	if len(grantable) == 0 {
+		return 0, fmt.Errorf("%w: not a manager of this project", ErrDelegationDenied)
+	}
+
+	var revoked int
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := lockUserRoles(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		userRoleRepo := s.userRoleRepo.WithTx(tx)
+
+		members, err := userRoleRepo.GetResourceMembers(ctx, projectID)
+		if err != nil {
+			return err
+		}
+
+		var held []roles.Role
+		for _, m := range members {
+			if m.UserID != userID {
+				continue
+			}
+			role := roles.Role(m.Role)
+			if !containsRole(grantable, role) {
+				return fmt.Errorf("%w: member holds %s", ErrDelegationDenied, role)
+			}
+			held = append(held, role)
+		}
+
+		if len(held) == 0 {
+			return fmt.Errorf("member not found")
+		}
+
+		revoked, err = userRoleRepo.RevokeResourceRoles(ctx, userID, projectID, held)
+		return err
+	})
+	return revoked, err
+}
@@ -2,60 +2,87 @@ package service
 
 import (
 	"AuthServer/internal/domain/models"
+	"AuthServer/internal/events"
 	"AuthServer/internal/repository"
+	"AuthServer/internal/tracing"
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
 type IProjectService interface {
-	FindAll() ([]models.Project, error)
-	FindById(id string) (*models.Project, error)
-	FindByName(name string) (*models.Project, error)
-	Save(project models.Project) (*models.Project, error)
-	Update(project models.Project) error
-	Delete(id string) error
+	FindAll(ctx context.Context) ([]models.Project, error)
+	FindById(ctx context.Context, id string) (*models.Project, error)
+	FindByName(ctx context.Context, name string) (*models.Project, error)
+	Save(ctx context.Context, project models.Project) (*models.Project, error)
+	Update(ctx context.Context, project models.Project) error
+	Delete(ctx context.Context, id string) error
 }
 
 type ProjectService struct {
 	projectRepository repository.IProjectRepository
+	bus               *events.Bus
+	tracer            trace.Tracer
 }
 
-func NewProjectService(repo repository.IProjectRepository) *ProjectService {
+func NewProjectService(repo repository.IProjectRepository, bus *events.Bus, tracer trace.Tracer) *ProjectService {
 	return &ProjectService{
 		projectRepository: repo,
+		bus:               bus,
+		tracer:            tracer,
 	}
 }
 
-func (p *ProjectService) FindAll() ([]models.Project, error) {
-	return p.projectRepository.FindAll()
+func (p *ProjectService) FindAll(ctx context.Context) ([]models.Project, error) {
+	return p.projectRepository.FindAll(ctx)
 }
 
-func (p *ProjectService) FindById(id string) (*models.Project, error) {
-	project, err := p.projectRepository.FindById(id)
+func (p *ProjectService) FindById(ctx context.Context, id string) (*models.Project, error) {
+	project, err := p.projectRepository.FindById(ctx, id)
 	if err != nil {
 		return nil, err
 	}
 	return project, nil
 }
 
-func (p *ProjectService) FindByName(name string) (*models.Project, error) {
-	project, err := p.projectRepository.FindByName(name)
+func (p *ProjectService) FindByName(ctx context.Context, name string) (*models.Project, error) {
+	project, err := p.projectRepository.FindByName(ctx, name)
 	if err != nil {
 		return nil, err
 	}
 	return project, nil
 }
 
-func (p *ProjectService) Save(project models.Project) (*models.Project, error) {
-	err := p.projectRepository.Save(project)
-	if err != nil {
+func (p *ProjectService) Save(ctx context.Context, project models.Project) (created *models.Project, err error) {
+	ctx, span := p.tracer.Start(ctx, "ProjectService.Save")
+	defer func() { tracing.End(span, err) }()
+
+	if err := p.projectRepository.Save(ctx, project); err != nil {
 		return nil, err
 	}
+	p.bus.Publish(ctx, events.ProjectCreated, "", project)
 	return &project, nil
 }
 
-func (p *ProjectService) Update(project models.Project) error {
-	return p.projectRepository.Update(project)
+func (p *ProjectService) Update(ctx context.Context, project models.Project) (err error) {
+	ctx, span := p.tracer.Start(ctx, "ProjectService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	return p.projectRepository.Update(ctx, project)
 }
 
-func (p *ProjectService) Delete(id string) error {
-	return p.projectRepository.Delete(id)
+// Delete removes the project and publishes its last state.
+func (p *ProjectService) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := p.tracer.Start(ctx, "ProjectService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	project, err := p.projectRepository.FindById(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := p.projectRepository.Delete(ctx, id); err != nil {
+		return err
+	}
+	p.bus.Publish(ctx, events.ProjectDeleted, "", project)
+	return nil
 }
@@ -1,70 +1,520 @@
 package service
 
 import (
+	"AuthServer/internal/database"
 	"AuthServer/internal/domain/roles"
+	"AuthServer/internal/events"
+	"AuthServer/internal/metrics"
 	"AuthServer/internal/repository"
+	"AuthServer/internal/tracing"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
 	"time"
+
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrInvalidSimulation is returned when a proposed change cannot be applied in a simulation.
+var ErrInvalidSimulation = errors.New("invalid simulated change")
+
 type RBACService struct {
+	tx           database.Transactor
 	userRoleRepo *repository.UserRoleRepository
+	groupRepo    *repository.GroupRepository
+	userRepo     repository.IUserRepository
+	projectRepo  repository.IProjectRepository
+	sod          *SoDService
+	conditions   *ConditionEvaluator
+	bus          *events.Bus
+	metrics      *metrics.Metrics
+	tracer       trace.Tracer
 }
 
-func NewRBACService(repo *repository.UserRoleRepository) *RBACService {
+func NewRBACService(tx database.Transactor, repo *repository.UserRoleRepository, groupRepo *repository.GroupRepository, userRepo repository.IUserRepository, projectRepo repository.IProjectRepository, sod *SoDService, bus *events.Bus, m *metrics.Metrics, tracer trace.Tracer) *RBACService {
 	return &RBACService{
+		tx:           tx,
 		userRoleRepo: repo,
+		groupRepo:    groupRepo,
+		userRepo:     userRepo,
+		projectRepo:  projectRepo,
+		sod:          sod,
+		conditions:   NewConditionEvaluator(),
+		bus:          bus,
+		metrics:      m,
+		tracer:       tracer,
+	}
+}
+
+// AssignRole stores a role assignment. A non-empty condition must compile, otherwise
+// ErrInvalidCondition is returned and nothing is stored. Assignments that break a
+// separation-of-duties rule are rejected with a *roles.SoDViolation. It returns the ID of
+// the new assignment.
+func (s *RBACService) AssignRole(ctx context.Context, userID string, role roles.Role, resourceID *string, expiresAt *time.Time, assignedBy string, condition *string) (id string, err error) {
+	ctx, span := s.tracer.Start(ctx, "RBACService.AssignRole")
+	defer func() { tracing.End(span, err) }()
+
+	if condition != nil && *condition == "" {
+		condition = nil
+	}
+	if condition != nil {
+		if err := s.conditions.Validate(*condition); err != nil {
+			return "", err
+		}
+	}
+
+	assigned := &roles.UserRole{
+		UserID:     userID,
+		Role:       role,
+		ResourceID: resourceID,
+		ExpiresAt:  expiresAt,
+		Condition:  condition,
+	}
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		// Serialize assignments to the same user so two concurrent grants cannot both pass
+		// the separation-of-duties check
+		if err := lockUserRoles(ctx, tx, userID); err != nil {
+			return err
+		}
+
+		existing, err := s.userRolesTx(ctx, tx, userID)
+		if err != nil {
+			return err
+		}
+		if err := s.sod.CheckAssignment(ctx, assignedBy, userID, role, resourceID, existing); err != nil {
+			return err
+		}
+
+		return s.assignRoleTx(ctx, tx, assigned, assignedBy)
+	})
+	if err != nil {
+		return "", err
+	}
+
+	s.roleAssigned(ctx, assigned)
+	return assigned.ID, nil
+}
+
+// assignRoleTx stores assigned inside tx and sets its ID. The caller has run the
+// separation-of-duties check under lockUserRoles, and calls roleAssigned once tx has
+// committed.
+func (s *RBACService) assignRoleTx(ctx context.Context, tx *sql.Tx, assigned *roles.UserRole, assignedBy string) error {
+	id, err := s.userRoleRepo.WithTx(tx).AssignRole(ctx, assigned.UserID, assigned.Role, assigned.ResourceID, assigned.ExpiresAt, assignedBy, assigned.Condition)
+	if err != nil {
+		return err
+	}
+	assigned.ID = id
+	return nil
+}
+
+// roleAssigned announces an assignment made with assignRoleTx.
+func (s *RBACService) roleAssigned(ctx context.Context, assigned *roles.UserRole) {
+	s.bus.Publish(ctx, events.RoleAssigned, assigned.UserID, *assigned)
+}
+
+// lockUserRoles takes the transaction-scoped lock guarding changes to a user's roles.
+func lockUserRoles(ctx context.Context, tx *sql.Tx, userID string) error {
+	return database.LockKey(ctx, tx, "user-roles:"+userID)
+}
+
+// ValidateCondition reports ErrInvalidCondition if the expression does not compile.
+func (s *RBACService) ValidateCondition(condition string) error {
+	return s.conditions.Validate(condition)
+}
+
+// GetUserRoles returns the user's active direct assignments followed by the roles
+// inherited through group membership.
+func (s *RBACService) GetUserRoles(ctx context.Context, userID string) ([]roles.UserRole, error) {
+	return s.collectRoles(ctx, userID, false)
+}
+
+// userRolesTx is GetUserRoles read inside tx.
+func (s *RBACService) userRolesTx(ctx context.Context, tx *sql.Tx, userID string) ([]roles.UserRole, error) {
+	direct, err := s.userRoleRepo.WithTx(tx).GetUserRoles(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	inherited, err := s.groupRepo.WithTx(tx).GetInheritedRoles(ctx, userID, false)
+	if err != nil {
+		return nil, err
 	}
+
+	return append(direct, inherited...), nil
+}
+
+func (s *RBACService) collectRoles(ctx context.Context, userID string, includeExpired bool) ([]roles.UserRole, error) {
+	var direct []roles.UserRole
+	var err error
+	if includeExpired {
+		direct, err = s.userRoleRepo.GetUserRolesIncludingExpired(ctx, userID)
+	} else {
+		direct, err = s.userRoleRepo.GetUserRoles(ctx, userID)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	inherited, err := s.groupRepo.GetInheritedRoles(ctx, userID, includeExpired)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(direct, inherited...), nil
 }
 
-func (s *RBACService) AssignRole(userID string, role roles.Role, resourceID *string, expiresAt *time.Time, assignedBy string) error {
-	return s.userRoleRepo.AssignRole(userID, role, resourceID, expiresAt, assignedBy)
+// RevokeRole deletes a direct assignment and returns it as it was before the revocation.
+func (s *RBACService) RevokeRole(ctx context.Context, roleID string) (revoked *roles.UserRole, err error) {
+	ctx, span := s.tracer.Start(ctx, "RBACService.RevokeRole")
+	defer func() { tracing.End(span, err) }()
+
+	err = s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		revoked, err = s.revokeRoleTx(ctx, tx, roleID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.roleRevoked(ctx, revoked)
+	return revoked, nil
+}
+
+// revokeRoleTx is RevokeRole inside tx. The caller calls roleRevoked once tx has committed.
+func (s *RBACService) revokeRoleTx(ctx context.Context, tx *sql.Tx, roleID string) (*roles.UserRole, error) {
+	repo := s.userRoleRepo.WithTx(tx)
+
+	revoked, err := repo.GetByID(ctx, roleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := repo.RevokeRole(ctx, roleID); err != nil {
+		return nil, err
+	}
+	return revoked, nil
+}
+
+// roleRevoked announces a revocation made with revokeRoleTx.
+func (s *RBACService) roleRevoked(ctx context.Context, revoked *roles.UserRole) {
+	s.bus.Publish(ctx, events.RoleRevoked, revoked.UserID, revoked)
+}
+
+// HasPermission reports whether the user holds requiredRole, globally or on resourceID.
+// Assignments with a condition only count when the condition holds for ac. Disabled users
+// hold no permissions, so their tokens stop working as soon as they are disabled.
+func (s *RBACService) HasPermission(ctx context.Context, userID string, requiredRole roles.Role, resourceID *string, ac roles.AccessContext) (bool, error) {
+	allowed, _, err := s.Authorize(ctx, userID, requiredRole, resourceID, ac)
+	return allowed, err
+}
+
+// Authorize is HasPermission that also returns the active roles the check read, so that
+// middleware can reuse them for the rest of the request. They are nil for a disabled or
+// unknown user.
+func (s *RBACService) Authorize(ctx context.Context, userID string, requiredRole roles.Role, resourceID *string, ac roles.AccessContext) (allowed bool, userRoles []roles.UserRole, err error) {
+	ctx, span := s.tracer.Start(ctx, "RBACService.HasPermission")
+	defer func() { tracing.End(span, err) }()
+
+	defer func(start time.Time) {
+		s.metrics.PermissionCheck(allowed, err, time.Since(start))
+	}(time.Now())
+
+	// The disabled check rides on the direct roles query; an unknown user is denied
+	direct, enabled, err := s.userRoleRepo.GetEnabledUserRoles(ctx, userID)
+	if err != nil || !enabled {
+		return false, nil, err
+	}
+
+	inherited, err := s.groupRepo.GetInheritedRoles(ctx, userID, false)
+	if err != nil {
+		return false, nil, err
+	}
+	userRoles = append(direct, inherited...)
+
+	decision := s.evaluate(ctx, userID, requiredRole, resourceID, ac, userRoles, false)
+	return decision.Allowed, userRoles, nil
+}
+
+// Explain evaluates a permission check like HasPermission and returns the full trace,
+// including the expired assignments that were skipped.
+func (s *RBACService) Explain(ctx context.Context, userID string, requiredRole roles.Role, resourceID *string, ac roles.AccessContext) (decision *roles.Decision, err error) {
+	ctx, span := s.tracer.Start(ctx, "RBACService.Explain")
+	defer func() { tracing.End(span, err) }()
+
+	disabled, err := s.isDisabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if disabled {
+		return disabledDecision(userID, requiredRole, resourceID), nil
+	}
+
+	userRoles, err := s.collectRoles(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.evaluate(ctx, userID, requiredRole, resourceID, ac, userRoles, true), nil
 }
 
-func (s *RBACService) GetUserRoles(userID string) ([]roles.UserRole, error) {
-	return s.userRoleRepo.GetUserRoles(userID)
+// Simulate evaluates a permission check before and after applying the proposed changes
+// to the user's assignments. Nothing is written.
+func (s *RBACService) Simulate(ctx context.Context, userID string, requiredRole roles.Role, resourceID *string, ac roles.AccessContext, changes []roles.SimulatedChange) (simulation *roles.Simulation, err error) {
+	ctx, span := s.tracer.Start(ctx, "RBACService.Simulate")
+	defer func() { tracing.End(span, err) }()
+
+	disabled, err := s.isDisabled(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.collectRoles(ctx, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	proposed := append([]roles.UserRole(nil), current...)
+	for i, change := range changes {
+		switch change.Op {
+		case "grant":
+			if change.Role == "" {
+				return nil, fmt.Errorf("%w: change %d: role is required for grant", ErrInvalidSimulation, i)
+			}
+			if change.Condition != nil && *change.Condition != "" {
+				if err := s.conditions.Validate(*change.Condition); err != nil {
+					return nil, fmt.Errorf("change %d: %w", i, err)
+				}
+			} else {
+				change.Condition = nil
+			}
+			proposed = append(proposed, roles.UserRole{
+				ID:         fmt.Sprintf("simulated-%d", i),
+				UserID:     userID,
+				Role:       change.Role,
+				ResourceID: change.ResourceID,
+				ExpiresAt:  change.ExpiresAt,
+				Condition:  change.Condition,
+			})
+
+		case "revoke":
+			kept := proposed[:0]
+			removed := false
+			for _, ur := range proposed {
+				if revokes(change, ur) {
+					removed = true
+					continue
+				}
+				kept = append(kept, ur)
+			}
+			if !removed {
+				return nil, fmt.Errorf("%w: change %d: no matching assignment to revoke", ErrInvalidSimulation, i)
+			}
+			proposed = kept
+
+		default:
+			return nil, fmt.Errorf("%w: change %d: unknown op %q", ErrInvalidSimulation, i, change.Op)
+		}
+	}
+
+	before := s.evaluate(ctx, userID, requiredRole, resourceID, ac, current, true)
+	after := s.evaluate(ctx, userID, requiredRole, resourceID, ac, proposed, true)
+	if disabled {
+		// No change to assignments grants anything to a disabled user
+		before = disabledDecision(userID, requiredRole, resourceID)
+		after = disabledDecision(userID, requiredRole, resourceID)
+	}
+
+	return &roles.Simulation{
+		Changes: changes,
+		Before:  before,
+		After:   after,
+		Changed: before.Allowed != after.Allowed,
+	}, nil
 }
 
-func (s *RBACService) RevokeRole(roleID string) error {
-	return s.userRoleRepo.RevokeRole(roleID)
+func revokes(change roles.SimulatedChange, ur roles.UserRole) bool {
+	if change.AssignmentID != "" {
+		return ur.ID == change.AssignmentID
+	}
+	if ur.Role != change.Role {
+		return false
+	}
+	if change.ResourceID == nil || ur.ResourceID == nil {
+		return change.ResourceID == nil && ur.ResourceID == nil
+	}
+	return *change.ResourceID == *ur.ResourceID
 }
 
-func (s *RBACService) HasPermission(userID string, requiredRole roles.Role, resourceID *string) (bool, error) {
-	userRoles, err := s.userRoleRepo.GetUserRoles(userID)
+// isDisabled reports whether the user has been disabled. An unknown user counts as disabled,
+// so checks for them are denied rather than failing.
+func (s *RBACService) isDisabled(ctx context.Context, userID string) (bool, error) {
+	user, err := s.userRepo.FindById(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return true, nil
+	}
 	if err != nil {
 		return false, err
 	}
+	return user.DisabledAt != nil, nil
+}
+
+// disabledDecision is the denial Explain and Simulate report for a disabled or unknown user.
+func disabledDecision(userID string, requiredRole roles.Role, resourceID *string) *roles.Decision {
+	return &roles.Decision{
+		UserID:       userID,
+		RequiredRole: requiredRole,
+		ResourceID:   resourceID,
+		Reason:       "user is disabled or does not exist",
+		Trace:        []roles.TraceStep{},
+	}
+}
+
+// evaluate walks the assignments in order. Without trace it stops at the first grant.
+func (s *RBACService) evaluate(ctx context.Context, userID string, requiredRole roles.Role, resourceID *string, ac roles.AccessContext, userRoles []roles.UserRole, trace bool) *roles.Decision {
+	decision := &roles.Decision{
+		UserID:       userID,
+		RequiredRole: requiredRole,
+		ResourceID:   resourceID,
+		Reason:       "no assignment grants the required role",
+		Trace:        []roles.TraceStep{},
+	}
+
+	record := func(ur roles.UserRole, outcome, detail string) {
+		if trace {
+			if ur.GroupName != nil {
+				detail += fmt.Sprintf(" (inherited from group %s)", *ur.GroupName)
+			}
+			decision.Trace = append(decision.Trace, roles.TraceStep{
+				AssignmentID: ur.ID,
+				Role:         ur.Role,
+				ResourceID:   ur.ResourceID,
+				Outcome:      outcome,
+				Detail:       detail,
+			})
+		}
+	}
+
+	attributesLoaded := false
 
 	for _, ur := range userRoles {
 		// Skip expired roles
 		if ur.ExpiresAt != nil && ur.ExpiresAt.Before(time.Now()) {
+			record(ur, roles.OutcomeExpired, fmt.Sprintf("expired at %s", ur.ExpiresAt.Format(time.RFC3339)))
 			continue
 		}
 
-		// Check resource-specific role
-		if resourceID != nil && ur.ResourceID != nil {
-			if *ur.ResourceID == *resourceID {
-				if ur.Role == requiredRole || s.CheckHierarchy(ur.Role, requiredRole) {
-					return true, nil
-				}
+		matched, outcome, detail := s.matches(ur, requiredRole, resourceID)
+		if !matched {
+			record(ur, outcome, detail)
+			continue
+		}
+
+		if ur.Condition != nil {
+			if !attributesLoaded {
+				ac = s.withAttributes(ctx, ac, userID, resourceID)
+				attributesLoaded = true
+			}
+			ok, err := s.conditions.Evaluate(*ur.Condition, ac)
+			if err != nil {
+				slog.WarnContext(ctx, "role condition failed to evaluate", "role", ur.Role, "user_id", userID, "error", err)
+				record(ur, roles.OutcomeConditionError, err.Error())
+				continue
 			}
+			if !ok {
+				record(ur, roles.OutcomeConditionFalse, fmt.Sprintf("condition %q evaluated to false", *ur.Condition))
+				continue
+			}
+			detail += fmt.Sprintf("; condition %q holds", *ur.Condition)
 		}
 
-		// Check global role
-		if ur.ResourceID == nil {
-			if ur.Role == requiredRole || s.CheckHierarchy(ur.Role, requiredRole) {
-				return true, nil
+		record(ur, roles.OutcomeGranted, detail)
+		if !decision.Allowed {
+			decision.Allowed = true
+			decision.Reason = fmt.Sprintf("granted by %s assignment", ur.Role)
+			if ur.GroupName != nil {
+				decision.Reason += fmt.Sprintf(" inherited from group %s", *ur.GroupName)
 			}
 		}
+		if !trace {
+			break
+		}
 	}
 
-	return false, nil
+	return decision
 }
 
-func (s *RBACService) CheckHierarchy(userRole roles.Role, requiredRole roles.Role) bool {
-	userLevel, userExists := roles.RoleHierarchy[userRole]
-	requiredLevel, requiredExists := roles.RoleHierarchy[requiredRole]
+// matches reports whether an assignment grants requiredRole for the requested scope,
+// together with the trace outcome and a human readable explanation.
+func (s *RBACService) matches(ur roles.UserRole, requiredRole roles.Role, resourceID *string) (bool, string, string) {
+	// Check resource-specific role
+	if ur.ResourceID != nil {
+		if resourceID == nil {
+			return false, roles.OutcomeScopeMismatch, fmt.Sprintf("assignment is scoped to resource %s but the check is global", *ur.ResourceID)
+		}
+		if *ur.ResourceID != *resourceID {
+			return false, roles.OutcomeScopeMismatch, fmt.Sprintf("assignment is scoped to resource %s, not %s", *ur.ResourceID, *resourceID)
+		}
+	}
+
+	scope := "global"
+	if ur.ResourceID != nil {
+		scope = "resource " + *ur.ResourceID
+	}
 
-	if !userExists || !requiredExists {
+	if ur.Role == requiredRole {
+		return true, roles.OutcomeGranted, fmt.Sprintf("%s role %s equals required role", scope, ur.Role)
+	}
+
+	if s.CheckHierarchy(ur.Role, requiredRole) {
+		userLevel, requiredLevel, _ := roles.CompareLevels(ur.Role, requiredRole)
+		return true, roles.OutcomeGranted, fmt.Sprintf("%s role %s (level %d) >= %s (level %d)",
+			scope, ur.Role, userLevel, requiredRole, requiredLevel)
+	}
+
+	return false, roles.OutcomeRoleMismatch, s.describeHierarchy(ur.Role, requiredRole)
+}
+
+func (s *RBACService) describeHierarchy(userRole roles.Role, requiredRole roles.Role) string {
+	userLevel, requiredLevel, comparable := roles.CompareLevels(userRole, requiredRole)
+	if !comparable {
+		return fmt.Sprintf("%s does not imply %s (not comparable in the hierarchy)", userRole, requiredRole)
+	}
+
+	return fmt.Sprintf("%s (level %d) < %s (level %d)", userRole, userLevel, requiredRole, requiredLevel)
+}
+
+// withAttributes fills in user and resource attributes that conditions may reference.
+func (s *RBACService) withAttributes(ctx context.Context, ac roles.AccessContext, userID string, resourceID *string) roles.AccessContext {
+	if ac.User == nil {
+		ac.User = map[string]any{"id": userID}
+		if user, err := s.userRepo.FindById(ctx, userID); err == nil {
+			ac.User["username"] = user.Username
+			ac.User["email"] = user.Email
+			ac.User["full_name"] = user.FullName
+			ac.User["created_at"] = user.CreatedAt
+			ac.User["email_verified"] = user.EmailVerifiedAt != nil
+		}
+	}
+
+	if ac.Resource == nil && resourceID != nil {
+		ac.Resource = map[string]any{"id": *resourceID}
+		if project, err := s.projectRepo.FindById(ctx, *resourceID); err == nil {
+			ac.Resource["type"] = "project"
+			ac.Resource["name"] = project.Name
+			ac.Resource["created_at"] = project.CreatedAt
+		}
+	}
+
+	return ac
+}
+
+// CheckHierarchy reports whether userRole implies requiredRole, either in the global
+// hierarchy (admin > manager > user) or the resource hierarchy (owner > editor > viewer).
+func (s *RBACService) CheckHierarchy(userRole roles.Role, requiredRole roles.Role) bool {
+	userLevel, requiredLevel, comparable := roles.CompareLevels(userRole, requiredRole)
+	if !comparable {
 		return false
 	}
 
@@ -0,0 +1,289 @@
+package service
+
+import (
+	"AuthServer/internal/domain/rebac"
+	"AuthServer/internal/domain/roles"
+	"context"
+	"fmt"
+)
+
+// maxCheckDepth bounds the recursion through subject sets and tuple-to-userset rewrites.
+const maxCheckDepth = 25
+
+// lookupBatch is how many candidate objects LookupResources reads at a time, and
+// maxLookupChecks how many it checks in one call before returning a cursor.
+const (
+	lookupBatch     = 100
+	maxLookupChecks = 1000
+)
+
+type tupleStore interface {
+	Write(ctx context.Context, tuple rebac.RelationTuple) error
+	WriteSynced(ctx context.Context, tuple rebac.RelationTuple) error
+	Delete(ctx context.Context, tuple rebac.RelationTuple) error
+	ReadTuples(ctx context.Context, object rebac.Object, relation string) ([]rebac.RelationTuple, error)
+	ListByObject(ctx context.Context, object rebac.Object, relation string) ([]rebac.RelationTuple, error)
+	ListSynced(ctx context.Context, namespace string) ([]rebac.RelationTuple, error)
+	ListObjectIDs(ctx context.Context, namespace, after string, limit int) ([]string, error)
+}
+
+type resourceRoleLister interface {
+	GetAllResourceRoles(ctx context.Context) ([]roles.UserRole, error)
+}
+
+// ExpandNode is one node of the userset tree returned by Expand.
+type ExpandNode struct {
+	Kind     string          `json:"kind"` // union, this, computed_userset, tuple_to_userset, leaf
+	Object   rebac.Object    `json:"object"`
+	Relation string          `json:"relation"`
+	Subjects []rebac.Subject `json:"subjects,omitempty"`
+	Children []*ExpandNode   `json:"children,omitempty"`
+}
+
+type ReBACService struct {
+	config       *rebac.Config
+	tuples       tupleStore
+	userRoleRepo resourceRoleLister
+}
+
+func NewReBACService(config *rebac.Config, tuples tupleStore, userRoleRepo resourceRoleLister) *ReBACService {
+	return &ReBACService{
+		config:       config,
+		tuples:       tuples,
+		userRoleRepo: userRoleRepo,
+	}
+}
+
+func (s *ReBACService) Config() *rebac.Config {
+	return s.config
+}
+
+func (s *ReBACService) WriteTuple(ctx context.Context, tuple rebac.RelationTuple) error {
+	if err := s.config.ValidateTuple(tuple); err != nil {
+		return err
+	}
+	return s.tuples.Write(ctx, tuple)
+}
+
+func (s *ReBACService) DeleteTuple(ctx context.Context, tuple rebac.RelationTuple) error {
+	return s.tuples.Delete(ctx, tuple)
+}
+
+func (s *ReBACService) ListTuples(ctx context.Context, object rebac.Object, relation string) ([]rebac.RelationTuple, error) {
+	return s.tuples.ListByObject(ctx, object, relation)
+}
+
+// Check reports whether subject has relation on object, following subject sets and rewrites.
+func (s *ReBACService) Check(ctx context.Context, object rebac.Object, relation string, subject rebac.Subject) (bool, error) {
+	return s.check(ctx, object, relation, subject, map[string]bool{}, 0)
+}
+
+func (s *ReBACService) check(ctx context.Context, object rebac.Object, relation string, subject rebac.Subject, visited map[string]bool, depth int) (bool, error) {
+	if depth > maxCheckDepth {
+		return false, fmt.Errorf("check exceeded maximum depth of %d", maxCheckDepth)
+	}
+
+	key := object.String() + "#" + relation
+	if visited[key] {
+		return false, nil
+	}
+	visited[key] = true
+	defer delete(visited, key)
+
+	rel, err := s.config.Relation(object.Namespace, relation)
+	if err != nil {
+		return false, err
+	}
+
+	for _, rw := range rel.Rewrites {
+		switch rw.Kind {
+		case rebac.RewriteThis:
+			tuples, err := s.tuples.ReadTuples(ctx, object, relation)
+			if err != nil {
+				return false, err
+			}
+			for _, t := range tuples {
+				if t.Subject == subject {
+					return true, nil
+				}
+				if t.Subject.IsSet() {
+					ok, err := s.check(ctx, t.Subject.Object(), t.Subject.Relation, subject, visited, depth+1)
+					if err != nil || ok {
+						return ok, err
+					}
+				}
+			}
+
+		case rebac.RewriteComputedUserset:
+			ok, err := s.check(ctx, object, rw.Relation, subject, visited, depth+1)
+			if err != nil || ok {
+				return ok, err
+			}
+
+		case rebac.RewriteTupleToUserset:
+			tuples, err := s.tuples.ReadTuples(ctx, object, rw.Tupleset)
+			if err != nil {
+				return false, err
+			}
+			for _, t := range tuples {
+				target := t.Subject.Object()
+				if _, err := s.config.Relation(target.Namespace, rw.Relation); err != nil {
+					continue
+				}
+				ok, err := s.check(ctx, target, rw.Relation, subject, visited, depth+1)
+				if err != nil || ok {
+					return ok, err
+				}
+			}
+		}
+	}
+
+	return false, nil
+}
+
+// Expand returns the userset tree for object#relation without resolving it to users.
+func (s *ReBACService) Expand(ctx context.Context, object rebac.Object, relation string) (*ExpandNode, error) {
+	return s.expand(ctx, object, relation, 0)
+}
+
+func (s *ReBACService) expand(ctx context.Context, object rebac.Object, relation string, depth int) (*ExpandNode, error) {
+	if depth > maxCheckDepth {
+		return nil, fmt.Errorf("expand exceeded maximum depth of %d", maxCheckDepth)
+	}
+
+	rel, err := s.config.Relation(object.Namespace, relation)
+	if err != nil {
+		return nil, err
+	}
+
+	root := &ExpandNode{Kind: "union", Object: object, Relation: relation}
+
+	for _, rw := range rel.Rewrites {
+		switch rw.Kind {
+		case rebac.RewriteThis:
+			tuples, err := s.tuples.ReadTuples(ctx, object, relation)
+			if err != nil {
+				return nil, err
+			}
+			node := &ExpandNode{Kind: string(rebac.RewriteThis), Object: object, Relation: relation}
+			for _, t := range tuples {
+				node.Subjects = append(node.Subjects, t.Subject)
+			}
+			root.Children = append(root.Children, node)
+
+		case rebac.RewriteComputedUserset:
+			child, err := s.expand(ctx, object, rw.Relation, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			child.Kind = string(rebac.RewriteComputedUserset)
+			root.Children = append(root.Children, child)
+
+		case rebac.RewriteTupleToUserset:
+			tuples, err := s.tuples.ReadTuples(ctx, object, rw.Tupleset)
+			if err != nil {
+				return nil, err
+			}
+			node := &ExpandNode{Kind: string(rebac.RewriteTupleToUserset), Object: object, Relation: rw.Tupleset + "->" + rw.Relation}
+			for _, t := range tuples {
+				target := t.Subject.Object()
+				if _, err := s.config.Relation(target.Namespace, rw.Relation); err != nil {
+					continue
+				}
+				child, err := s.expand(ctx, target, rw.Relation, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				node.Children = append(node.Children, child)
+			}
+			root.Children = append(root.Children, node)
+		}
+	}
+
+	return root, nil
+}
+
+// LookupResources returns, in id order, up to limit ids after cursor of the objects in
+// namespace on which subject has relation. One call checks at most maxLookupChecks objects;
+// next is the cursor to continue from, empty once every object has been checked.
+func (s *ReBACService) LookupResources(ctx context.Context, namespace, relation string, subject rebac.Subject, cursor string, limit int) (resources []string, next string, err error) {
+	if _, err := s.config.Relation(namespace, relation); err != nil {
+		return nil, "", err
+	}
+
+	resources = []string{}
+	checked := 0
+	for {
+		ids, err := s.tuples.ListObjectIDs(ctx, namespace, cursor, lookupBatch)
+		if err != nil {
+			return nil, "", err
+		}
+
+		for _, id := range ids {
+			ok, err := s.Check(ctx, rebac.Object{Namespace: namespace, ID: id}, relation, subject)
+			if err != nil {
+				return nil, "", err
+			}
+			if ok {
+				resources = append(resources, id)
+			}
+
+			cursor = id
+			checked++
+			if len(resources) == limit || checked == maxLookupChecks {
+				return resources, cursor, nil
+			}
+		}
+
+		if len(ids) < lookupBatch {
+			return resources, "", nil
+		}
+	}
+}
+
+// SyncProjectRoles reconciles the project tuples written by the sync with the project role
+// assignments in user_roles: it writes a tuple for each permanent, unconditional assignment
+// and deletes synced tuples whose assignment is gone. Assignments that expire or carry a
+// condition are skipped, as a tuple can express neither; they stay enforced by RBAC alone.
+// Tuples written through the API are never touched, so it can be re-run while both models
+// are in use.
+func (s *ReBACService) SyncProjectRoles(ctx context.Context) (written, deleted int, err error) {
+	assignments, err := s.userRoleRepo.GetAllResourceRoles(ctx)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	want := map[rebac.RelationTuple]bool{}
+	for _, ur := range assignments {
+		if ur.ExpiresAt != nil || ur.Condition != nil {
+			continue
+		}
+		if tuple, ok := rebac.TupleFromUserRole(ur); ok {
+			want[tuple] = true
+		}
+	}
+
+	synced, err := s.tuples.ListSynced(ctx, "project")
+	if err != nil {
+		return 0, 0, err
+	}
+	for _, tuple := range synced {
+		if want[tuple] {
+			delete(want, tuple)
+			continue
+		}
+		if err := s.tuples.Delete(ctx, tuple); err != nil {
+			return written, deleted, err
+		}
+		deleted++
+	}
+
+	for tuple := range want {
+		if err := s.tuples.WriteSynced(ctx, tuple); err != nil {
+			return written, deleted, err
+		}
+		written++
+	}
+
+	return written, deleted, nil
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"AuthServer/internal/domain/rebac"
+	"AuthServer/internal/domain/roles"
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+type memoryTupleStore struct {
+	tuples []rebac.RelationTuple
+	synced map[rebac.RelationTuple]bool
+}
+
+func (m *memoryTupleStore) Write(_ context.Context, t rebac.RelationTuple) error {
+	m.tuples = append(m.tuples, t)
+	return nil
+}
+
+func (m *memoryTupleStore) WriteSynced(ctx context.Context, t rebac.RelationTuple) error {
+	if m.synced == nil {
+		m.synced = map[rebac.RelationTuple]bool{}
+	}
+	m.synced[t] = true
+	return m.Write(ctx, t)
+}
+
+func (m *memoryTupleStore) Delete(_ context.Context, t rebac.RelationTuple) error {
+	delete(m.synced, t)
+	for i, existing := range m.tuples {
+		if existing == t {
+			m.tuples = append(m.tuples[:i], m.tuples[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
+func (m *memoryTupleStore) ListSynced(_ context.Context, namespace string) ([]rebac.RelationTuple, error) {
+	var out []rebac.RelationTuple
+	for t := range m.synced {
+		if t.Object.Namespace == namespace {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryTupleStore) ReadTuples(_ context.Context, object rebac.Object, relation string) ([]rebac.RelationTuple, error) {
+	var out []rebac.RelationTuple
+	for _, t := range m.tuples {
+		if t.Object == object && t.Relation == relation {
+			out = append(out, t)
+		}
+	}
+	return out, nil
+}
+
+func (m *memoryTupleStore) ListByObject(ctx context.Context, object rebac.Object, relation string) ([]rebac.RelationTuple, error) {
+	return m.ReadTuples(ctx, object, relation)
+}
+
+func (m *memoryTupleStore) ListObjectIDs(_ context.Context, namespace, after string, limit int) ([]string, error) {
+	seen := map[string]bool{}
+	var ids []string
+	for _, t := range m.tuples {
+		if t.Object.Namespace == namespace && t.Object.ID > after && !seen[t.Object.ID] {
+			seen[t.Object.ID] = true
+			ids = append(ids, t.Object.ID)
+		}
+	}
+	slices.Sort(ids)
+	if len(ids) > limit {
+		ids = ids[:limit]
+	}
+	return ids, nil
+}
+
+type memoryResourceRoles []roles.UserRole
+
+func (m memoryResourceRoles) GetAllResourceRoles(context.Context) ([]roles.UserRole, error) {
+	return m, nil
+}
+
+func newTestReBACService(t *testing.T, tuples ...string) *ReBACService {
+	t.Helper()
+
+	config, err := rebac.ParseConfig(rebac.DefaultConfig)
+	if err != nil {
+		t.Fatalf("default config does not parse: %v", err)
+	}
+
+	store := &memoryTupleStore{}
+	for _, s := range tuples {
+		tuple, err := rebac.ParseTuple(s)
+		if err != nil {
+			t.Fatalf("ParseTuple(%q): %v", s, err)
+		}
+		store.Write(context.Background(), tuple)
+	}
+
+	return NewReBACService(config, store, nil)
+}
+
+func TestReBACCheck(t *testing.T) {
+	svc := newTestReBACService(t,
+		"group:eng#member@user:alice",
+		"project:p1#viewer@group:eng#member",
+		"folder:f1#owner@user:bob",
+		"project:p1#parent@folder:f1",
+		"project:p2#editor@user:carol",
+	)
+
+	tests := []struct {
+		object   string
+		relation string
+		subject  string
+		want     bool
+	}{
+		{"project:p1", "viewer", "user:alice", true},  // through group subject set
+		{"project:p1", "editor", "user:alice", false}, // viewer does not imply editor
+		{"project:p1", "editor", "user:bob", true},    // owner of parent folder
+		{"project:p1", "viewer", "user:bob", true},    // editor implies viewer
+		{"project:p2", "viewer", "user:carol", true},
+		{"project:p2", "viewer", "user:alice", false},
+	}
+
+	for _, tt := range tests {
+		object, _ := rebac.ParseObject(tt.object)
+		subject, _ := rebac.ParseSubject(tt.subject)
+
+		got, err := svc.Check(context.Background(), object, tt.relation, subject)
+		if err != nil {
+			t.Fatalf("Check(%s#%s@%s) returned error: %v", tt.object, tt.relation, tt.subject, err)
+		}
+		if got != tt.want {
+			t.Errorf("Check(%s#%s@%s) = %v, want %v", tt.object, tt.relation, tt.subject, got, tt.want)
+		}
+	}
+}
+
+func TestReBACCheckCycle(t *testing.T) {
+	svc := newTestReBACService(t,
+		"group:a#member@group:b#member",
+		"group:b#member@group:a#member",
+	)
+
+	got, err := svc.Check(context.Background(), rebac.Object{Namespace: "group", ID: "a"}, "member", rebac.UserSubject("alice"))
+	if err != nil {
+		t.Fatalf("Check returned error: %v", err)
+	}
+	if got {
+		t.Fatal("expected cyclic groups without users to deny")
+	}
+}
+
+func TestReBACLookupResources(t *testing.T) {
+	svc := newTestReBACService(t,
+		"project:p1#viewer@user:alice",
+		"project:p2#editor@user:alice",
+		"project:p3#viewer@user:bob",
+	)
+
+	ids, next, err := svc.LookupResources(context.Background(), "project", "viewer", rebac.UserSubject("alice"), "", 100)
+	if err != nil {
+		t.Fatalf("LookupResources returned error: %v", err)
+	}
+	if len(ids) != 2 || ids[0] != "p1" || ids[1] != "p2" || next != "" {
+		t.Fatalf("LookupResources = %v, %q, want [p1 p2] and no cursor", ids, next)
+	}
+
+	ids, next, err = svc.LookupResources(context.Background(), "project", "viewer", rebac.UserSubject("alice"), "", 1)
+	if err != nil || len(ids) != 1 || ids[0] != "p1" || next != "p1" {
+		t.Fatalf("first page = %v, %q, %v, want [p1] and cursor p1", ids, next, err)
+	}
+	ids, next, err = svc.LookupResources(context.Background(), "project", "viewer", rebac.UserSubject("alice"), next, 1)
+	if err != nil || len(ids) != 1 || ids[0] != "p2" {
+		t.Fatalf("second page = %v, %q, %v, want [p2]", ids, next, err)
+	}
+}
+
+func TestReBACSyncProjectRoles(t *testing.T) {
+	svc := newTestReBACService(t, "project:p1#viewer@user:manual")
+	p1 := "p1"
+	expires := time.Now().Add(time.Hour)
+	condition := "true"
+	assignments := memoryResourceRoles{
+		{UserID: "alice", Role: roles.RoleProjectEditor, ResourceID: &p1},
+		{UserID: "bob", Role: roles.RoleProjectViewer, ResourceID: &p1, ExpiresAt: &expires},
+		{UserID: "carol", Role: roles.RoleProjectViewer, ResourceID: &p1, Condition: &condition},
+	}
+	svc.userRoleRepo = assignments
+
+	written, deleted, err := svc.SyncProjectRoles(context.Background())
+	if err != nil || written != 1 || deleted != 0 {
+		t.Fatalf("first sync wrote %d, deleted %d, %v; want 1 and 0", written, deleted, err)
+	}
+	for _, user := range []string{"bob", "carol"} {
+		if ok, _ := svc.Check(context.Background(), rebac.Object{Namespace: "project", ID: "p1"}, "viewer", rebac.UserSubject(user)); ok {
+			t.Fatalf("%s's expiring or conditional role was synced", user)
+		}
+	}
+
+	svc.userRoleRepo = assignments[1:]
+	written, deleted, err = svc.SyncProjectRoles(context.Background())
+	if err != nil || written != 0 || deleted != 1 {
+		t.Fatalf("second sync wrote %d, deleted %d, %v; want 0 and 1", written, deleted, err)
+	}
+	if ok, _ := svc.Check(context.Background(), rebac.Object{Namespace: "project", ID: "p1"}, "editor", rebac.UserSubject("alice")); ok {
+		t.Fatal("revoked role still grants through its synced tuple")
+	}
+	if ok, _ := svc.Check(context.Background(), rebac.Object{Namespace: "project", ID: "p1"}, "viewer", rebac.UserSubject("manual")); !ok {
+		t.Fatal("sync deleted a tuple written through the API")
+	}
+}
+
+func TestParseConfigRejectsUnknownRelation(t *testing.T) {
+	_, err := rebac.ParseConfig("namespace doc {\n relation viewer = this | editor\n}\n")
+	if err == nil {
+		t.Fatal("expected an error for a rewrite referencing an unknown relation")
+	}
+}
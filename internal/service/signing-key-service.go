@@ -0,0 +1,241 @@
+package service
+
+import (
+	"AuthServer/internal/database"
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/repository"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrNoSigningKey is returned by Check when new tokens would not verify.
+var ErrNoSigningKey = errors.New("no usable signing key")
+
+// SigningKeyRefreshInterval is how often servers reload the signing keys, and so the longest
+// a rotation takes to reach every server.
+const SigningKeyRefreshInterval = time.Minute
+
+const signingKeyLock = "signing-keys"
+
+// Keyring supplies the keys that sign and verify access tokens.
+type Keyring interface {
+	// SigningKey returns the key new tokens are signed with. kid is empty for the
+	// configured secret.
+	SigningKey() (kid string, secret []byte)
+	// VerificationKey returns the key named by a token's kid header.
+	VerificationKey(kid string) ([]byte, bool)
+}
+
+// SigningKeyService keeps the access token signing keys. Until the first rotation tokens are
+// signed with the configured secret; afterwards the newest stored key signs, and replaced
+// keys, the configured secret included, keep verifying for grace so that tokens signed just
+// before a rotation stay valid until they expire. Stored secrets are sealed with the
+// key-encryption key from configuration, so reading the database does not grant token
+// signing.
+type SigningKeyService struct {
+	tx       database.Transactor
+	repo     *repository.SigningKeyRepository
+	fallback []byte
+	sealer   cipher.AEAD
+	grace    time.Duration
+
+	mu    sync.RWMutex
+	state keyringState
+}
+
+func NewSigningKeyService(tx database.Transactor, repo *repository.SigningKeyRepository, fallback, kek []byte, grace time.Duration) *SigningKeyService {
+	return &SigningKeyService{
+		tx:       tx,
+		repo:     repo,
+		fallback: fallback,
+		sealer:   newKeySealer(kek),
+		grace:    grace,
+		state:    keyringState{fallbackValid: true},
+	}
+}
+
+// keyringState is the set of keys usable at one point in time.
+type keyringState struct {
+	current       *models.SigningKey
+	verify        map[string][]byte
+	fallbackValid bool
+}
+
+// buildKeyring works out from every stored key which one signs and which still verify at now.
+func buildKeyring(keys []models.SigningKey, now time.Time, grace time.Duration) keyringState {
+	state := keyringState{verify: make(map[string][]byte), fallbackValid: true}
+	cutoff := now.Add(-grace)
+
+	for i := range keys {
+		key := &keys[i]
+		if key.RetiredAt == nil {
+			if state.current == nil || key.CreatedAt.After(state.current.CreatedAt) {
+				state.current = key
+			}
+			state.verify[key.ID] = key.Secret
+		} else if key.RetiredAt.After(cutoff) {
+			state.verify[key.ID] = key.Secret
+		}
+		// The configured secret was replaced when the first key was created
+		if key.CreatedAt.Before(cutoff) {
+			state.fallbackValid = false
+		}
+	}
+
+	return state
+}
+
+// newKeySealer returns AES-256-GCM under a key derived from kek.
+func newKeySealer(kek []byte) cipher.AEAD {
+	sum := sha256.Sum256(kek)
+	// Neither call fails for a 32-byte AES key
+	block, _ := aes.NewCipher(sum[:])
+	aead, _ := cipher.NewGCM(block)
+	return aead
+}
+
+// seal encrypts key.Secret into key.Sealed. The key ID is authenticated with it, so a sealed
+// secret cannot be moved to another row.
+func (s *SigningKeyService) seal(key *models.SigningKey) error {
+	nonce := make([]byte, s.sealer.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return err
+	}
+	key.Sealed = s.sealer.Seal(nonce, nonce, key.Secret, []byte(key.ID))
+	return nil
+}
+
+// open decrypts key.Sealed into key.Secret.
+func (s *SigningKeyService) open(key *models.SigningKey) error {
+	n := s.sealer.NonceSize()
+	if len(key.Sealed) < n {
+		return fmt.Errorf("signing key %s is not sealed", key.ID)
+	}
+	secret, err := s.sealer.Open(nil, key.Sealed[:n], key.Sealed[n:], []byte(key.ID))
+	if err != nil {
+		return fmt.Errorf("signing key %s does not open with the configured key-encryption key", key.ID)
+	}
+	key.Secret = secret
+	return nil
+}
+
+// load reads every stored key and opens it. Retired keys that do not open, such as those
+// sealed before the key-encryption key changed, are left out. An unretired key that does not
+// open fails the load instead, since leaving it out could make an older key current again.
+func (s *SigningKeyService) load(ctx context.Context) ([]models.SigningKey, error) {
+	stored, err := s.repo.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := stored[:0]
+	for _, key := range stored {
+		if err := s.open(&key); err != nil {
+			if key.RetiredAt == nil {
+				return nil, err
+			}
+			slog.WarnContext(ctx, "skipping retired signing key", "error", err)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Refresh reloads the keys from the database.
+func (s *SigningKeyService) Refresh(ctx context.Context) error {
+	keys, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+
+	state := buildKeyring(keys, time.Now(), s.grace)
+	s.mu.Lock()
+	s.state = state
+	s.mu.Unlock()
+	return nil
+}
+
+// Rotate creates a new signing key and retires the current one. Servers pick the new key up
+// on their next refresh.
+func (s *SigningKeyService) Rotate(ctx context.Context) (*models.SigningKey, error) {
+	key := &models.SigningKey{ID: newKeyID(), Secret: make([]byte, 32)}
+	if _, err := rand.Read(key.Secret); err != nil {
+		return nil, err
+	}
+	if err := s.seal(key); err != nil {
+		return nil, err
+	}
+
+	err := s.tx.WithTx(ctx, func(tx *sql.Tx) error {
+		if err := database.LockKey(ctx, tx, signingKeyLock); err != nil {
+			return err
+		}
+
+		repo := s.repo.WithTx(tx)
+		if _, err := repo.RetireActive(ctx, time.Now()); err != nil {
+			return err
+		}
+		return repo.Create(ctx, key)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return key, s.Refresh(ctx)
+}
+
+// List returns every stored key, newest first.
+func (s *SigningKeyService) List(ctx context.Context) ([]models.SigningKey, error) {
+	return s.repo.List(ctx)
+}
+
+func (s *SigningKeyService) SigningKey() (string, []byte) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.state.current != nil {
+		return s.state.current.ID, s.state.current.Secret
+	}
+	return "", s.fallback
+}
+
+func (s *SigningKeyService) VerificationKey(kid string) ([]byte, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if kid == "" {
+		return s.fallback, s.state.fallbackValid && len(s.fallback) > 0
+	}
+	secret, ok := s.state.verify[kid]
+	return secret, ok
+}
+
+// Check reports whether tokens signed now would verify: either a stored key is current, or
+// the configured secret is set and has not been replaced for longer than the grace period.
+func (s *SigningKeyService) Check() error {
+	kid, secret := s.SigningKey()
+	if len(secret) == 0 {
+		return ErrNoSigningKey
+	}
+	if _, ok := s.VerificationKey(kid); !ok {
+		return ErrNoSigningKey
+	}
+	return nil
+}
+
+func newKeyID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
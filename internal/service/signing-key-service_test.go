@@ -0,0 +1,91 @@
+package service
+
+import (
+	"AuthServer/internal/domain/models"
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestBuildKeyring(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	grace := time.Hour
+	at := func(d time.Duration) *time.Time {
+		t := now.Add(d)
+		return &t
+	}
+
+	state := buildKeyring(nil, now, grace)
+	if state.current != nil || !state.fallbackValid {
+		t.Fatal("without stored keys the configured secret must sign and verify")
+	}
+
+	keys := []models.SigningKey{
+		{ID: "old", CreatedAt: now.Add(-5 * time.Hour), RetiredAt: at(-3 * time.Hour)},
+		{ID: "prev", CreatedAt: now.Add(-3 * time.Hour), RetiredAt: at(-10 * time.Minute)},
+		{ID: "cur", CreatedAt: now.Add(-10 * time.Minute)},
+	}
+	state = buildKeyring(keys, now, grace)
+
+	if state.current == nil || state.current.ID != "cur" {
+		t.Fatalf("current = %v, want cur", state.current)
+	}
+	if _, ok := state.verify["prev"]; !ok {
+		t.Error("key retired within the grace period must still verify")
+	}
+	if _, ok := state.verify["old"]; ok {
+		t.Error("key retired before the grace period must not verify")
+	}
+	if state.fallbackValid {
+		t.Error("configured secret must stop verifying once replaced for longer than the grace period")
+	}
+
+	// Just after the first rotation the configured secret still verifies
+	state = buildKeyring(keys[2:], now, grace)
+	if !state.fallbackValid {
+		t.Error("configured secret must verify during the grace period after the first rotation")
+	}
+}
+
+func TestSigningKeyCheck(t *testing.T) {
+	s := NewSigningKeyService(nil, nil, []byte("configured-secret"), nil, time.Hour)
+	if err := s.Check(); err != nil {
+		t.Fatalf("configured secret must be usable before any rotation: %v", err)
+	}
+
+	// Every stored key retired and the configured secret replaced long ago
+	long := time.Now().Add(-48 * time.Hour)
+	s.state = buildKeyring([]models.SigningKey{{ID: "old", CreatedAt: long, RetiredAt: &long}}, time.Now(), time.Hour)
+	if err := s.Check(); err != ErrNoSigningKey {
+		t.Fatalf("Check() = %v, want ErrNoSigningKey", err)
+	}
+
+	if err := NewSigningKeyService(nil, nil, nil, nil, time.Hour).Check(); err != ErrNoSigningKey {
+		t.Fatalf("Check() without a secret = %v, want ErrNoSigningKey", err)
+	}
+}
+
+func TestSealSigningKey(t *testing.T) {
+	s := NewSigningKeyService(nil, nil, nil, []byte("key-encryption-key"), time.Hour)
+	key := &models.SigningKey{ID: "k1", Secret: []byte("signing-secret")}
+	if err := s.seal(key); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(key.Sealed, key.Secret) {
+		t.Fatal("sealed key contains the secret")
+	}
+
+	opened := models.SigningKey{ID: "k1", Sealed: key.Sealed}
+	if err := s.open(&opened); err != nil || string(opened.Secret) != "signing-secret" {
+		t.Fatalf("open() = %q, %v", opened.Secret, err)
+	}
+
+	moved := models.SigningKey{ID: "k2", Sealed: key.Sealed}
+	if err := s.open(&moved); err == nil {
+		t.Fatal("a sealed secret opened under another key ID")
+	}
+	other := NewSigningKeyService(nil, nil, nil, []byte("another-key"), time.Hour)
+	if err := other.open(&models.SigningKey{ID: "k1", Sealed: key.Sealed}); err == nil {
+		t.Fatal("a sealed secret opened with another key-encryption key")
+	}
+}
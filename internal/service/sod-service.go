@@ -0,0 +1,142 @@
+package service
+
+import (
+	"AuthServer/internal/domain/roles"
+	"context"
+	"fmt"
+	"log/slog"
+)
+
+type violationRecorder interface {
+	Record(ctx context.Context, v *roles.SoDViolation) error
+	List(ctx context.Context, limit int) ([]roles.SoDViolation, error)
+}
+
+// SoDService enforces separation-of-duties rules on role assignments and JIT approvals.
+// Every rejected operation is recorded before the violation is returned to the caller.
+type SoDService struct {
+	policy     roles.SoDPolicy
+	violations violationRecorder
+}
+
+func NewSoDService(policy roles.SoDPolicy, violations violationRecorder) *SoDService {
+	return &SoDService{
+		policy:     policy,
+		violations: violations,
+	}
+}
+
+func (s *SoDService) Policy() roles.SoDPolicy {
+	return s.policy
+}
+
+func (s *SoDService) Violations(ctx context.Context, limit int) ([]roles.SoDViolation, error) {
+	return s.violations.List(ctx, limit)
+}
+
+// CheckAssignment validates granting role to targetUserID by actorID, given the target's current roles.
+func (s *SoDService) CheckAssignment(ctx context.Context, actorID, targetUserID string, role roles.Role, resourceID *string, existing []roles.UserRole) error {
+	if s.policy.NoSelfGrant && actorID == targetUserID {
+		return s.reject(ctx, &roles.SoDViolation{
+			Rule:         roles.SoDNoSelfGrant,
+			Message:      "users cannot grant roles to themselves",
+			ActorID:      actorID,
+			TargetUserID: targetUserID,
+			Role:         role,
+			ResourceID:   resourceID,
+		})
+	}
+
+	if conflict, ok := s.conflictingRole(role, resourceID, existing); ok {
+		return s.reject(ctx, &roles.SoDViolation{
+			Rule:            roles.SoDMutuallyExclusive,
+			Message:         fmt.Sprintf("%s cannot be held together with %s", role, conflict),
+			ActorID:         actorID,
+			TargetUserID:    targetUserID,
+			Role:            role,
+			ResourceID:      resourceID,
+			ConflictingRole: &conflict,
+		})
+	}
+
+	return nil
+}
+
+// CheckApproval validates that approverID may approve a JIT request. groupApproved reports
+// that the approver is eligible through the policy's approver group, which exempts them from
+// the outrank rule.
+func (s *SoDService) CheckApproval(ctx context.Context, approverID string, approverRoles []roles.UserRole, request *roles.JITRequestDB, requesterRoles []roles.UserRole, groupApproved bool) error {
+	violation := &roles.SoDViolation{
+		ActorID:      approverID,
+		TargetUserID: request.UserID,
+		Role:         roles.Role(request.Role),
+		ResourceID:   request.ResourceID,
+		RequestID:    &request.ID,
+	}
+
+	if s.policy.NoSelfApproval && approverID == request.UserID {
+		violation.Rule = roles.SoDNoSelfApproval
+		violation.Message = "users cannot approve their own requests"
+		return s.reject(ctx, violation)
+	}
+
+	if s.policy.ApproverMustOutrankRequester && !groupApproved {
+		approverLevel := highestGlobalLevel(approverRoles)
+		requesterLevel := highestGlobalLevel(requesterRoles)
+		if approverLevel <= requesterLevel {
+			violation.Rule = roles.SoDApproverOutranks
+			violation.Message = fmt.Sprintf("approver level %d does not outrank requester level %d", approverLevel, requesterLevel)
+			return s.reject(ctx, violation)
+		}
+	}
+
+	return nil
+}
+
+// conflictingRole returns a role in existing that may not be held together with role on
+// resourceID. Assignments conflict only when their scopes overlap: on the same resource, or
+// when either is global, since a global role applies to every resource.
+func (s *SoDService) conflictingRole(role roles.Role, resourceID *string, existing []roles.UserRole) (roles.Role, bool) {
+	for _, pair := range s.policy.MutuallyExclusive {
+		var other roles.Role
+		switch role {
+		case pair[0]:
+			other = pair[1]
+		case pair[1]:
+			other = pair[0]
+		default:
+			continue
+		}
+		for _, ur := range existing {
+			if ur.Role == other && overlaps(ur.ResourceID, resourceID) {
+				return other, true
+			}
+		}
+	}
+	return "", false
+}
+
+func overlaps(a, b *string) bool {
+	return a == nil || b == nil || *a == *b
+}
+
+func (s *SoDService) reject(ctx context.Context, v *roles.SoDViolation) error {
+	if err := s.violations.Record(ctx, v); err != nil {
+		slog.ErrorContext(ctx, "failed to record SoD violation", "rule", v.Rule, "error", err)
+	}
+	return v
+}
+
+// highestGlobalLevel returns the highest level in the global hierarchy among the roles.
+func highestGlobalLevel(userRoles []roles.UserRole) int {
+	highest := 0
+	for _, ur := range userRoles {
+		if ur.ResourceID != nil {
+			continue
+		}
+		if level, ok := roles.RoleHierarchy[ur.Role]; ok && level > highest {
+			highest = level
+		}
+	}
+	return highest
+}
@@ -0,0 +1,99 @@
+package service
+
+import (
+	"AuthServer/internal/domain/roles"
+	"context"
+	"errors"
+	"testing"
+)
+
+type memoryViolations struct {
+	recorded []roles.SoDViolation
+}
+
+func (m *memoryViolations) Record(_ context.Context, v *roles.SoDViolation) error {
+	m.recorded = append(m.recorded, *v)
+	return nil
+}
+
+func (m *memoryViolations) List(_ context.Context, limit int) ([]roles.SoDViolation, error) {
+	return m.recorded, nil
+}
+
+func TestSoDCheckAssignment(t *testing.T) {
+	policy := roles.DefaultSoDPolicy()
+	policy.MutuallyExclusive = [][2]roles.Role{{roles.RoleAdmin, roles.RoleReporter}}
+	recorder := &memoryViolations{}
+	sod := NewSoDService(policy, recorder)
+
+	var violation *roles.SoDViolation
+
+	err := sod.CheckAssignment(context.Background(), "u1", "u1", roles.RoleManager, nil, nil)
+	if !errors.As(err, &violation) || violation.Rule != roles.SoDNoSelfGrant {
+		t.Fatalf("expected no_self_grant violation, got %v", err)
+	}
+
+	existing := []roles.UserRole{{UserID: "u2", Role: roles.RoleReporter}}
+	err = sod.CheckAssignment(context.Background(), "u1", "u2", roles.RoleAdmin, nil, existing)
+	if !errors.As(err, &violation) || violation.Rule != roles.SoDMutuallyExclusive {
+		t.Fatalf("expected mutually_exclusive_roles violation, got %v", err)
+	}
+
+	if err := sod.CheckAssignment(context.Background(), "u1", "u2", roles.RoleManager, nil, existing); err != nil {
+		t.Fatalf("expected assignment to pass, got %v", err)
+	}
+
+	p1, p2 := "p1", "p2"
+	scoped := []roles.UserRole{{UserID: "u2", Role: roles.RoleReporter, ResourceID: &p1}}
+	if err := sod.CheckAssignment(context.Background(), "u1", "u2", roles.RoleAdmin, &p2, scoped); err != nil {
+		t.Fatalf("expected roles on different resources to pass, got %v", err)
+	}
+	err = sod.CheckAssignment(context.Background(), "u1", "u2", roles.RoleAdmin, &p1, scoped)
+	if !errors.As(err, &violation) || violation.Rule != roles.SoDMutuallyExclusive {
+		t.Fatalf("expected mutually_exclusive_roles violation on the same resource, got %v", err)
+	}
+
+	if len(recorder.recorded) != 3 {
+		t.Fatalf("expected 3 recorded violations, got %d", len(recorder.recorded))
+	}
+}
+
+func TestSoDCheckApproval(t *testing.T) {
+	sod := NewSoDService(roles.DefaultSoDPolicy(), &memoryViolations{})
+	request := &roles.JITRequestDB{ID: "r1", UserID: "requester", Role: string(roles.RoleProjectEditor)}
+	manager := []roles.UserRole{{Role: roles.RoleManager}}
+	admin := []roles.UserRole{{Role: roles.RoleAdmin}}
+
+	var violation *roles.SoDViolation
+
+	err := sod.CheckApproval(context.Background(), "requester", manager, request, manager, false)
+	if !errors.As(err, &violation) || violation.Rule != roles.SoDNoSelfApproval {
+		t.Fatalf("expected no_self_approval violation, got %v", err)
+	}
+
+	err = sod.CheckApproval(context.Background(), "approver", manager, request, manager, false)
+	if !errors.As(err, &violation) || violation.Rule != roles.SoDApproverOutranks {
+		t.Fatalf("expected approver_must_outrank_requester violation, got %v", err)
+	}
+
+	if err := sod.CheckApproval(context.Background(), "approver", admin, request, manager, false); err != nil {
+		t.Fatalf("expected approval to pass, got %v", err)
+	}
+}
+
+func TestSoDCheckApprovalByApproverGroup(t *testing.T) {
+	sod := NewSoDService(roles.DefaultSoDPolicy(), &memoryViolations{})
+	request := &roles.JITRequestDB{ID: "r1", UserID: "requester", Role: string(roles.RoleProjectEditor)}
+	manager := []roles.UserRole{{Role: roles.RoleManager}}
+
+	// A group member with no global role approves a manager's request
+	if err := sod.CheckApproval(context.Background(), "approver", nil, request, manager, true); err != nil {
+		t.Fatalf("expected group approval to pass, got %v", err)
+	}
+
+	var violation *roles.SoDViolation
+	err := sod.CheckApproval(context.Background(), "requester", manager, request, manager, true)
+	if !errors.As(err, &violation) || violation.Rule != roles.SoDNoSelfApproval {
+		t.Fatalf("expected no_self_approval violation, got %v", err)
+	}
+}
@@ -1,24 +1,14 @@
 package service
 
 import (
-	"AuthServer/internal/database"
-	"AuthServer/internal/repository"
 	"crypto/rand"
 	"encoding/hex"
 	"errors"
-	"fmt"
-	"os"
+	"time"
 
 	"github.com/dgrijalva/jwt-go"
 )
 
-var (
-	dbService                                 = database.New()
-	userRepository repository.IUserRepository = repository.NewUserRepository(dbService)
-	userService    IUserService               = NewUserService(userRepository)
-	jwtService     IJWTService                = NewJWTService()
-)
-
 type ITokenService interface {
 	//GenerateRefreshToken() string
 	//ValidateRefreshToken(refreshToken string, userId string) (error, bool)
@@ -31,10 +21,15 @@ type ITokenService interface {
 }
 
 type TokenService struct {
+	jwtService IJWTService
+	keys       Keyring
 }
 
-func NewTokenService() ITokenService {
-	return &TokenService{}
+func NewTokenService(keys Keyring, issuer string, ttl time.Duration) ITokenService {
+	return &TokenService{
+		jwtService: NewJWTService(keys, issuer, ttl),
+		keys:       keys,
+	}
 }
 
 func (t *TokenService) GenerateRefreshToken() string {
@@ -69,11 +64,11 @@ func (t *TokenService) GenerateRefreshToken() string {
 //}
 
 func (t *TokenService) GenerateAccessToken(userId string) string {
-	return jwtService.GenerateAccessToken(userId)
+	return t.jwtService.GenerateAccessToken(userId)
 }
 
 func (t *TokenService) ValidateAccessToken(accessToken string) (*jwt.Token, error) {
-	return jwtService.ValidateAccessToken(accessToken)
+	return t.jwtService.ValidateAccessToken(accessToken)
 }
 
 func (t *TokenService) DecodeAccessToken(tokenString string) (map[string]interface{}, error) {
@@ -90,13 +85,7 @@ func (t *TokenService) DecodeAccessToken(tokenString string) (map[string]interfa
 }
 
 func (t *TokenService) DecodeExpiredAccessToken(tokenString string) (map[string]interface{}, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Make sure the token method conforms to "SigningMethodHMAC"
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-		}
-		return []byte(os.Getenv("ACCESS_TOKEN_SECRET_KEY")), nil // Use your secret key here
-	})
+	token, err := jwt.Parse(tokenString, keyFunc(t.keys))
 
 	if err != nil {
 		if ve, ok := err.(*jwt.ValidationError); ok {
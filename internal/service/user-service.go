@@ -3,52 +3,157 @@ package service
 import (
 	"AuthServer/internal/domain/models"
 	"AuthServer/internal/repository"
+	"AuthServer/internal/tracing"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var (
+	ErrInvalidUser  = errors.New("invalid user")
+	ErrUserDisabled = errors.New("user is disabled")
 )
 
+var emailPattern = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
+
 type IUserService interface {
 	//FindAll() []models.User
-	FindById(Id string) (*models.User, error)
-	FindByEmailOrUsername(identifier string) (*models.User, error)
-	Save(models.User) models.User
-	Update(models.User) error
-	Delete(id string) error
+	FindById(ctx context.Context, Id string) (*models.User, error)
+	FindByEmailOrUsername(ctx context.Context, identifier string) (*models.User, error)
+	List(ctx context.Context) ([]models.User, error)
+	Create(ctx context.Context, fullName, username, email, password string) (*models.User, error)
+	Save(ctx context.Context, user models.User) models.User
+	Update(ctx context.Context, user models.User) error
+	Delete(ctx context.Context, id string) error
+	Disable(ctx context.Context, id string) (*models.User, error)
+	Enable(ctx context.Context, id string) (*models.User, error)
+	MarkEmailVerified(ctx context.Context, id string) error
 }
 
 type UserService struct {
 	userRepository repository.IUserRepository
+	hashService    IHashService
+	tracer         trace.Tracer
 }
 
-func NewUserService(repo repository.IUserRepository) *UserService {
+func NewUserService(repo repository.IUserRepository, hash IHashService, tracer trace.Tracer) *UserService {
 	return &UserService{
 		userRepository: repo,
+		hashService:    hash,
+		tracer:         tracer,
 	}
 }
 
 //func (u *UserService) FindAll() []models.User {
-//	return u.userRepository.FindAll()
+//	return u.userRepository.FindAll(ctx)
 //}
 
-func (u *UserService) FindById(Id string) (*models.User, error) {
-	user, err := u.userRepository.FindById(Id)
+func (u *UserService) FindById(ctx context.Context, Id string) (*models.User, error) {
+	user, err := u.userRepository.FindById(ctx, Id)
 	if err != nil {
 		return nil, err
 	}
 	return user, nil
 }
 
-func (u *UserService) FindByEmailOrUsername(identifier string) (*models.User, error) {
-	return u.userRepository.FindByEmailOrUsername(identifier)
+func (u *UserService) FindByEmailOrUsername(ctx context.Context, identifier string) (*models.User, error) {
+	return u.userRepository.FindByEmailOrUsername(ctx, identifier)
+}
+
+func (u *UserService) List(ctx context.Context) ([]models.User, error) {
+	return u.userRepository.List(ctx)
+}
+
+// Create validates and stores a new user with a hashed password. Validation failures wrap
+// ErrInvalidUser.
+func (u *UserService) Create(ctx context.Context, fullName, username, email, password string) (created *models.User, err error) {
+	ctx, span := u.tracer.Start(ctx, "UserService.Create")
+	defer func() { tracing.End(span, err) }()
+
+	switch {
+	case username == "":
+		return nil, fmt.Errorf("%w: username is required", ErrInvalidUser)
+	case !emailPattern.MatchString(email):
+		return nil, fmt.Errorf("%w: invalid email format", ErrInvalidUser)
+	case password == "":
+		return nil, fmt.Errorf("%w: password is required", ErrInvalidUser)
+	}
+
+	hashed, err := u.hashService.HashPassword(ctx, password)
+	if err != nil {
+		return nil, err
+	}
+
+	user := models.User{
+		ID:        uuid.New().String(),
+		FullName:  fullName,
+		Username:  username,
+		Email:     email,
+		Password:  hashed,
+		CreatedAt: time.Now(),
+	}
+	if err := u.userRepository.Save(ctx, user); err != nil {
+		return nil, err
+	}
+	return &user, nil
 }
 
-func (u *UserService) Save(user models.User) models.User {
-	u.userRepository.Save(user)
+func (u *UserService) Save(ctx context.Context, user models.User) models.User {
+	ctx, span := u.tracer.Start(ctx, "UserService.Save")
+	defer span.End()
+
+	u.userRepository.Save(ctx, user)
 	return user
 }
 
-func (u *UserService) Update(user models.User) error {
-	return u.userRepository.Update(user)
+func (u *UserService) Update(ctx context.Context, user models.User) (err error) {
+	ctx, span := u.tracer.Start(ctx, "UserService.Update")
+	defer func() { tracing.End(span, err) }()
+
+	return u.userRepository.Update(ctx, user)
+}
+
+func (u *UserService) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := u.tracer.Start(ctx, "UserService.Delete")
+	defer func() { tracing.End(span, err) }()
+
+	return u.userRepository.Delete(ctx, id)
+}
+
+// Disable stops the user from logging in. Tokens already issued stop passing permission
+// checks at once.
+func (u *UserService) Disable(ctx context.Context, id string) (user *models.User, err error) {
+	ctx, span := u.tracer.Start(ctx, "UserService.Disable")
+	defer func() { tracing.End(span, err) }()
+
+	now := time.Now()
+	return u.setDisabled(ctx, id, &now)
 }
 
-func (u *UserService) Delete(id string) error {
-	return u.userRepository.Delete(id)
+// Enable lets a disabled user log in again.
+func (u *UserService) Enable(ctx context.Context, id string) (user *models.User, err error) {
+	ctx, span := u.tracer.Start(ctx, "UserService.Enable")
+	defer func() { tracing.End(span, err) }()
+
+	return u.setDisabled(ctx, id, nil)
+}
+
+// MarkEmailVerified records that the user proved they control their email address.
+func (u *UserService) MarkEmailVerified(ctx context.Context, id string) (err error) {
+	ctx, span := u.tracer.Start(ctx, "UserService.MarkEmailVerified")
+	defer func() { tracing.End(span, err) }()
+
+	return u.userRepository.MarkEmailVerified(ctx, id)
+}
+
+func (u *UserService) setDisabled(ctx context.Context, id string, at *time.Time) (*models.User, error) {
+	if err := u.userRepository.SetDisabled(ctx, id, at); err != nil {
+		return nil, err
+	}
+	return u.userRepository.FindById(ctx, id)
 }
@@ -0,0 +1,447 @@
+package service
+
+import (
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/events"
+	"AuthServer/internal/repository"
+	"AuthServer/internal/tracing"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Webhook delivery limits.
+const (
+	// WebhookMaxAttempts is how often a delivery is tried before it is marked failed.
+	WebhookMaxAttempts = 10
+	// WebhookDisableAfter is how many attempts in a row may fail before the subscription is
+	// disabled.
+	WebhookDisableAfter = 20
+	// WebhookSignatureTolerance is how old a signature timestamp receivers should accept.
+	WebhookSignatureTolerance = 5 * time.Minute
+
+	webhookTimeout      = 10 * time.Second
+	webhookLease        = 2 * time.Minute
+	webhookBatchSize    = 50
+	webhookBaseBackoff  = 30 * time.Second
+	webhookMaxBackoff   = 6 * time.Hour
+	webhookResponseSize = 1024
+)
+
+// Webhook request headers.
+const (
+	WebhookSignatureHeader = "X-Webhook-Signature"
+	WebhookEventHeader     = "X-Webhook-Event"
+	WebhookIDHeader        = "X-Webhook-ID"
+)
+
+var (
+	// ErrInvalidWebhook is returned for a subscription with a bad URL or unknown events.
+	ErrInvalidWebhook = errors.New("invalid webhook subscription")
+	// ErrInvalidWebhookSignature is returned when a signature header does not match the body.
+	ErrInvalidWebhookSignature = errors.New("invalid webhook signature")
+)
+
+// WebhookService queues identity and authorization events for subscribed endpoints and
+// delivers them with signed, retried POST requests.
+type WebhookService struct {
+	repo   *repository.WebhookRepository
+	client *http.Client
+	tracer trace.Tracer
+}
+
+// NewWebhookService returns a service that queues every event published on bus for the
+// subscriptions that selected its type.
+func NewWebhookService(repo *repository.WebhookRepository, bus *events.Bus, tracer trace.Tracer) *WebhookService {
+	s := &WebhookService{
+		repo:   repo,
+		tracer: tracer,
+		client: &http.Client{
+			Timeout: webhookTimeout,
+			// A redirect is reported as the response rather than followed
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+	bus.Handle(func(ctx context.Context, e events.Event) {
+		s.Publish(ctx, e.Type, e.Data)
+	})
+	return s
+}
+
+// CreateSubscription validates the subscription and stores it with a new secret, which is
+// returned once.
+func (s *WebhookService) CreateSubscription(ctx context.Context, sub *models.WebhookSubscription) (secret string, err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.CreateSubscription")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateWebhook(sub); err != nil {
+		return "", err
+	}
+
+	secret, err = newWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	sub.Secret = secret
+
+	if err := s.repo.CreateSubscription(ctx, sub); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func (s *WebhookService) GetSubscription(ctx context.Context, id string) (*models.WebhookSubscription, error) {
+	return s.repo.GetSubscription(ctx, id)
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) ([]models.WebhookSubscription, error) {
+	return s.repo.ListSubscriptions(ctx)
+}
+
+// UpdateSubscription saves the changed subscription. Re-activating a subscription that was
+// disabled after repeated failures resumes its pending deliveries.
+func (s *WebhookService) UpdateSubscription(ctx context.Context, sub *models.WebhookSubscription) (err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.UpdateSubscription")
+	defer func() { tracing.End(span, err) }()
+
+	if err := validateWebhook(sub); err != nil {
+		return err
+	}
+	return s.repo.UpdateSubscription(ctx, sub)
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id string) (err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.DeleteSubscription")
+	defer func() { tracing.End(span, err) }()
+
+	return s.repo.DeleteSubscription(ctx, id)
+}
+
+// RotateSecret replaces the subscription's secret and returns the new one.
+func (s *WebhookService) RotateSecret(ctx context.Context, id string) (secret string, err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.RotateSecret")
+	defer func() { tracing.End(span, err) }()
+
+	secret, err = newWebhookSecret()
+	if err != nil {
+		return "", err
+	}
+	if err := s.repo.UpdateSecret(ctx, id, secret); err != nil {
+		return "", err
+	}
+	return secret, nil
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionID, status string, limit int) ([]models.WebhookDelivery, error) {
+	return s.repo.ListDeliveries(ctx, subscriptionID, status, limit)
+}
+
+// Publish queues the event for every active subscriber, due right away, and returns; the
+// webhook-deliveries job sends it with DeliverDue. Failures are logged; publishing never
+// fails the caller.
+func (s *WebhookService) Publish(ctx context.Context, eventType string, data any) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.Publish")
+	defer span.End()
+
+	ctx = context.WithoutCancel(ctx)
+
+	subs, err := s.repo.ListSubscribers(ctx, eventType)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to find webhook subscribers", "type", eventType, "error", err)
+		return
+	}
+	if len(subs) == 0 {
+		return
+	}
+
+	raw, err := json.Marshal(data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode webhook event", "type", eventType, "error", err)
+		return
+	}
+	event := models.WebhookEvent{
+		ID:        uuid.New().String(),
+		Type:      eventType,
+		CreatedAt: time.Now().UTC(),
+		Data:      raw,
+	}
+	payload, err := json.Marshal(event)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to encode webhook event", "type", eventType, "error", err)
+		return
+	}
+
+	due := time.Now()
+	for _, sub := range subs {
+		d := &models.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      eventType,
+			Payload:        payload,
+			NextAttemptAt:  &due,
+		}
+		if err := s.repo.CreateDelivery(ctx, d); err != nil {
+			slog.ErrorContext(ctx, "failed to queue webhook", "type", eventType, "subscription_id", sub.ID, "error", err)
+		}
+	}
+}
+
+// Redeliver queues a new delivery of the same event, with the same event ID, and attempts
+// it right away.
+func (s *WebhookService) Redeliver(ctx context.Context, subscriptionID, deliveryID string) (d *models.WebhookDelivery, err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.Redeliver")
+	defer func() { tracing.End(span, err) }()
+
+	sub, err := s.repo.GetSubscription(ctx, subscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	original, err := s.repo.GetDelivery(ctx, subscriptionID, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	lease := time.Now().Add(webhookLease)
+	d = &models.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        original.EventID,
+		EventType:      original.EventType,
+		Payload:        original.Payload,
+		NextAttemptAt:  &lease,
+		RedeliveryOf:   &original.ID,
+	}
+	if err := s.repo.CreateDelivery(ctx, d); err != nil {
+		return nil, err
+	}
+
+	s.attempt(context.WithoutCancel(ctx), sub, d)
+	return d, nil
+}
+
+// DeliverDue attempts the deliveries whose retry is due, or whose first attempt was lost,
+// and returns how many it attempted.
+func (s *WebhookService) DeliverDue(ctx context.Context) (n int, err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.DeliverDue")
+	defer func() { tracing.End(span, err) }()
+
+	due, err := s.repo.ClaimDue(ctx, webhookBatchSize, webhookLease)
+	if err != nil {
+		return 0, err
+	}
+
+	subs := make(map[string]*models.WebhookSubscription)
+	for i := range due {
+		d := &due[i]
+		sub, ok := subs[d.SubscriptionID]
+		if !ok {
+			if sub, err = s.repo.GetSubscription(ctx, d.SubscriptionID); err != nil {
+				slog.ErrorContext(ctx, "failed to load webhook subscription", "subscription_id", d.SubscriptionID, "error", err)
+				continue
+			}
+			subs[d.SubscriptionID] = sub
+		}
+		s.attempt(ctx, sub, d)
+	}
+
+	return len(due), nil
+}
+
+// attempt sends d once and records the outcome: success, a retry after backoff, or failure
+// once the attempts run out. Every failed attempt counts towards disabling the subscription.
+func (s *WebhookService) attempt(ctx context.Context, sub *models.WebhookSubscription, d *models.WebhookDelivery) {
+	now := time.Now()
+	d.Attempts++
+	d.LastAttemptAt = &now
+	d.ResponseStatus, d.ResponseBody, d.Error = nil, nil, nil
+
+	status, body, err := s.send(ctx, sub, d)
+	if err == nil && (status < 200 || status > 299) {
+		err = fmt.Errorf("endpoint responded with status %d", status)
+	}
+	if status != 0 {
+		d.ResponseStatus = &status
+		d.ResponseBody = &body
+	}
+
+	switch {
+	case err == nil:
+		d.Status = models.WebhookSucceeded
+		d.NextAttemptAt = nil
+	case d.Attempts >= WebhookMaxAttempts:
+		d.Status = models.WebhookFailed
+		d.NextAttemptAt = nil
+	default:
+		next := now.Add(webhookBackoff(d.Attempts))
+		d.NextAttemptAt = &next
+	}
+	if err != nil {
+		message := err.Error()
+		d.Error = &message
+	}
+
+	if err := s.repo.RecordAttempt(ctx, d); err != nil {
+		slog.ErrorContext(ctx, "failed to record webhook delivery", "delivery_id", d.ID, "error", err)
+	}
+
+	if d.Status == models.WebhookSucceeded {
+		if err := s.repo.RecordSuccess(ctx, sub.ID); err != nil {
+			slog.ErrorContext(ctx, "failed to reset webhook subscription failures", "subscription_id", sub.ID, "error", err)
+		}
+		return
+	}
+
+	reason := fmt.Sprintf("disabled after %d failed delivery attempts in a row", WebhookDisableAfter)
+	disabled, err := s.repo.RecordFailure(ctx, sub.ID, WebhookDisableAfter, reason)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to count webhook subscription failure", "subscription_id", sub.ID, "error", err)
+	}
+	if disabled {
+		slog.WarnContext(ctx, "webhook subscription disabled", "subscription_id", sub.ID, "url", sub.URL, "reason", reason)
+	}
+}
+
+// send POSTs the payload and returns the response status and the start of its body.
+func (s *WebhookService) send(ctx context.Context, sub *models.WebhookSubscription, d *models.WebhookDelivery) (status int, body string, err error) {
+	ctx, span := s.tracer.Start(ctx, "WebhookService.send", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("webhook.event_type", d.EventType), attribute.Int("webhook.attempt", d.Attempts)))
+	defer func() {
+		span.SetAttributes(attribute.Int("http.response.status_code", status))
+		tracing.End(span, err)
+	}()
+
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(d.Payload))
+	if err != nil {
+		return 0, "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "AuthServer-Webhooks/1")
+	req.Header.Set(WebhookIDHeader, d.EventID)
+	req.Header.Set(WebhookEventHeader, d.EventType)
+	req.Header.Set(WebhookSignatureHeader, SignWebhookPayload(sub.Secret, time.Now(), d.Payload))
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, "", err
+	}
+	defer resp.Body.Close()
+
+	raw, _ := io.ReadAll(io.LimitReader(resp.Body, webhookResponseSize))
+	return resp.StatusCode, string(raw), nil
+}
+
+// SignWebhookPayload returns the signature header for body sent at t:
+// "t=<unix seconds>,v1=<hex HMAC-SHA256 of "<t>.<body>">". Receivers recompute the HMAC and
+// reject timestamps older than WebhookSignatureTolerance, so a captured request cannot be
+// replayed later.
+func SignWebhookPayload(secret string, t time.Time, body []byte) string {
+	ts := strconv.FormatInt(t.Unix(), 10)
+	return "t=" + ts + ",v1=" + webhookHMAC(secret, ts, body)
+}
+
+// VerifyWebhookSignature checks a signature header produced by SignWebhookPayload. It is the
+// check receivers are expected to make.
+func VerifyWebhookSignature(secret, header string, body []byte, tolerance time.Duration, now time.Time) error {
+	var ts string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			ts = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	unix, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return ErrInvalidWebhookSignature
+	}
+	if age := now.Sub(time.Unix(unix, 0)); age > tolerance || age < -tolerance {
+		return fmt.Errorf("%w: timestamp outside tolerance", ErrInvalidWebhookSignature)
+	}
+
+	expected := webhookHMAC(secret, ts, body)
+	for _, sig := range signatures {
+		if hmac.Equal([]byte(sig), []byte(expected)) {
+			return nil
+		}
+	}
+	return ErrInvalidWebhookSignature
+}
+
+func webhookHMAC(secret, ts string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(ts))
+	mac.Write([]byte{'.'})
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// webhookBackoff is the wait after the given failed attempt: 30s doubling each time, up to
+// six hours.
+func webhookBackoff(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+	backoff := webhookBaseBackoff
+	for i := 1; i < attempt; i++ {
+		backoff *= 2
+		if backoff >= webhookMaxBackoff {
+			return webhookMaxBackoff
+		}
+	}
+	return backoff
+}
+
+func validateWebhook(sub *models.WebhookSubscription) error {
+	u, err := url.Parse(sub.URL)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute http or https URL", ErrInvalidWebhook)
+	}
+	if len(sub.Events) == 0 {
+		return fmt.Errorf("%w: at least one event is required", ErrInvalidWebhook)
+	}
+	for _, event := range sub.Events {
+		if !slices.Contains(events.Types, event) {
+			return fmt.Errorf("%w: unknown event %q", ErrInvalidWebhook, event)
+		}
+	}
+	slices.Sort(sub.Events)
+	sub.Events = slices.Compact(sub.Events)
+	return nil
+}
+
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "whsec_" + hex.EncodeToString(b), nil
+}
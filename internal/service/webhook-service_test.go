@@ -0,0 +1,67 @@
+package service
+
+import (
+	"AuthServer/internal/domain/models"
+	"AuthServer/internal/events"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestWebhookSignature(t *testing.T) {
+	body := []byte(`{"id":"e1","type":"role.assigned"}`)
+	sentAt := time.Unix(1700000000, 0)
+	header := SignWebhookPayload("whsec_test", sentAt, body)
+
+	if err := VerifyWebhookSignature("whsec_test", header, body, WebhookSignatureTolerance, sentAt.Add(time.Minute)); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+	if err := VerifyWebhookSignature("whsec_other", header, body, WebhookSignatureTolerance, sentAt); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Fatalf("signature accepted under another secret: %v", err)
+	}
+	if err := VerifyWebhookSignature("whsec_test", header, []byte(`{}`), WebhookSignatureTolerance, sentAt); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Fatalf("signature accepted for another body: %v", err)
+	}
+	if err := VerifyWebhookSignature("whsec_test", header, body, WebhookSignatureTolerance, sentAt.Add(time.Hour)); !errors.Is(err, ErrInvalidWebhookSignature) {
+		t.Fatalf("replayed signature accepted: %v", err)
+	}
+}
+
+func TestWebhookBackoff(t *testing.T) {
+	cases := map[int]time.Duration{
+		1:  30 * time.Second,
+		2:  time.Minute,
+		5:  8 * time.Minute,
+		10: 4*time.Hour + 16*time.Minute,
+		12: 6 * time.Hour,
+	}
+	for attempt, want := range cases {
+		if got := webhookBackoff(attempt); got != want {
+			t.Errorf("backoff after attempt %d: got %s, want %s", attempt, got, want)
+		}
+	}
+}
+
+func TestValidateWebhook(t *testing.T) {
+	sub := &models.WebhookSubscription{
+		URL:    "https://example.com/hooks",
+		Events: []string{events.RoleRevoked, events.RoleAssigned, events.RoleRevoked},
+	}
+	if err := validateWebhook(sub); err != nil {
+		t.Fatal(err)
+	}
+	if len(sub.Events) != 2 {
+		t.Fatalf("duplicate events kept: %v", sub.Events)
+	}
+
+	for _, bad := range []models.WebhookSubscription{
+		{URL: "ftp://example.com", Events: []string{events.RoleAssigned}},
+		{URL: "/relative", Events: []string{events.RoleAssigned}},
+		{URL: "https://example.com", Events: []string{"role.renamed"}},
+		{URL: "https://example.com"},
+	} {
+		if err := validateWebhook(&bad); !errors.Is(err, ErrInvalidWebhook) {
+			t.Errorf("expected %+v to be invalid, got %v", bad, err)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package tracing sets up OpenTelemetry tracing. The tracer provider is built from
+// configuration and handed to the app rather than installed globally, so tests can pass one
+// backed by an in-memory exporter. Trace context crosses process boundaries in W3C
+// traceparent and tracestate headers.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Scope is the instrumentation scope of the server's own spans.
+const Scope = "AuthServer"
+
+// serviceName identifies the server in exported spans.
+const serviceName = "authserver"
+
+// Exporters.
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+// Config selects where spans are exported.
+type Config struct {
+	Exporter string `yaml:"exporter" env:"TRACING_EXPORTER"` // otlp, stdout or none
+	// Endpoint is the OTLP/HTTP collector URL, such as http://localhost:4318. When empty the
+	// exporter reads OTEL_EXPORTER_OTLP_ENDPOINT and falls back to localhost.
+	Endpoint string `yaml:"endpoint" env:"TRACING_ENDPOINT"`
+}
+
+// DefaultConfig exports nothing.
+var DefaultConfig = Config{Exporter: ExporterNone}
+
+// Validate reports whether the exporter is known and the endpoint is a URL.
+func (c Config) Validate() error {
+	switch c.Exporter {
+	case ExporterOTLP, ExporterStdout, ExporterNone:
+	default:
+		return fmt.Errorf("tracing.exporter must be otlp, stdout or none, not %q", c.Exporter)
+	}
+	if c.Endpoint != "" {
+		if u, err := url.Parse(c.Endpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("tracing.endpoint must be an absolute http(s) URL")
+		}
+	}
+	return nil
+}
+
+// Propagator reads and writes the W3C traceparent and tracestate headers.
+var Propagator propagation.TextMapPropagator = propagation.TraceContext{}
+
+// NewProvider returns a tracer provider exporting as cfg describes, and a function that
+// flushes and stops it on shutdown. With no exporter spans are not recorded at all.
+func NewProvider(ctx context.Context, cfg Config) (trace.TracerProvider, func(context.Context) error, error) {
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return noop.NewTracerProvider(), func(context.Context) error { return nil }, nil
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create %s trace exporter: %v", cfg.Exporter, err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	return tp, tp.Shutdown, nil
+}
+
+// End records err, if any, on span and ends it. Call it deferred with the function's named
+// error result.
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}